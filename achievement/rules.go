@@ -0,0 +1,106 @@
+package achievement
+
+// Events that can earn a user badges
+const (
+	EventPostCreated     = "post_created"
+	EventCommentCreated  = "comment_created"
+	EventLikeReceived    = "like_received"
+	EventEcoActionLogged = "eco_action_logged"
+)
+
+// What rules count, for the user the event happened to
+const (
+	MetricPosts         = "posts"
+	MetricComments      = "comments"
+	MetricLikesReceived = "likes_received"
+	MetricEcoActions    = "eco_actions"
+	MetricCO2eSavedKg   = "co2e_saved_kg"
+	// Longest run of consecutive days, in UTC, with a post, comment or eco-action
+	MetricStreakDays = "streak_days"
+)
+
+// Events after which each metric may have grown
+var metricEvents = map[string][]string{
+	MetricPosts:         {EventPostCreated},
+	MetricComments:      {EventCommentCreated},
+	MetricLikesReceived: {EventLikeReceived},
+	MetricEcoActions:    {EventEcoActionLogged},
+	MetricCO2eSavedKg:   {EventEcoActionLogged},
+	MetricStreakDays:    {EventPostCreated, EventCommentCreated, EventEcoActionLogged},
+}
+
+/*
+Rule struct, a badge and what earns it
+
+A user earns the badge once the metric reaches the threshold. Badges are
+never taken away, even when the metric drops again.
+
+Fields:
+  - Badge:       string  - Unique identifier of the badge, never change it once awarded
+  - Name:        string  - Human readable name
+  - Description: string  - What earns the badge
+  - Metric:      string  - One of the Metric* metrics
+  - Threshold:   float64 - Value of the metric that earns the badge
+*/
+type Rule struct {
+	Badge       string  `json:"badge"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Metric      string  `json:"metric"`
+	Threshold   float64 `json:"threshold"`
+}
+
+// Every badge, in the order clients should show them
+var Rules = []Rule{
+	{Badge: "first_post", Name: "First post", Description: "Shared a first post", Metric: MetricPosts, Threshold: 1},
+	{Badge: "storyteller", Name: "Storyteller", Description: "Shared 50 posts", Metric: MetricPosts, Threshold: 50},
+	{Badge: "first_comment", Name: "Conversation starter", Description: "Wrote a first comment", Metric: MetricComments, Threshold: 1},
+	{Badge: "likes_10", Name: "Rising star", Description: "Received 10 likes", Metric: MetricLikesReceived, Threshold: 10},
+	{Badge: "likes_100", Name: "Crowd favourite", Description: "Received 100 likes", Metric: MetricLikesReceived, Threshold: 100},
+	{Badge: "first_eco_action", Name: "First step", Description: "Logged a first eco-action", Metric: MetricEcoActions, Threshold: 1},
+	{Badge: "co2e_100kg", Name: "Carbon cutter", Description: "Saved 100 kg of CO2e", Metric: MetricCO2eSavedKg, Threshold: 100},
+	{Badge: "streak_7", Name: "Week streak", Description: "Was active 7 days in a row", Metric: MetricStreakDays, Threshold: 7},
+	{Badge: "streak_30", Name: "Month streak", Description: "Was active 30 days in a row", Metric: MetricStreakDays, Threshold: 30},
+}
+
+// Returns the rules an event can satisfy
+func RulesFor(event string) []Rule {
+	var rules []Rule
+	for _, rule := range Rules {
+		for _, metricEvent := range metricEvents[rule.Metric] {
+			if metricEvent == event {
+				rules = append(rules, rule)
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// Returns the rule of a badge
+func Find(badge string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Badge == badge {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Returns the metrics the rules count, each once
+func MetricsOf(rules []Rule) []string {
+	var metrics []string
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if !seen[rule.Metric] {
+			seen[rule.Metric] = true
+			metrics = append(metrics, rule.Metric)
+		}
+	}
+	return metrics
+}
+
+// Reports whether the values of the metrics earn the badge
+func (rule Rule) Met(values map[string]float64) bool {
+	return values[rule.Metric] >= rule.Threshold
+}
@@ -0,0 +1,92 @@
+package achievement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func badgesOf(rules []Rule) []string {
+	var badges []string
+	for _, rule := range rules {
+		badges = append(badges, rule.Badge)
+	}
+	return badges
+}
+
+func TestRulesFor(t *testing.T) {
+	tests := []struct {
+		event   string
+		badges  []string
+		metrics []string
+	}{
+		{EventPostCreated, []string{"first_post", "storyteller", "streak_7", "streak_30"}, []string{MetricPosts, MetricStreakDays}},
+		{EventCommentCreated, []string{"first_comment", "streak_7", "streak_30"}, []string{MetricComments, MetricStreakDays}},
+		{EventLikeReceived, []string{"likes_10", "likes_100"}, []string{MetricLikesReceived}},
+		{EventEcoActionLogged, []string{"first_eco_action", "co2e_100kg", "streak_7", "streak_30"}, []string{MetricEcoActions, MetricCO2eSavedKg, MetricStreakDays}},
+		{"unknown_event", nil, nil},
+	}
+
+	for _, test := range tests {
+		rules := RulesFor(test.event)
+		if badges := badgesOf(rules); !reflect.DeepEqual(badges, test.badges) {
+			t.Errorf("RulesFor(%q) = %v, want %v", test.event, badges, test.badges)
+		}
+		if metrics := MetricsOf(rules); !reflect.DeepEqual(metrics, test.metrics) {
+			t.Errorf("MetricsOf(RulesFor(%q)) = %v, want %v", test.event, metrics, test.metrics)
+		}
+	}
+}
+
+func TestEveryRuleCanBeEarned(t *testing.T) {
+	seen := map[string]bool{}
+	for _, rule := range Rules {
+		if seen[rule.Badge] {
+			t.Errorf("badge %q is defined twice", rule.Badge)
+		}
+		seen[rule.Badge] = true
+
+		if len(metricEvents[rule.Metric]) == 0 {
+			t.Errorf("badge %q counts metric %q, which no event updates", rule.Badge, rule.Metric)
+		}
+		if rule.Threshold <= 0 {
+			t.Errorf("badge %q has threshold %v, it would be earned without doing anything", rule.Badge, rule.Threshold)
+		}
+	}
+}
+
+func TestMet(t *testing.T) {
+	firstPost, _ := Find("first_post")
+	storyteller, _ := Find("storyteller")
+	carbonCutter, _ := Find("co2e_100kg")
+
+	tests := []struct {
+		rule   Rule
+		values map[string]float64
+		want   bool
+	}{
+		{firstPost, map[string]float64{MetricPosts: 1}, true},
+		{firstPost, map[string]float64{MetricPosts: 0}, false},
+		{firstPost, map[string]float64{MetricComments: 5}, false},
+		{storyteller, map[string]float64{MetricPosts: 49}, false},
+		{storyteller, map[string]float64{MetricPosts: 50}, true},
+		{carbonCutter, map[string]float64{MetricCO2eSavedKg: 99.9}, false},
+		{carbonCutter, map[string]float64{MetricCO2eSavedKg: 100.5}, true},
+	}
+
+	for _, test := range tests {
+		if got := test.rule.Met(test.values); got != test.want {
+			t.Errorf("%s.Met(%v) = %v, want %v", test.rule.Badge, test.values, got, test.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	rule, ok := Find("likes_100")
+	if !ok || rule.Metric != MetricLikesReceived || rule.Threshold != 100 {
+		t.Errorf("Find(likes_100) = %+v, %v", rule, ok)
+	}
+
+	if _, ok := Find("does_not_exist"); ok {
+		t.Error("Find returned a rule for an unknown badge")
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/ecofriends/authentication-backend/database"
+	"github.com/ecofriends/authentication-backend/realtime"
 	"github.com/ecofriends/authentication-backend/route"
 	"github.com/joho/godotenv"
 )
@@ -16,12 +19,16 @@ import (
 type App struct {
 	router   http.Handler
 	database *sql.DB
+	hub      *realtime.Hub
 }
 
 func New(db *sql.DB) *App {
+	hub := realtime.NewHub()
+
 	app := &App{
-		router:   route.LoadRoutes(db),
+		router:   route.LoadRoutes(db, hub),
 		database: db,
+		hub:      hub,
 	}
 
 	return app
@@ -43,10 +50,25 @@ func (app *App) Start(ctx context.Context) error {
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", port),
 		Handler: app.router,
+		// Shutdown waits for requests to finish, event streams only end when their context does
+		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
 
 	errorChan := make(chan error, 1)
 
+	// Remove accounts once their deletion grace period has passed
+	go app.purgeDeletedAccounts(ctx)
+
+	// Keep the leaderboard scores current
+	go app.refreshLeaderboards(ctx)
+
+	// Deliver the events published by every server instance to the connected clients
+	go func() {
+		if err := realtime.Listen(ctx, database.ConnectionString(), app.hub); err != nil {
+			log.Println("[FAIL]: unable to listen for real-time events:", err)
+		}
+	}()
+
 	// Handle server listening on port in a goroutine
 	go func() {
 		err := server.ListenAndServe()
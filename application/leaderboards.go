@@ -0,0 +1,52 @@
+package application
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	repository "github.com/ecofriends/authentication-backend/repository"
+)
+
+// How often leaderboard scores are recomputed unless LEADERBOARD_REFRESH_INTERVAL says otherwise
+const defaultLeaderboardRefreshInterval = 5 * time.Minute
+
+/*
+Recomputes the leaderboard scores periodically, until the context is
+cancelled
+
+Params:
+  - ctx: The application context
+
+Returns:
+  - No return value
+*/
+func (app *App) refreshLeaderboards(ctx context.Context) {
+	repo := &repository.PostGreSQL{Database: app.database}
+
+	interval := defaultLeaderboardRefreshInterval
+	if value := os.Getenv("LEADERBOARD_REFRESH_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("[FAIL]: invalid LEADERBOARD_REFRESH_INTERVAL %q, using %s", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := repo.RefreshLeaderboards(ctx); err != nil {
+			log.Println("[FAIL]: could not refresh leaderboards:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
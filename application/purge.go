@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+)
+
+// How often accounts past their deletion grace period are removed
+const purgeInterval = time.Hour
+
+/*
+Hard deletes accounts whose deletion grace period has passed, until the
+context is cancelled
+
+Params:
+  - ctx: The application context
+
+Returns:
+  - No return value
+*/
+func (app *App) purgeDeletedAccounts(ctx context.Context) {
+	repo := &repository.PostGreSQL{Database: app.database}
+	store := storage.FromEnvironment()
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purgeDeletedAccountsOnce(ctx, repo, store)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+Hard deletes the accounts past their grace period together with the
+images they uploaded
+
+Params:
+  - ctx:   The application context
+  - repo:  The application repository
+  - store: The storage the images are kept in
+
+Returns:
+  - No return value
+*/
+func purgeDeletedAccountsOnce(ctx context.Context, repo *repository.PostGreSQL, store storage.Storage) {
+	deletedBefore := time.Now().Add(-model.AccountDeletionGracePeriod)
+
+	// The keys have to be read before the rows referencing them are gone
+	keys, err := repo.GetMediaKeysOfDeletedUsers(ctx, deletedBefore)
+	if err != nil {
+		log.Println("[FAIL]: could not get media of deleted accounts:", err)
+		return
+	}
+
+	purged, err := repo.PurgeDeletedUsers(ctx, deletedBefore)
+	if err != nil {
+		log.Println("[FAIL]: could not purge deleted accounts:", err)
+		return
+	}
+
+	if purged > 0 {
+		log.Printf("[LOG]: purged %d deleted accounts", purged)
+	}
+
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Println("[FAIL]: could not delete media of deleted account:", err)
+		}
+	}
+}
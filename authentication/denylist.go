@@ -0,0 +1,269 @@
+package authentication
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// How long a "not revoked" answer is trusted before asking the database again.
+// Revocations made by this instance are visible immediately, revocations made
+// by other instances are picked up within this window.
+const denylistCacheTTL = 30 * time.Second
+
+// Number of cached entries above which expired entries are swept
+const denylistSweepThreshold = 10000
+
+// Storage the denylist persists revocations in, implemented by the repository
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeAllUserTokens(ctx context.Context, userID string) (time.Time, error)
+	GetTokensRevokedAt(ctx context.Context, userID string) (*time.Time, error)
+}
+
+/*
+Denylist of revoked access tokens
+
+Revocations are persisted in PostgreSQL and cached in memory, so the
+authentication middleware only reaches the database for tokens it has not
+seen recently.
+*/
+type Denylist struct {
+	repo RevocationStore
+
+	mu     sync.Mutex
+	tokens map[string]cachedRevocation
+	users  map[string]cachedCutoff
+}
+
+type cachedRevocation struct {
+	revoked bool
+	until   time.Time
+}
+
+type cachedCutoff struct {
+	revokedAt *time.Time
+	until     time.Time
+}
+
+var denylist *Denylist
+
+func NewDenylist(repo RevocationStore) *Denylist {
+	return &Denylist{
+		repo:   repo,
+		tokens: make(map[string]cachedRevocation),
+		users:  make(map[string]cachedCutoff),
+	}
+}
+
+/*
+Sets the denylist consulted by the package level revocation functions
+
+Params:
+  - list: The denylist to use
+
+Returns:
+  - No return value
+*/
+func UseDenylist(list *Denylist) {
+	denylist = list
+}
+
+/*
+Revokes a single access token
+
+Params:
+  - ctx:    The request context
+  - claims: The claims of the token to revoke
+
+Returns:
+  - An error if the revocation could not be stored
+*/
+func RevokeToken(ctx context.Context, claims jwt.MapClaims) error {
+	if denylist == nil {
+		return fmt.Errorf("[FAIL]: token denylist is not configured")
+	}
+	return denylist.Revoke(ctx, claims)
+}
+
+/*
+Revokes every access token issued to a user up to now
+
+Params:
+  - ctx:    The request context
+  - userID: The ID of the user
+
+Returns:
+  - An error if the revocation could not be stored
+*/
+func RevokeAllTokens(ctx context.Context, userID string) error {
+	if denylist == nil {
+		return fmt.Errorf("[FAIL]: token denylist is not configured")
+	}
+	return denylist.RevokeAll(ctx, userID)
+}
+
+/*
+Reports whether a verified token has been revoked
+
+Params:
+  - ctx:    The request context
+  - claims: The claims of the verified token
+
+Returns:
+  - True if the token has been revoked, false otherwise
+  - An error if the revocation status could not be determined
+*/
+func IsTokenRevoked(ctx context.Context, claims jwt.MapClaims) (bool, error) {
+	if denylist == nil {
+		return false, nil
+	}
+	return denylist.IsRevoked(ctx, claims)
+}
+
+func (list *Denylist) Revoke(ctx context.Context, claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	userID, _ := claims["sub"].(string)
+	if jti == "" || userID == "" {
+		return fmt.Errorf("[FAIL]: token has no jti or subject")
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return fmt.Errorf("[FAIL]: token has no expiration time")
+	}
+
+	if err := list.repo.RevokeToken(ctx, jti, userID, exp.Time); err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.tokens[jti] = cachedRevocation{revoked: true, until: exp.Time}
+	list.sweep()
+
+	return nil
+}
+
+func (list *Denylist) RevokeAll(ctx context.Context, userID string) error {
+	revokedAt, err := list.repo.RevokeAllUserTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.users[userID] = cachedCutoff{revokedAt: &revokedAt, until: time.Now().Add(denylistCacheTTL)}
+	list.sweep()
+
+	return nil
+}
+
+func (list *Denylist) IsRevoked(ctx context.Context, claims jwt.MapClaims) (bool, error) {
+	userID, _ := claims["sub"].(string)
+	jti, _ := claims["jti"].(string)
+
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return true, fmt.Errorf("[FAIL]: token has no issued at time")
+	}
+
+	// Tokens issued at or before a "sign out of all devices" are revoked
+	revokedAt, err := list.userCutoff(ctx, userID)
+	if err != nil {
+		return true, err
+	}
+	if revokedAt != nil && !iat.Time.After(*revokedAt) {
+		return true, nil
+	}
+
+	// Tokens issued before jti was introduced can only be revoked in bulk
+	if jti == "" {
+		return false, nil
+	}
+
+	return list.tokenRevoked(ctx, jti, claims)
+}
+
+func (list *Denylist) userCutoff(ctx context.Context, userID string) (*time.Time, error) {
+	list.mu.Lock()
+	cached, ok := list.users[userID]
+	list.mu.Unlock()
+
+	if ok && time.Now().Before(cached.until) {
+		return cached.revokedAt, nil
+	}
+
+	revokedAt, err := list.repo.GetTokensRevokedAt(ctx, userID)
+	if err != nil {
+		// A token for a deleted user is as good as revoked
+		if strings.Contains(err.Error(), "not found") {
+			now := time.Now()
+			return &now, nil
+		}
+		return nil, err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.users[userID] = cachedCutoff{revokedAt: revokedAt, until: time.Now().Add(denylistCacheTTL)}
+	list.sweep()
+
+	return revokedAt, nil
+}
+
+func (list *Denylist) tokenRevoked(ctx context.Context, jti string, claims jwt.MapClaims) (bool, error) {
+	list.mu.Lock()
+	cached, ok := list.tokens[jti]
+	list.mu.Unlock()
+
+	if ok && time.Now().Before(cached.until) {
+		return cached.revoked, nil
+	}
+
+	revoked, err := list.repo.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return true, err
+	}
+
+	// A revoked token stays revoked until it expires on its own
+	until := time.Now().Add(denylistCacheTTL)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil && (revoked || exp.Time.Before(until)) {
+		until = exp.Time
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.tokens[jti] = cachedRevocation{revoked: revoked, until: until}
+	list.sweep()
+
+	return revoked, nil
+}
+
+// Drops expired cache entries once the cache grows large, callers must hold the lock
+func (list *Denylist) sweep() {
+	if len(list.tokens)+len(list.users) < denylistSweepThreshold {
+		return
+	}
+
+	now := time.Now()
+	for jti, entry := range list.tokens {
+		if now.After(entry.until) {
+			delete(list.tokens, jti)
+		}
+	}
+	for userID, entry := range list.users {
+		if now.After(entry.until) {
+			delete(list.users, userID)
+		}
+	}
+}
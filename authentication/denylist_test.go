@@ -0,0 +1,152 @@
+package authentication
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// In-memory revocation store standing in for the database
+type fakeRevocationStore struct {
+	mu        sync.Mutex
+	tokens    map[string]bool
+	revokedAt map[string]time.Time
+}
+
+func newFakeRevocationStore() *fakeRevocationStore {
+	return &fakeRevocationStore{
+		tokens:    make(map[string]bool),
+		revokedAt: make(map[string]time.Time),
+	}
+}
+
+func (store *fakeRevocationStore) RevokeToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.tokens[jti] = true
+	return nil
+}
+
+func (store *fakeRevocationStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.tokens[jti], nil
+}
+
+func (store *fakeRevocationStore) RevokeAllUserTokens(ctx context.Context, userID string) (time.Time, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	now := time.Now().Truncate(time.Microsecond)
+	store.revokedAt[userID] = now
+	return now, nil
+}
+
+func (store *fakeRevocationStore) GetTokensRevokedAt(ctx context.Context, userID string) (*time.Time, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if revokedAt, ok := store.revokedAt[userID]; ok {
+		return &revokedAt, nil
+	}
+	return nil, nil
+}
+
+func claimsIssuedAt(userID string, jti string, iat time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": userID,
+		"jti": jti,
+		"iat": float64(iat.UnixMicro()) / 1e6,
+		"exp": float64(iat.Add(AccessTokenTTL).Unix()),
+	}
+}
+
+func TestIsRevokedComparesIssueTimeWithTheCutoff(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeRevocationStore()
+	list := NewDenylist(store)
+
+	if err := list.RevokeAll(ctx, "user"); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+	cutoff := store.revokedAt["user"]
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"earlier second", cutoff.Add(-time.Second), true},
+		{"earlier in the same second", cutoff.Add(-time.Millisecond), true},
+		{"at the cutoff", cutoff, true},
+		{"right after the cutoff", cutoff.Add(time.Millisecond), false},
+	}
+
+	for _, test := range tests {
+		revoked, err := list.IsRevoked(ctx, claimsIssuedAt("user", test.name, test.issuedAt))
+		if err != nil {
+			t.Fatalf("%s: IsRevoked: %v", test.name, err)
+		}
+		if revoked != test.revoked {
+			t.Errorf("%s: revoked = %v, want %v", test.name, revoked, test.revoked)
+		}
+	}
+}
+
+// Tokens issued before issue times had microseconds only carry whole seconds
+func TestIsRevokedWithWholeSecondIssueTime(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeRevocationStore()
+	list := NewDenylist(store)
+
+	if err := list.RevokeAll(ctx, "user"); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+
+	claims := claimsIssuedAt("user", "legacy", store.revokedAt["user"])
+	claims["iat"] = float64(store.revokedAt["user"].Unix())
+
+	revoked, err := list.IsRevoked(ctx, claims)
+	if err != nil {
+		t.Fatalf("IsRevoked: %v", err)
+	}
+	if !revoked {
+		t.Error("token issued in the second of the cutoff was not revoked")
+	}
+}
+
+func TestIsRevokedRejectsRevokedToken(t *testing.T) {
+	ctx := context.Background()
+	list := NewDenylist(newFakeRevocationStore())
+
+	claims := claimsIssuedAt("user", "revoked", time.Now())
+	if err := list.Revoke(ctx, claims); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	revoked, err := list.IsRevoked(ctx, claims)
+	if err != nil {
+		t.Fatalf("IsRevoked: %v", err)
+	}
+	if !revoked {
+		t.Error("revoked token was accepted")
+	}
+
+	other, err := list.IsRevoked(ctx, claimsIssuedAt("user", "other", time.Now()))
+	if err != nil {
+		t.Fatalf("IsRevoked: %v", err)
+	}
+	if other {
+		t.Error("revoking one token revoked another")
+	}
+}
+
+func TestIsRevokedRequiresIssuedAt(t *testing.T) {
+	list := NewDenylist(newFakeRevocationStore())
+
+	revoked, err := list.IsRevoked(context.Background(), jwt.MapClaims{"sub": "user", "jti": "jti"})
+	if err == nil || !revoked {
+		t.Errorf("IsRevoked without iat = %v, %v, want revoked with an error", revoked, err)
+	}
+}
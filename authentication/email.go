@@ -0,0 +1,107 @@
+package authentication
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// How long an email verification link remains valid
+const EmailVerificationTTL = 24 * time.Hour
+
+// Audience of email verification tokens, keeps them from being used as access tokens
+const emailVerificationAudience = "verify-email"
+
+/*
+Email verification token claims
+
+Fields:
+  - JTI:    string - Unique identifier, used to make the token single-use
+  - UserID: string - ID of the user being verified
+  - Email:  string - The address being verified
+*/
+type EmailVerificationClaims struct {
+	JTI    string
+	UserID string
+	Email  string
+}
+
+/*
+Creates a signed email verification token
+
+Params:
+  - userID: The ID of the user being verified
+  - email:  The address being verified
+
+Returns:
+  - The signed token
+  - The token's unique identifier
+  - An error if the token could not be signed
+*/
+func CreateEmailVerificationToken(userID uuid.UUID, email string) (string, string, error) {
+	secretKey, err := loadSecretKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	jti := uuid.New().String()
+
+	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti":   jti,
+		"sub":   userID,
+		"email": email,
+		"aud":   emailVerificationAudience,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(EmailVerificationTTL).Unix(),
+	})
+
+	tokenString, err := claims.SignedString(secretKey)
+	if err != nil {
+		return "", "", fmt.Errorf("[FAIL]: could not sign token: %w", err)
+	}
+
+	return tokenString, jti, nil
+}
+
+/*
+Verifies an email verification token
+
+Params:
+  - tokenString: The signed token
+
+Returns:
+  - The token claims
+  - An error if the token is invalid, expired or not a verification token
+*/
+func VerifyEmailVerificationToken(tokenString string) (EmailVerificationClaims, error) {
+	secretKey, err := loadSecretKey()
+	if err != nil {
+		return EmailVerificationClaims{}, err
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return secretKey, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience(emailVerificationAudience), jwt.WithExpirationRequired())
+
+	if err != nil {
+		return EmailVerificationClaims{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return EmailVerificationClaims{}, fmt.Errorf("[FAIL]: invalid token sent")
+	}
+
+	var verification EmailVerificationClaims
+	verification.JTI, _ = claims["jti"].(string)
+	verification.UserID, _ = claims["sub"].(string)
+	verification.Email, _ = claims["email"].(string)
+
+	if verification.JTI == "" || verification.UserID == "" || verification.Email == "" {
+		return EmailVerificationClaims{}, fmt.Errorf("[FAIL]: incomplete token claims")
+	}
+
+	return verification, nil
+}
@@ -11,23 +11,26 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// Issue times carry microseconds, so a token issued right after a "sign out of
+// all devices" can be told apart from one issued earlier in the same second
+func init() {
+	jwt.TimePrecision = time.Microsecond
+}
+
 func CreateJWToken(userID uuid.UUID) (string, error) {
-	// Load environment variables from .env file in development
-	if env := os.Getenv("ENVIRONMENT"); env != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			return "", fmt.Errorf("[FAIL]: could not load environment variables: %w", err)
-		}
+	secretKey, err := loadSecretKey()
+	if err != nil {
+		return "", err
 	}
 
-	var secretKey = []byte(os.Getenv("JWT_SECRET_KEY"))
-
+	now := time.Now()
 	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti":    uuid.New().String(),
 		"sub":    userID,
 		"issuer": "go-auth-server",
 		"aud":    "user",
-		"iat":    time.Now().Unix(),
-		"exp":    time.Now().Add(time.Hour).Unix(),
+		"iat":    jwt.NewNumericDate(now),
+		"exp":    now.Add(AccessTokenTTL).Unix(),
 	})
 
 	log.Printf("[SUCCESS]: token claims added: %+v\n", claims)
@@ -41,20 +44,15 @@ func CreateJWToken(userID uuid.UUID) (string, error) {
 }
 
 func VerifyToken(tokenString string) (*jwt.Token, error) {
-	// Load environment variables from .env file in development
-	if env := os.Getenv("ENVIRONMENT"); env != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			return nil, fmt.Errorf("[FAIL]: could not load environment variables: %w", err)
-		}
+	secretKey, err := loadSecretKey()
+	if err != nil {
+		return nil, err
 	}
 
-	var secretKey = []byte(os.Getenv("JWT_SECRET_KEY"))
-
-	// Verify token
+	// Verify token, only access tokens are accepted
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return secretKey, nil
-	})
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience("user"))
 
 	if err != nil {
 		return nil, err
@@ -66,3 +64,29 @@ func VerifyToken(tokenString string) (*jwt.Token, error) {
 
 	return token, nil
 }
+
+/*
+Reads the key tokens are signed with
+
+Objectives:
+  - Load environment variables from the .env file in development
+  - Read the JWT secret key
+
+Params:
+  - No parameters
+
+Returns:
+  - The secret key
+  - An error if the environment variables could not be loaded
+*/
+func loadSecretKey() ([]byte, error) {
+	// Load environment variables from .env file in development
+	if env := os.Getenv("ENVIRONMENT"); env != "production" {
+		err := godotenv.Load()
+		if err != nil {
+			return nil, fmt.Errorf("[FAIL]: could not load environment variables: %w", err)
+		}
+	}
+
+	return []byte(os.Getenv("JWT_SECRET_KEY")), nil
+}
@@ -0,0 +1,102 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// How long an access token, a refresh token and a password reset token remain valid
+const (
+	AccessTokenTTL   = time.Hour
+	RefreshTokenTTL  = 30 * 24 * time.Hour
+	PasswordResetTTL = time.Hour
+)
+
+/*
+Generates a new opaque refresh token
+
+Params:
+  - No parameters
+
+Returns:
+  - The refresh token
+  - An error if the random source could not be read
+*/
+func CreateRefreshToken() (string, error) {
+	return createOpaqueToken()
+}
+
+/*
+Hashes a refresh token for storage
+
+Refresh tokens carry enough entropy that a plain SHA-256 is sufficient, and
+unlike bcrypt it lets the token be looked up by its hash.
+
+Params:
+  - token: The refresh token to hash
+
+Returns:
+  - The hex encoded hash
+*/
+func HashRefreshToken(token string) string {
+	return hashOpaqueToken(token)
+}
+
+/*
+Generates a new opaque password reset token
+
+Params:
+  - No parameters
+
+Returns:
+  - The password reset token
+  - An error if the random source could not be read
+*/
+func CreatePasswordResetToken() (string, error) {
+	return createOpaqueToken()
+}
+
+/*
+Hashes a password reset token for storage, see HashRefreshToken
+
+Params:
+  - token: The password reset token to hash
+
+Returns:
+  - The hex encoded hash
+*/
+func HashPasswordResetToken(token string) string {
+	return hashOpaqueToken(token)
+}
+
+/*
+Generates a random URL safe token
+
+Objectives:
+  - Read 32 random bytes
+  - Encode them as a URL safe string
+
+Params:
+  - No parameters
+
+Returns:
+  - The token
+  - An error if the random source could not be read
+*/
+func createOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("[FAIL]: could not generate token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/ecofriends/authentication-backend/database"
+	repository "github.com/ecofriends/authentication-backend/repository"
+)
+
+// Number of users read from the database at a time
+const batchSize = 500
+
+/*
+Evaluates every achievement rule against historical data
+
+Objectives:
+  - Award the badges users earned before the rules existed or changed
+  - Process every user not awaiting deletion, or only the one given with -user
+  - Never award a badge twice, so the command can be run again at any time
+
+Badges awarded by the backfill are dated when the command runs.
+
+Params:
+  - No parameters
+
+Returns:
+  - No return value
+*/
+func main() {
+	userID := flag.String("user", "", "Only backfill the badges of this user ID")
+	flag.Parse()
+
+	db, err := database.ConnectDatabase()
+	if err != nil {
+		log.Fatal("[FATAL]: unable to connect database: ", err)
+	}
+	defer db.Close()
+
+	// Stop between users on interrupt
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	repo := &repository.PostGreSQL{Database: db}
+
+	if *userID != "" {
+		backfillUser(ctx, repo, *userID)
+		return
+	}
+
+	users, awarded := 0, 0
+	after := ""
+	for ctx.Err() == nil {
+		ids, err := repo.GetUserIDs(ctx, after, batchSize)
+		if err != nil {
+			log.Fatal("[FATAL]: could not get users: ", err)
+		}
+
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				break
+			}
+			awarded += backfillUser(ctx, repo, id)
+			users++
+		}
+
+		if len(ids) < batchSize {
+			break
+		}
+		after = ids[len(ids)-1]
+	}
+
+	log.Printf("[SUCCESS]: evaluated badges of %d users, awarded %d badges", users, awarded)
+}
+
+// Backfills the badges of a user, returning the number of badges awarded
+func backfillUser(ctx context.Context, repo *repository.PostGreSQL, userID string) int {
+	badges, err := repo.BackfillBadges(ctx, userID)
+	if err != nil {
+		log.Printf("[FAIL]: could not backfill badges of user %s: %v", userID, err)
+		return 0
+	}
+
+	for _, badge := range badges {
+		log.Printf("[LOG]: awarded %s to user %s", badge.ID, userID)
+	}
+
+	return len(badges)
+}
@@ -45,6 +45,11 @@ func getConnectionString() string {
 	)
 }
 
+// Returns the URL of the application database, also used to listen for real-time events
+func ConnectionString() string {
+	return getConnectionString()
+}
+
 func runMigrations(dbURL, migrationsPath string) error {
 	m, err := migrate.New(
 		"file://"+migrationsPath,
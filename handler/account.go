@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	shared "github.com/ecofriends/authentication-backend/handler/auth/shared"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	"github.com/ecofriends/authentication-backend/service"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+// Number of rows read from the database at a time while exporting
+const exportPageSize = 500
+
+// DeleteMe schedules the authenticated user's account for deletion
+// @Summary Delete the authenticated user
+// @Description Signs the user out everywhere and deletes the account with all of its posts, comments and reactions after a 30 day grace period. Signing in again during the grace period cancels the deletion.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body util.DeleteAccountRequestBody false "Current password, required when the account has one"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me [delete]
+func (user *User) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	var body = util.DeleteAccountRequestBody{}
+	var msg = ""
+
+	// The body is optional for users without a password
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			msg = "Bad request, could not read request body"
+			util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+			return
+		}
+	}
+
+	util.SanitizeUserInput(&body)
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	theUser, err := user.repo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		msg = "Internal server error, failed to get user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Confirm the password so a hijacked session cannot delete the account
+	if theUser.Password != "" && !util.CompareWithHash([]byte(theUser.Password), body.Password) {
+		msg = "Provided passwords mismatch"
+		util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		return
+	}
+
+	deletedAt, err := user.repo.SoftDeleteUser(r.Context(), userID)
+	if err != nil {
+		msg = "Internal server error, failed to delete user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	dbService := &service.DatabaseProvider{Repo: user.repo}
+	if err := shared.EndAllSessions(r.Context(), dbService, userID); err != nil {
+		log.Println(err)
+	}
+
+	util.ExpireCookie(w, "token")
+	util.ExpireCookie(w, "refresh_token")
+
+	payload := map[string]time.Time{
+		"deletion_scheduled_at": deletedAt.Add(model.AccountDeletionGracePeriod),
+	}
+
+	msg = "Successfully scheduled account deletion, sign in again to cancel it"
+	util.JsonResponse(w, msg, http.StatusOK, payload)
+}
+
+// ExportMe exports the authenticated user's data
+// @Summary Export the authenticated user's data
+// @Description Streams a ZIP archive with the profile, linked accounts, posts, comments, reactions and eco-actions of the user as JSON files
+// @Tags user
+// @Produce application/zip
+// @Success 200 {file} file
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me/export [get]
+func (user *User) ExportMe(w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	theUser, err := user.repo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		msg := "Internal server error, failed to get user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	theProfile, err := user.repo.GetProfile(r.Context(), userID)
+	if err != nil {
+		msg := "Internal server error, failed to get profile"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	identities, err := user.repo.GetIdentitiesByUser(r.Context(), userID)
+	if err != nil {
+		msg := "Internal server error, failed to get linked accounts"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	badges, err := user.repo.GetBadgesByUser(r.Context(), userID)
+	if err != nil {
+		msg := "Internal server error, failed to get badges"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	profile := map[string]interface{}{
+		"user":            util.NewUserPayload(theUser),
+		"profile":         theProfile,
+		"linked_accounts": identities,
+		"badges":          badges,
+		"exported_at":     time.Now(),
+	}
+
+	// From here on the response is streamed, errors can only be logged
+	filename := fmt.Sprintf("ecofriends-export-%s.zip", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	archive := zip.NewWriter(w)
+	defer func() {
+		if err := archive.Close(); err != nil {
+			log.Println("[FAIL]: could not finish export archive:", err)
+		}
+	}()
+
+	ctx := r.Context()
+
+	if err := writeExportFile(archive, "profile.json", profile); err != nil {
+		log.Println("[FAIL]: could not export profile:", err)
+		return
+	}
+
+	err = writeExportArray(archive, "posts.json", func(page pagination.Page) (pagination.Response[util.PostPayload], error) {
+		posts, err := user.repo.GetPostsByUser(ctx, userID, page)
+		return pagination.NewResponse(posts, page, postCursor, util.NewPostPayload), err
+	})
+	if err != nil {
+		log.Println("[FAIL]: could not export posts:", err)
+		return
+	}
+
+	err = writeExportArray(archive, "comments.json", func(page pagination.Page) (pagination.Response[util.CommentPayload], error) {
+		comments, err := user.repo.GetCommentsByUser(ctx, userID, page)
+		return pagination.NewResponse(comments, page, commentCursor, util.NewCommentPayload), err
+	})
+	if err != nil {
+		log.Println("[FAIL]: could not export comments:", err)
+		return
+	}
+
+	for _, targetType := range []string{model.ReactionTargetPost, model.ReactionTargetComment} {
+		name := fmt.Sprintf("%s_reactions.json", targetType)
+		err = writeExportArray(archive, name, func(page pagination.Page) (pagination.Response[model.Reaction], error) {
+			reactions, err := user.repo.GetReactionsByUser(ctx, userID, targetType, page)
+			return pagination.NewResponse(reactions, page, reactionCursor, asIs[model.Reaction]), err
+		})
+		if err != nil {
+			log.Printf("[FAIL]: could not export %s reactions: %v", targetType, err)
+			return
+		}
+	}
+
+	err = writeExportArray(archive, "eco_actions.json", func(page pagination.Page) (pagination.Response[model.EcoAction], error) {
+		actions, err := user.repo.GetEcoActionsByUser(ctx, userID, page)
+		return pagination.NewResponse(actions, page, ecoActionCursor, asIs[model.EcoAction]), err
+	})
+	if err != nil {
+		log.Println("[FAIL]: could not export eco actions:", err)
+		return
+	}
+}
+
+/*
+Writes a value as an indented JSON file to the archive
+
+Params:
+  - archive: The ZIP writer
+  - name:    The name of the file in the archive
+  - value:   The value to encode
+
+Returns:
+  - An error if the file could not be written
+*/
+func writeExportFile(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+/*
+Writes a JSON array to the archive, fetching it one page at a time so the
+whole history never has to be held in memory
+
+Params:
+  - archive: The ZIP writer
+  - name:    The name of the file in the archive
+  - fetch:   Returns the requested page
+
+Returns:
+  - An error if a page could not be fetched or written
+*/
+func writeExportArray[T any](archive *zip.Writer, name string, fetch func(page pagination.Page) (pagination.Response[T], error)) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write([]byte("[")); err != nil {
+		return err
+	}
+
+	written := 0
+	page := pagination.Page{Limit: exportPageSize}
+	for {
+		response, err := fetch(page)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range response.Items {
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+
+			separator := ",\n  "
+			if written == 0 {
+				separator = "\n  "
+			}
+
+			if _, err := file.Write(append([]byte(separator), encoded...)); err != nil {
+				return err
+			}
+			written++
+		}
+
+		if !response.HasMore {
+			break
+		}
+
+		cursor, err := pagination.Decode(response.NextCursor)
+		if err != nil {
+			return err
+		}
+		page.After = &cursor
+	}
+
+	_, err = file.Write([]byte("\n]\n"))
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	oauth "github.com/ecofriends/authentication-backend/handler/auth/oauth"
 	password "github.com/ecofriends/authentication-backend/handler/auth/password"
 	shared "github.com/ecofriends/authentication-backend/handler/auth/shared"
+	"github.com/ecofriends/authentication-backend/mailer"
 	"github.com/ecofriends/authentication-backend/service"
 	"github.com/ecofriends/authentication-backend/util"
 	"github.com/go-chi/chi/v5"
@@ -14,39 +15,72 @@ import (
 
 type AuthHandler struct {
 	dbService *service.DatabaseProvider
+	mailer    mailer.Mailer
 }
 
 func (authHandler *AuthHandler) WithService(service *service.DatabaseProvider) {
 	authHandler.dbService = service
 }
 
+func (authHandler *AuthHandler) WithMailer(mailer mailer.Mailer) {
+	authHandler.mailer = mailer
+}
+
 func (auth *AuthHandler) Home(w http.ResponseWriter, r *http.Request) {
 	msg := "Auth route home"
 	util.JsonResponse(w, msg, http.StatusOK, nil)
 }
 
 func (auth *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
-	password.SignUp(auth.dbService, w, r)
+	password.SignUp(auth.dbService, auth.mailer, w, r)
 }
 
 func (auth *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	password.SignIn(auth.dbService, w, r)
 }
 
-func (auth *AuthHandler) GoogleSignIn(w http.ResponseWriter, r *http.Request) {
-	oauth.GoogleSignIn(w, r)
+func (auth *AuthHandler) OAuthSignIn(w http.ResponseWriter, r *http.Request) {
+	oauth.SignIn(w, r)
+}
+
+func (auth *AuthHandler) OAuthSignInCallback(w http.ResponseWriter, r *http.Request) {
+	oauth.SignInCallback(auth.dbService, w, r)
 }
 
-func (auth *AuthHandler) GoogleSignInCallback(w http.ResponseWriter, r *http.Request) {
-	oauth.GoogleSignInCallback(auth.dbService, w, r)
+func (auth *AuthHandler) OAuthLink(w http.ResponseWriter, r *http.Request) {
+	oauth.Link(w, r)
 }
 
 func (auth *AuthHandler) OAuthFailure(w http.ResponseWriter, r *http.Request) {
-	provider := chi.URLParam(r, "x")
+	provider := chi.URLParam(r, "provider")
 	msg := fmt.Sprintf("Failed to sign-in using %s OAuth", util.CapitalizeFirstLetter(provider))
 	util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
 }
 
+func (auth *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	shared.Refresh(auth.dbService, w, r)
+}
+
+func (auth *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	password.ForgotPassword(auth.dbService, auth.mailer, w, r)
+}
+
+func (auth *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	password.ResetPassword(auth.dbService, w, r)
+}
+
+func (auth *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	shared.VerifyEmail(auth.dbService, w, r)
+}
+
+func (auth *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	shared.ResendVerification(auth.dbService, auth.mailer, w, r)
+}
+
 func (auth *AuthHandler) SignOut(w http.ResponseWriter, r *http.Request) {
-	shared.SignOut(w, r)
+	shared.SignOut(auth.dbService, w, r)
+}
+
+func (auth *AuthHandler) SignOutAll(w http.ResponseWriter, r *http.Request) {
+	shared.SignOutAll(auth.dbService, w, r)
 }
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const oauthGitHubAPIURL = "https://api.github.com"
+
+// GitHub sign-in provider
+type GitHubProvider struct {
+	oauth2Provider
+	apiURL string
+}
+
+/*
+Creates a GitHub provider
+
+Objectives:
+  - Default the scopes and endpoint to GitHub's when not set
+  - Default the API URL to GitHub's when empty
+
+Params:
+  - config: The oauth2 client configuration
+  - apiURL: The base URL of the REST API, empty for api.github.com
+
+Returns:
+  - A pointer to the provider
+*/
+func NewGitHubProvider(config *oauth2.Config, apiURL string) *GitHubProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"read:user", "user:email"}
+	}
+
+	if config.Endpoint.AuthURL == "" {
+		config.Endpoint = github.Endpoint
+	}
+
+	if apiURL == "" {
+		apiURL = oauthGitHubAPIURL
+	}
+
+	return &GitHubProvider{
+		oauth2Provider: oauth2Provider{name: "github", config: config},
+		apiURL:         strings.TrimSuffix(apiURL, "/"),
+	}
+}
+
+/*
+Handles getting user data from GitHub provided an access token
+
+Objectives:
+  - Read the user's account
+  - Read the user's primary email when the account email is private
+
+Params:
+  - ctx:   The request context
+  - token: The access token
+
+Returns:
+  - The user profile
+  - An error if any step fails
+*/
+func (provider *GitHubProvider) FetchProfile(ctx context.Context, token *oauth2.Token) (*Profile, error) {
+	client := provider.config.Client(ctx, token)
+
+	var account struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Email string `json:"email"`
+	}
+
+	if err := getJSON(ctx, client, provider.apiURL+"/user", &account); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+
+	if err := getJSON(ctx, client, provider.apiURL+"/user/emails", &emails); err != nil {
+		return nil, fmt.Errorf("failed to get user emails: %w", err)
+	}
+
+	profile := &Profile{
+		Provider: provider.Name(),
+		Subject:  strconv.FormatInt(account.ID, 10),
+		Email:    account.Email,
+		Username: account.Login,
+	}
+
+	// Prefer the primary address, it is the only one GitHub guarantees to be current
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return profile, nil
+}
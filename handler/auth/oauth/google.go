@@ -4,197 +4,121 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
-
-	"github.com/ecofriends/authentication-backend/authentication"
-	"github.com/ecofriends/authentication-backend/model"
-	"github.com/ecofriends/authentication-backend/service"
-	"github.com/ecofriends/authentication-backend/util"
-	"github.com/google/uuid"
-	"github.com/joho/godotenv"
+
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
-var googleOauthConfig = &oauth2.Config{
-	ClientID:     "",
-	ClientSecret: "",
-	RedirectURL:  "",
-	Scopes: []string{
-		"https://www.googleapis.com/auth/userinfo.email",
-		"https://www.googleapis.com/auth/userinfo.profile",
-	},
-	Endpoint: google.Endpoint,
-}
-
-const oauthGoogleUserURL = "https://www.googleapis.com/oauth2/v2/userinfo?access_token="
-
-func initializeGoogleConfig() error {
-	// Load environment variables from .env file in development
-	if env := os.Getenv("ENVIRONMENT"); env != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			return fmt.Errorf("[FAIL]: could not load environment variables: %w", err)
-		}
-	}
-
-	// Configure sensitive information
-	googleOauthConfig.ClientID = os.Getenv("GOOGLE_CLIENT_ID")
-	googleOauthConfig.ClientSecret = os.Getenv("GOOGLE_CLIENT_SECRET")
-	googleOauthConfig.RedirectURL = os.Getenv("GOOGLE_OAUTH_REDIRECT_URL")
+const oauthGoogleUserURL = "https://www.googleapis.com/oauth2/v2/userinfo"
 
-	return nil
+// Google sign-in provider
+type GoogleProvider struct {
+	oauth2Provider
+	userInfoURL string
 }
 
 /*
-Handles Google account sign-in with OAuth 2.0
+Creates a Google provider
 
 Objectives:
-  - Setup auth config struct
-  - Request authentication from Google
-  - Handle auth callback
+  - Default the scopes and endpoint to Google's when not set
+  - Default the userinfo URL to Google's when empty
 
 Params:
-  - dbService: The database service provider
-  - w:         A http response writer
-  - r:         A pointer to a http request object
+  - config:      The oauth2 client configuration
+  - userInfoURL: The URL of the userinfo endpoint, empty for Google's
 
 Returns:
-  - No return value
+  - A pointer to the provider
 */
-func GoogleSignIn(w http.ResponseWriter, r *http.Request) {
-	// Initialize the config
-	err := initializeGoogleConfig()
-	if err != nil {
-		log.Println("[FAIL]: Could not configure google oauth")
-		util.JsonResponse(w, "Failed to configure Google OAuth", http.StatusInternalServerError, nil)
+func NewGoogleProvider(config *oauth2.Config, userInfoURL string) *GoogleProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{
+			"https://www.googleapis.com/auth/userinfo.email",
+			"https://www.googleapis.com/auth/userinfo.profile",
+		}
 	}
 
-	// Generate the auth state and redirect
-	oauthState := util.GenerateStateOauthCookie(w)
-	url := googleOauthConfig.AuthCodeURL(oauthState)
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	if config.Endpoint.AuthURL == "" {
+		config.Endpoint = google.Endpoint
+	}
+
+	if userInfoURL == "" {
+		userInfoURL = oauthGoogleUserURL
+	}
+
+	return &GoogleProvider{
+		oauth2Provider: oauth2Provider{name: "google", config: config},
+		userInfoURL:    userInfoURL,
+	}
 }
 
 /*
-Handles callbacks to Google sign-in
-
-Objectives:
-  - Handle auth callback
-  - Save user to the database
+Handles getting user data from Google provided an access token
 
 Params:
-  - dbService: The database service provider
-  - w:         A http response writer
-  - r:         A pointer to a http request object
+  - ctx:   The request context
+  - token: The access token
 
 Returns:
-  - No return value
+  - The user profile
+  - An error if any step fails
 */
-func GoogleSignInCallback(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
-	// Read state from cookie
-	oauthState, _ := r.Cookie("oauthstate")
-	failureRedirectURL := "failure"
-
-	if r.FormValue("state") != oauthState.Value {
-		log.Println("[AUTH]: Oauth states do not match")
-		http.Redirect(w, r, failureRedirectURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	// Get user info from Google
-	userData, err := getGoogleUserData(r.FormValue("code"))
-	if err != nil {
-		log.Println("[FAIL]:", err.Error())
-		http.Redirect(w, r, failureRedirectURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	// Make sure the user doesn't already exist
-	userExists, _ := dbService.Repo.UserExists(r.Context(), userData.Email, userData.Username)
-	if userExists {
-		util.JsonResponse(w, "User already exists", http.StatusConflict, nil)
-		return
-	}
-
-	// Save user to database
-	err = dbService.Repo.InsertUser(r.Context(), *userData)
-	if err != nil {
-		log.Println("[FAIL]: could not insert user")
-		util.JsonResponse(w, "Failed to create new user", http.StatusInternalServerError, nil)
-		return
-	}
-
-	// Generate a new token and send response
-	token, err := authentication.CreateJWToken(userData.ID)
-	if err != nil {
-		log.Println(err)
-		msg := "Failed to create token"
-		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
-		return
+func (provider *GoogleProvider) FetchProfile(ctx context.Context, token *oauth2.Token) (*Profile, error) {
+	var responseData struct {
+		ID            string `json:"id"`
+		Username      string `json:"name"`
+		Email         string `json:"email"`
+		VerifiedEmail bool   `json:"verified_email"`
 	}
 
-	// Create the user payload
-	var userPayload = util.UserPayload{
-		ID:       userData.ID,
-		Username: userData.Username,
-		Email:    userData.Email,
+	client := provider.config.Client(ctx, token)
+	if err := getJSON(ctx, client, provider.userInfoURL, &responseData); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	// Respond with the payload
-	cookie := util.CreateTokenCookie(token)
-	http.SetCookie(w, &cookie)
-	util.JsonResponse(w, "Successfully signed-in with Google", http.StatusOK, userPayload)
+	return &Profile{
+		Provider:      provider.Name(),
+		Subject:       responseData.ID,
+		Email:         responseData.Email,
+		EmailVerified: responseData.VerifiedEmail,
+		Username:      responseData.Username,
+	}, nil
 }
 
 /*
-Handles getting user data from Google provided an auth code
+Makes a GET request and decodes the JSON response
 
 Params:
-  - code: The auth exchange code
+  - ctx:    The request context
+  - client: The http client carrying the access token
+  - url:    The URL to request
+  - out:    A pointer to decode the response into
 
 Returns:
-  - The user data which is a byte slice
-  - An error if any step fails
+  - An error if the request failed or returned a non 2xx status
 */
-func getGoogleUserData(code string) (*model.User, error) {
-	token, err := googleOauthConfig.Exchange(context.Background(), code)
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return &model.User{}, fmt.Errorf("failed to exchange code")
+		return err
 	}
+	req.Header.Set("Accept", "application/json")
 
-	url := fmt.Sprintf("%s%s", oauthGoogleUserURL, token.AccessToken)
-	log.Println("[LOG]:", url)
-
-	// Make a response using the token
-	res, err := http.Get(url)
+	res, err := client.Do(req)
 	if err != nil {
-		return &model.User{}, fmt.Errorf("failed to get user info")
+		return err
 	}
-
-	// Read user data
 	defer res.Body.Close()
 
-	var responseData struct {
-		Username string `json:"name"`
-		Email    string `json:"email"`
-		Password string `json:"id"`
-	}
-
-	// Read response into struct
-	if err := json.NewDecoder(res.Body).Decode(&responseData); err != nil {
-		return &model.User{}, fmt.Errorf("failed to decode response: %w", err)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, url)
 	}
 
-	// Create user data model
-	var userData = &model.User{
-		ID:       uuid.New(),
-		Username: responseData.Username,
-		Email:    responseData.Email,
-		Password: responseData.Password,
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return userData, nil
+	return nil
 }
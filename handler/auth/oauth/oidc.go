@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+/*
+Generic OpenID Connect provider
+
+Endpoints are read from the issuer's discovery document on first use. The
+profile is read from the userinfo endpoint with the access token, so no ID
+token signature verification is needed.
+*/
+type OIDCProvider struct {
+	name      string
+	issuerURL string
+
+	mu          sync.Mutex
+	config      *oauth2.Config
+	userInfoURL string
+	discovered  bool
+}
+
+/*
+Creates an OpenID Connect provider
+
+Params:
+  - name:      The path segment to register the provider under
+  - issuerURL: The issuer URL, which serves /.well-known/openid-configuration
+  - config:    The oauth2 client configuration, the endpoint is discovered
+
+Returns:
+  - A pointer to the provider
+*/
+func NewOIDCProvider(name string, issuerURL string, config *oauth2.Config) *OIDCProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &OIDCProvider{
+		name:      name,
+		issuerURL: strings.TrimSuffix(issuerURL, "/"),
+		config:    config,
+	}
+}
+
+func (provider *OIDCProvider) Name() string {
+	return provider.name
+}
+
+func (provider *OIDCProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	config, err := provider.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return config.AuthCodeURL(state), nil
+}
+
+func (provider *OIDCProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	config, err := provider.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	return token, nil
+}
+
+/*
+Handles getting user data from the userinfo endpoint provided an access token
+
+Params:
+  - ctx:   The request context
+  - token: The access token
+
+Returns:
+  - The user profile
+  - An error if any step fails
+*/
+func (provider *OIDCProvider) FetchProfile(ctx context.Context, token *oauth2.Token) (*Profile, error) {
+	config, err := provider.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Subject           string `json:"sub"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+	}
+
+	client := config.Client(ctx, token)
+	if err := getJSON(ctx, client, provider.userInfoURL, &claims); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("userinfo response has no subject")
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Name
+	}
+
+	return &Profile{
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Username:      username,
+	}, nil
+}
+
+/*
+Reads the issuer's discovery document once and fills in the endpoints
+
+Params:
+  - ctx: The request context
+
+Returns:
+  - The oauth2 configuration with the discovered endpoint
+  - An error if the discovery document could not be read
+*/
+func (provider *OIDCProvider) discover(ctx context.Context) (*oauth2.Config, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.discovered {
+		return provider.config, nil
+	}
+
+	var document struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+
+	url := provider.issuerURL + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, oauth2.NewClient(ctx, nil), url, &document); err != nil {
+		return nil, fmt.Errorf("failed to discover %s: %w", provider.name, err)
+	}
+
+	if strings.TrimSuffix(document.Issuer, "/") != provider.issuerURL {
+		return nil, fmt.Errorf("discovered issuer %s does not match %s", document.Issuer, provider.issuerURL)
+	}
+
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.UserInfoEndpoint == "" {
+		return nil, fmt.Errorf("discovery document for %s is missing endpoints", provider.name)
+	}
+
+	provider.config.Endpoint = oauth2.Endpoint{
+		AuthURL:  document.AuthorizationEndpoint,
+		TokenURL: document.TokenEndpoint,
+	}
+	provider.userInfoURL = document.UserInfoEndpoint
+	provider.discovered = true
+
+	return provider.config, nil
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/oauth2"
+)
+
+/*
+Profile of a user as reported by an OAuth provider
+
+Fields:
+  - Provider:      string - Name of the provider the profile came from
+  - Subject:       string - Stable identifier of the user at the provider
+  - Email:         string - Email address of the user
+  - EmailVerified: bool   - Whether the provider verified the email address
+  - Username:      string - Display or login name of the user
+*/
+type Profile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+}
+
+/*
+Provider Interface
+
+Defines an OAuth 2.0 login provider, one per {provider} path segment
+*/
+type Provider interface {
+	// Name returns the path segment the provider is registered under
+	Name() string
+
+	// AuthCodeURL returns the URL the user is redirected to in order to consent
+	AuthCodeURL(ctx context.Context, state string) (string, error)
+
+	// Exchange trades an authorization code for an access token
+	Exchange(ctx context.Context, code string) (*oauth2.Token, error)
+
+	// FetchProfile reads the signed-in user's profile using the access token
+	FetchProfile(ctx context.Context, token *oauth2.Token) (*Profile, error)
+}
+
+// Registry of the configured providers, keyed by name
+var (
+	providers     = map[string]Provider{}
+	providersMu   sync.RWMutex
+	providersOnce sync.Once
+)
+
+/*
+Registers a provider, replacing any provider with the same name
+
+Params:
+  - provider: The provider to register
+
+Returns:
+  - No return value
+*/
+func RegisterProvider(provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[strings.ToLower(provider.Name())] = provider
+}
+
+/*
+Looks up a provider by the {provider} path segment
+
+Objectives:
+  - Register the providers configured in the environment on first use
+  - Return the provider registered under the name
+
+Params:
+  - name: The name of the provider
+
+Returns:
+  - The provider
+  - An error if no provider is registered under the name
+*/
+func LookupProvider(name string) (Provider, error) {
+	providersOnce.Do(registerEnvironmentProviders)
+
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	provider, ok := providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown oauth provider: %s", name)
+	}
+
+	return provider, nil
+}
+
+/*
+Registers every provider that has a client ID configured in the environment
+
+Environment:
+  - GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET, GOOGLE_OAUTH_REDIRECT_URL
+  - GITHUB_CLIENT_ID, GITHUB_CLIENT_SECRET, GITHUB_OAUTH_REDIRECT_URL
+  - OIDC_PROVIDER_NAME, OIDC_ISSUER_URL, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET, OIDC_OAUTH_REDIRECT_URL
+
+Params:
+  - No parameters
+
+Returns:
+  - No return value
+*/
+func registerEnvironmentProviders() {
+	// Load environment variables from .env file in development
+	if env := os.Getenv("ENVIRONMENT"); env != "production" {
+		err := godotenv.Load()
+		if err != nil {
+			log.Println("[FAIL]: could not load environment variables:", err)
+		}
+	}
+
+	if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" {
+		RegisterProvider(NewGoogleProvider(&oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("GOOGLE_OAUTH_REDIRECT_URL"),
+		}, ""))
+	}
+
+	if clientID := os.Getenv("GITHUB_CLIENT_ID"); clientID != "" {
+		RegisterProvider(NewGitHubProvider(&oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("GITHUB_OAUTH_REDIRECT_URL"),
+		}, ""))
+	}
+
+	if clientID := os.Getenv("OIDC_CLIENT_ID"); clientID != "" {
+		name := os.Getenv("OIDC_PROVIDER_NAME")
+		if name == "" {
+			name = "oidc"
+		}
+
+		RegisterProvider(NewOIDCProvider(name, os.Getenv("OIDC_ISSUER_URL"), &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_OAUTH_REDIRECT_URL"),
+		}))
+	}
+}
+
+/*
+Provider backed by a static oauth2 configuration
+
+Implements everything but FetchProfile, which differs for every provider
+*/
+type oauth2Provider struct {
+	name   string
+	config *oauth2.Config
+}
+
+func (provider *oauth2Provider) Name() string {
+	return provider.name
+}
+
+func (provider *oauth2Provider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	return provider.config.AuthCodeURL(state), nil
+}
+
+func (provider *oauth2Provider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	token, err := provider.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// Stand-in for a provider's token and API endpoints, routes map to a JSON body
+// served to bearers of the test token or to a handler of their own
+func newProviderServer(t *testing.T, routes map[string]interface{}) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("code") != "the-code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "the-token", "token_type": "bearer"})
+	})
+
+	for path, body := range routes {
+		if handler, ok := body.(http.HandlerFunc); ok {
+			mux.HandleFunc(path, handler)
+			continue
+		}
+
+		body := body
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer the-token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(body)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func testConfig(server *httptest.Server) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   server.URL + "/authorize",
+			TokenURL:  server.URL + "/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+func TestLookupProviderUnknown(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+
+	if _, err := LookupProvider("does-not-exist"); err == nil {
+		t.Error("LookupProvider returned no error for an unknown provider")
+	}
+}
+
+func TestLookupProviderIsCaseInsensitive(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+
+	RegisterProvider(NewGitHubProvider(&oauth2.Config{ClientID: "client"}, ""))
+
+	provider, err := LookupProvider("GitHub")
+	if err != nil {
+		t.Fatalf("LookupProvider: %v", err)
+	}
+	if provider.Name() != "github" {
+		t.Errorf("Name() = %q, want github", provider.Name())
+	}
+}
+
+func TestExchange(t *testing.T) {
+	server := newProviderServer(t, nil)
+	provider := NewGoogleProvider(testConfig(server), "")
+
+	token, err := provider.Exchange(context.Background(), "the-code")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if token.AccessToken != "the-token" {
+		t.Errorf("AccessToken = %q, want the-token", token.AccessToken)
+	}
+
+	if _, err := provider.Exchange(context.Background(), "wrong-code"); err == nil {
+		t.Error("Exchange accepted a rejected code")
+	}
+}
+
+func TestGoogleFetchProfile(t *testing.T) {
+	server := newProviderServer(t, map[string]interface{}{
+		"/userinfo": map[string]interface{}{
+			"id":             "1234",
+			"name":           "Jane",
+			"email":          "jane@example.com",
+			"verified_email": true,
+		},
+	})
+	provider := NewGoogleProvider(testConfig(server), server.URL+"/userinfo")
+
+	profile, err := provider.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "the-token"})
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+
+	want := Profile{Provider: "google", Subject: "1234", Email: "jane@example.com", EmailVerified: true, Username: "Jane"}
+	if *profile != want {
+		t.Errorf("profile = %+v, want %+v", *profile, want)
+	}
+}
+
+func TestGoogleFetchProfileRejected(t *testing.T) {
+	server := newProviderServer(t, map[string]interface{}{"/userinfo": map[string]interface{}{}})
+	provider := NewGoogleProvider(testConfig(server), server.URL+"/userinfo")
+
+	if _, err := provider.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "stale"}); err == nil {
+		t.Error("FetchProfile returned no error for a rejected token")
+	}
+}
+
+func TestGitHubFetchProfile(t *testing.T) {
+	server := newProviderServer(t, map[string]interface{}{
+		"/user": map[string]interface{}{"id": 42, "login": "jane", "email": "public@example.com"},
+		"/user/emails": []map[string]interface{}{
+			{"email": "other@example.com", "primary": false, "verified": true},
+			{"email": "jane@example.com", "primary": true, "verified": true},
+		},
+	})
+	provider := NewGitHubProvider(testConfig(server), server.URL+"/")
+
+	profile, err := provider.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "the-token"})
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+
+	want := Profile{Provider: "github", Subject: "42", Email: "jane@example.com", EmailVerified: true, Username: "jane"}
+	if *profile != want {
+		t.Errorf("profile = %+v, want %+v", *profile, want)
+	}
+}
+
+func TestGitHubFetchProfileWithoutPrimaryEmail(t *testing.T) {
+	server := newProviderServer(t, map[string]interface{}{
+		"/user":        map[string]interface{}{"id": 42, "login": "jane", "email": "public@example.com"},
+		"/user/emails": []map[string]interface{}{},
+	})
+	provider := NewGitHubProvider(testConfig(server), server.URL)
+
+	profile, err := provider.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "the-token"})
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+	if profile.Email != "public@example.com" || profile.EmailVerified {
+		t.Errorf("profile = %+v, want the unverified account email", *profile)
+	}
+}
+
+// Stand-in for an OpenID Connect issuer, issuer overrides the advertised issuer when set
+func newIssuerServer(t *testing.T, issuer string) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = newProviderServer(t, map[string]interface{}{
+		"/userinfo": map[string]interface{}{
+			"sub":                "abc",
+			"email":              "jane@example.com",
+			"email_verified":     true,
+			"name":               "Jane Doe",
+			"preferred_username": "jane",
+		},
+		"/.well-known/openid-configuration": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			advertised := issuer
+			if advertised == "" {
+				advertised = server.URL
+			}
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 advertised,
+				"authorization_endpoint": server.URL + "/authorize",
+				"token_endpoint":         server.URL + "/token",
+				"userinfo_endpoint":      server.URL + "/userinfo",
+			})
+		}),
+	})
+
+	return server
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	server := newIssuerServer(t, "")
+	provider := NewOIDCProvider("corp", server.URL+"/", &oauth2.Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{AuthStyle: oauth2.AuthStyleInParams},
+	})
+
+	url, err := provider.AuthCodeURL(context.Background(), "state")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if !strings.HasPrefix(url, server.URL+"/authorize?") || !strings.Contains(url, "state=state") {
+		t.Errorf("AuthCodeURL = %q, want the discovered authorization endpoint", url)
+	}
+
+	token, err := provider.Exchange(context.Background(), "the-code")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	profile, err := provider.FetchProfile(context.Background(), token)
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+
+	want := Profile{Provider: "corp", Subject: "abc", Email: "jane@example.com", EmailVerified: true, Username: "jane"}
+	if *profile != want {
+		t.Errorf("profile = %+v, want %+v", *profile, want)
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	server := newIssuerServer(t, "https://evil.example.com")
+	provider := NewOIDCProvider("corp", server.URL, &oauth2.Config{ClientID: "client"})
+
+	if _, err := provider.AuthCodeURL(context.Background(), "state"); err == nil {
+		t.Error("AuthCodeURL accepted a discovery document for another issuer")
+	}
+}
+
+func TestOIDCDiscoveryUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	provider := NewOIDCProvider("corp", server.URL, &oauth2.Config{ClientID: "client"})
+
+	if _, err := provider.Exchange(context.Background(), "the-code"); err == nil {
+		t.Error("Exchange succeeded without a discovery document")
+	}
+}
@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	shared "github.com/ecofriends/authentication-backend/handler/auth/shared"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/service"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// SignIn handles sign-in with an OAuth 2.0 provider
+// @Summary Sign in with an OAuth provider
+// @Description Redirects to the consent page of the provider named in the path (e.g. google, github)
+// @Tags authentication
+// @Param provider path string true "OAuth provider"
+// @Success 307
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /auth/oauth/{provider} [get]
+func SignIn(w http.ResponseWriter, r *http.Request) {
+	startFlow(w, r, false)
+}
+
+// Link starts linking an OAuth 2.0 provider to the signed-in user
+// @Summary Link an OAuth provider
+// @Description Redirects to the consent page of the provider; the callback links the provider account to the signed-in user
+// @Tags authentication
+// @Param provider path string true "OAuth provider"
+// @Success 307
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /auth/oauth/{provider}/link [get]
+func Link(w http.ResponseWriter, r *http.Request) {
+	startFlow(w, r, true)
+}
+
+/*
+Starts an OAuth flow by redirecting to the provider
+
+Params:
+  - w:    A http response writer
+  - r:    A pointer to a http request object
+  - link: Whether the callback should link the provider instead of signing in
+
+Returns:
+  - No return value
+*/
+func startFlow(w http.ResponseWriter, r *http.Request, link bool) {
+	provider, err := LookupProvider(chi.URLParam(r, "provider"))
+	if err != nil {
+		util.JsonResponse(w, "Unsupported OAuth provider", http.StatusNotFound, nil)
+		return
+	}
+
+	// Generate the auth state and redirect
+	oauthState := util.GenerateStateOauthCookie(w)
+	url, err := provider.AuthCodeURL(r.Context(), oauthState)
+	if err != nil {
+		log.Println("[FAIL]:", err)
+		msg := "Failed to configure " + util.CapitalizeFirstLetter(provider.Name()) + " OAuth"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if link {
+		util.SetOauthLinkCookie(w, oauthState)
+	}
+
+	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+}
+
+// SignInCallback handles callbacks from an OAuth 2.0 provider
+// @Summary OAuth provider callback
+// @Description Exchanges the authorization code, then signs the user in, creating an account on first sign-in, or links the provider when the flow was started from /link
+// @Tags authentication
+// @Produce json
+// @Param provider path string true "OAuth provider"
+// @Param state query string true "OAuth state"
+// @Param code query string true "Authorization code"
+// @Success 200 {object} util.Response
+// @Failure 307
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 409 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /auth/oauth/{provider}/callback [get]
+func SignInCallback(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
+	provider, err := LookupProvider(chi.URLParam(r, "provider"))
+	if err != nil {
+		util.JsonResponse(w, "Unsupported OAuth provider", http.StatusNotFound, nil)
+		return
+	}
+
+	// Read state from cookie
+	oauthState, err := r.Cookie("oauthstate")
+	failureRedirectURL := "failure"
+
+	if err != nil || r.FormValue("state") != oauthState.Value {
+		log.Println("[AUTH]: Oauth states do not match")
+		http.Redirect(w, r, failureRedirectURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	// Get user info from the provider
+	token, err := provider.Exchange(r.Context(), r.FormValue("code"))
+	if err != nil {
+		log.Println("[FAIL]:", err.Error())
+		http.Redirect(w, r, failureRedirectURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	profile, err := provider.FetchProfile(r.Context(), token)
+	if err != nil {
+		log.Println("[FAIL]:", err.Error())
+		http.Redirect(w, r, failureRedirectURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	// Link the provider when the flow was started from the settings
+	if linkCookie, err := r.Cookie("oauthlink"); err == nil && linkCookie.Value == oauthState.Value {
+		util.ExpireCookie(w, "oauthlink")
+		linkIdentity(dbService, w, r, profile)
+		return
+	}
+
+	// Find the user the provider account belongs to, or create one
+	userData, status, err := resolveUser(r.Context(), dbService.Repo, profile)
+	if err != nil {
+		log.Println("[FAIL]:", err)
+		msg := util.CapitalizeFirstLetter(err.Error())
+		if status == http.StatusInternalServerError {
+			msg = "Failed to sign-in user"
+		}
+		util.JsonResponse(w, msg, status, nil)
+		return
+	}
+
+	// Signing in during the grace period cancels a pending account deletion
+	restored, err := shared.RestoreDeletedAccount(r.Context(), dbService, &userData)
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error, could not restore account"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Start a new session, setting the token cookies
+	err = shared.StartSession(r.Context(), dbService, w, userData.ID)
+	if err != nil {
+		log.Println(err)
+		msg := "Failed to create token"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Create the user payload
+	var userPayload = util.NewUserPayload(userData)
+
+	// Respond with the payload
+	msg := "Successfully signed-in with " + util.CapitalizeFirstLetter(provider.Name())
+	if restored {
+		msg += ", account deletion cancelled"
+	}
+	util.JsonResponse(w, msg, http.StatusOK, userPayload)
+}
+
+// Queries resolveUser needs, implemented by the repository
+type identityStore interface {
+	GetUserByIdentity(ctx context.Context, provider string, subject string) (model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (model.User, error)
+	UserExists(ctx context.Context, email string, username string) (bool, error)
+	CreateIdentity(ctx context.Context, identity model.UserIdentity) error
+	ConvertLegacyGoogleUser(ctx context.Context, identity model.UserIdentity) error
+	InsertOAuthUser(ctx context.Context, user model.User, identity model.UserIdentity) error
+}
+
+/*
+Finds the user a provider account belongs to, creating one on first sign-in
+
+Objectives:
+  - Return the user linked to the provider account
+  - Convert accounts created by the old Google sign-in, which used the Google ID as password
+  - Link the provider account to an existing user with the same, provider verified, email
+  - Otherwise create a user without a password along with the identity
+
+Params:
+  - ctx:     The request context
+  - store:   The users and identities store
+  - profile: The profile reported by the provider
+
+Returns:
+  - The user
+  - The http status to respond with when an error is returned
+  - An error if the user could not be resolved
+*/
+func resolveUser(ctx context.Context, store identityStore, profile *Profile) (model.User, int, error) {
+	// Returning users are found by their identity
+	user, err := store.GetUserByIdentity(ctx, profile.Provider, profile.Subject)
+	if err == nil {
+		return user, http.StatusOK, nil
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		return model.User{}, http.StatusInternalServerError, err
+	}
+
+	if profile.Email == "" {
+		return model.User{}, http.StatusBadRequest, fmt.Errorf("the provider did not share an email address")
+	}
+
+	identity := model.UserIdentity{
+		Provider: profile.Provider,
+		Subject:  profile.Subject,
+		Email:    profile.Email,
+	}
+
+	emailExists, err := store.UserExists(ctx, profile.Email, "")
+	if err != nil {
+		return model.User{}, http.StatusInternalServerError, err
+	}
+
+	if emailExists {
+		user, err := store.GetUserByEmail(ctx, profile.Email)
+		if err != nil {
+			return model.User{}, http.StatusInternalServerError, err
+		}
+
+		identity.UserID = user.ID
+
+		// The old Google sign-in stored the Google ID as the password, a match proves it is the same account
+		if profile.Provider == "google" && user.Password != "" && util.CompareWithHash([]byte(user.Password), profile.Subject) {
+			if err := store.ConvertLegacyGoogleUser(ctx, identity); err != nil {
+				return model.User{}, http.StatusInternalServerError, err
+			}
+
+			user.Password = ""
+			return user, http.StatusOK, nil
+		}
+
+		// Only trust the email match when the provider vouches for the address
+		if !profile.EmailVerified {
+			return model.User{}, http.StatusConflict, fmt.Errorf("a user with that email already exists, sign in and link the account instead")
+		}
+
+		if err := store.CreateIdentity(ctx, identity); err != nil {
+			if strings.Contains(err.Error(), "already linked") {
+				return model.User{}, http.StatusConflict, fmt.Errorf("a different account from this provider is already linked to that user")
+			}
+			return model.User{}, http.StatusInternalServerError, err
+		}
+
+		return user, http.StatusOK, nil
+	}
+
+	username, err := uniqueUsername(ctx, store, profile)
+	if err != nil {
+		return model.User{}, http.StatusInternalServerError, err
+	}
+
+	user = model.User{
+		ID:            uuid.New(),
+		Username:      username,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+	}
+
+	if err := store.InsertOAuthUser(ctx, user, identity); err != nil {
+		return model.User{}, http.StatusInternalServerError, err
+	}
+
+	return user, http.StatusOK, nil
+}
+
+/*
+Picks a username for a new user that is not taken yet
+
+Params:
+  - ctx:     The request context
+  - store:   The users and identities store
+  - profile: The profile reported by the provider
+
+Returns:
+  - The username
+  - An error if no free username could be found
+*/
+func uniqueUsername(ctx context.Context, store identityStore, profile *Profile) (string, error) {
+	base := profile.Username
+	if base == "" {
+		base, _, _ = strings.Cut(profile.Email, "@")
+	}
+
+	username := base
+	for attempt := 0; attempt < 5; attempt++ {
+		exists, err := store.UserExists(ctx, "", username)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return username, nil
+		}
+
+		username = fmt.Sprintf("%s%04d", base, rand.Intn(10000))
+	}
+
+	return "", fmt.Errorf("could not find a free username for %s", base)
+}
+
+/*
+Links a provider account to the signed-in user
+
+Params:
+  - dbService: The database service provider
+  - w:         A http response writer
+  - r:         A pointer to a http request object
+  - profile:   The profile reported by the provider
+
+Returns:
+  - No return value
+*/
+func linkIdentity(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request, profile *Profile) {
+	userID, err := signedInUserID(r)
+	if err != nil {
+		log.Println("[FAIL]:", err)
+		msg := "Unauthorized request, sign in before linking an account"
+		util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		return
+	}
+
+	providerName := util.CapitalizeFirstLetter(profile.Provider)
+
+	// The provider account may already be linked, to this or another user
+	linkedUser, err := dbService.Repo.GetUserByIdentity(r.Context(), profile.Provider, profile.Subject)
+	if err == nil {
+		if linkedUser.ID.String() == userID {
+			util.JsonResponse(w, providerName+" account is already linked", http.StatusOK, nil)
+			return
+		}
+		msg := "That " + providerName + " account is linked to another user"
+		util.JsonResponse(w, msg, http.StatusConflict, nil)
+		return
+	}
+
+	if !strings.Contains(err.Error(), "not found") {
+		log.Println("[FAIL]:", err)
+		msg := "Internal server error, could not link account"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	identity := model.UserIdentity{
+		Provider: profile.Provider,
+		Subject:  profile.Subject,
+		UserID:   uuid.MustParse(userID),
+		Email:    profile.Email,
+	}
+
+	if err := dbService.Repo.CreateIdentity(r.Context(), identity); err != nil {
+		if strings.Contains(err.Error(), "already linked") {
+			msg := "A different " + providerName + " account is already linked, unlink it first"
+			util.JsonResponse(w, msg, http.StatusConflict, nil)
+			return
+		}
+		log.Println("[FAIL]:", err)
+		msg := "Internal server error, could not link account"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully linked "+providerName+" account", http.StatusOK, nil)
+}
+
+/*
+Reads the signed-in user from the token cookie
+
+The callback is reached by a redirect from the provider, so it cannot sit
+behind the authentication middleware and verifies the token itself.
+
+Params:
+  - r: A pointer to a http request object
+
+Returns:
+  - The ID of the signed-in user
+  - An error if the token is missing, invalid or revoked
+*/
+func signedInUserID(r *http.Request) (string, error) {
+	tokenCookie, err := r.Cookie("token")
+	if err != nil {
+		return "", fmt.Errorf("token not present in cookie")
+	}
+
+	token, err := authentication.VerifyToken(tokenCookie.Value)
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", fmt.Errorf("could not parse token claims")
+	}
+
+	revoked, err := authentication.IsTokenRevoked(r.Context(), claims)
+	if err != nil {
+		return "", err
+	}
+	if revoked {
+		return "", fmt.Errorf("token has been revoked")
+	}
+
+	userID, ok := claims["sub"].(string)
+	if !ok {
+		return "", fmt.Errorf("could not extract the user id")
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return "", fmt.Errorf("invalid user id in token: %w", err)
+	}
+
+	return userID, nil
+}
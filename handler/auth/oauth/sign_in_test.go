@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/google/uuid"
+)
+
+// In-memory users and identities standing in for the database
+type fakeIdentityStore struct {
+	users      map[string]model.User
+	identities map[string]model.UserIdentity
+}
+
+func newFakeIdentityStore(users ...model.User) *fakeIdentityStore {
+	store := &fakeIdentityStore{
+		users:      make(map[string]model.User),
+		identities: make(map[string]model.UserIdentity),
+	}
+	for _, user := range users {
+		store.users[user.ID.String()] = user
+	}
+	return store
+}
+
+func (store *fakeIdentityStore) GetUserByIdentity(ctx context.Context, provider string, subject string) (model.User, error) {
+	identity, ok := store.identities[provider+":"+subject]
+	if !ok {
+		return model.User{}, fmt.Errorf("identity not found")
+	}
+	return store.users[identity.UserID.String()], nil
+}
+
+func (store *fakeIdentityStore) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	for _, user := range store.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return model.User{}, fmt.Errorf("[FAIL]: user with email %s not found", email)
+}
+
+func (store *fakeIdentityStore) UserExists(ctx context.Context, email string, username string) (bool, error) {
+	for _, user := range store.users {
+		if (email != "" && user.Email == email) || (username != "" && user.Username == username) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (store *fakeIdentityStore) CreateIdentity(ctx context.Context, identity model.UserIdentity) error {
+	for _, linked := range store.identities {
+		if linked.UserID == identity.UserID && linked.Provider == identity.Provider {
+			return fmt.Errorf("identity already linked")
+		}
+	}
+	store.identities[identity.Provider+":"+identity.Subject] = identity
+	return nil
+}
+
+func (store *fakeIdentityStore) ConvertLegacyGoogleUser(ctx context.Context, identity model.UserIdentity) error {
+	if err := store.CreateIdentity(ctx, identity); err != nil {
+		return err
+	}
+	user := store.users[identity.UserID.String()]
+	user.Password = ""
+	store.users[user.ID.String()] = user
+	return nil
+}
+
+func (store *fakeIdentityStore) InsertOAuthUser(ctx context.Context, user model.User, identity model.UserIdentity) error {
+	store.users[user.ID.String()] = user
+	identity.UserID = user.ID
+	return store.CreateIdentity(ctx, identity)
+}
+
+func TestResolveUserCreatesNewUser(t *testing.T) {
+	store := newFakeIdentityStore()
+	profile := &Profile{Provider: "github", Subject: "42", Email: "jane@example.com", EmailVerified: true, Username: "jane"}
+
+	user, status, err := resolveUser(context.Background(), store, profile)
+	if err != nil {
+		t.Fatalf("resolveUser: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want %d", status, http.StatusOK)
+	}
+	if user.Username != "jane" || user.Email != "jane@example.com" || !user.EmailVerified || user.Password != "" {
+		t.Errorf("user = %+v, want a passwordless user from the profile", user)
+	}
+	if _, ok := store.identities["github:42"]; !ok {
+		t.Error("identity was not stored")
+	}
+}
+
+func TestResolveUserPicksFreeUsername(t *testing.T) {
+	taken := model.User{ID: uuid.New(), Username: "jane", Email: "someone@example.com"}
+	store := newFakeIdentityStore(taken)
+	profile := &Profile{Provider: "google", Subject: "1", Email: "jane@example.com", Username: "jane"}
+
+	user, _, err := resolveUser(context.Background(), store, profile)
+	if err != nil {
+		t.Fatalf("resolveUser: %v", err)
+	}
+	if user.Username == "jane" {
+		t.Error("new user was given a taken username")
+	}
+}
+
+func TestResolveUserReturningUser(t *testing.T) {
+	existing := model.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	store := newFakeIdentityStore(existing)
+	store.identities["google:1234"] = model.UserIdentity{Provider: "google", Subject: "1234", UserID: existing.ID}
+
+	// The email at the provider changed since the account was linked
+	profile := &Profile{Provider: "google", Subject: "1234", Email: "new@example.com"}
+
+	user, _, err := resolveUser(context.Background(), store, profile)
+	if err != nil {
+		t.Fatalf("resolveUser: %v", err)
+	}
+	if user.ID != existing.ID {
+		t.Errorf("user = %s, want the linked user %s", user.ID, existing.ID)
+	}
+	if len(store.users) != 1 {
+		t.Error("a returning user created another account")
+	}
+}
+
+func TestResolveUserLinksVerifiedEmailMatch(t *testing.T) {
+	existing := model.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", Password: "hash"}
+	store := newFakeIdentityStore(existing)
+	profile := &Profile{Provider: "github", Subject: "42", Email: "jane@example.com", EmailVerified: true}
+
+	user, _, err := resolveUser(context.Background(), store, profile)
+	if err != nil {
+		t.Fatalf("resolveUser: %v", err)
+	}
+	if user.ID != existing.ID {
+		t.Errorf("user = %s, want the user with the matching email %s", user.ID, existing.ID)
+	}
+
+	identity, ok := store.identities["github:42"]
+	if !ok || identity.UserID != existing.ID {
+		t.Errorf("identity = %+v, want it linked to %s", identity, existing.ID)
+	}
+}
+
+func TestResolveUserRejectsUnverifiedEmailMatch(t *testing.T) {
+	existing := model.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	store := newFakeIdentityStore(existing)
+	profile := &Profile{Provider: "github", Subject: "42", Email: "jane@example.com", EmailVerified: false}
+
+	_, status, err := resolveUser(context.Background(), store, profile)
+	if err == nil || status != http.StatusConflict {
+		t.Errorf("resolveUser = %d, %v, want a conflict", status, err)
+	}
+	if len(store.identities) != 0 {
+		t.Error("an unverified email was linked")
+	}
+}
+
+// The old Google sign-in stored a hash of the Google ID as the password
+func TestResolveUserConvertsLegacyGoogleUser(t *testing.T) {
+	hash, err := util.GenerateHash("1234", util.DefaultHashCost)
+	if err != nil {
+		t.Fatalf("GenerateHash: %v", err)
+	}
+	existing := model.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", Password: hash}
+	store := newFakeIdentityStore(existing)
+	profile := &Profile{Provider: "google", Subject: "1234", Email: "jane@example.com"}
+
+	user, status, err := resolveUser(context.Background(), store, profile)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("resolveUser = %d, %v", status, err)
+	}
+	if user.ID != existing.ID || user.Password != "" {
+		t.Errorf("user = %+v, want %s without a password", user, existing.ID)
+	}
+	if store.users[existing.ID.String()].Password != "" {
+		t.Error("the legacy password was kept")
+	}
+	if identity, ok := store.identities["google:1234"]; !ok || identity.UserID != existing.ID {
+		t.Errorf("identity = %+v, want it linked to %s", identity, existing.ID)
+	}
+}
+
+func TestResolveUserKeepsRealPassword(t *testing.T) {
+	hash, err := util.GenerateHash("secret", util.DefaultHashCost)
+	if err != nil {
+		t.Fatalf("GenerateHash: %v", err)
+	}
+	existing := model.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", Password: hash}
+	store := newFakeIdentityStore(existing)
+	profile := &Profile{Provider: "google", Subject: "1234", Email: "jane@example.com", EmailVerified: true}
+
+	if _, _, err := resolveUser(context.Background(), store, profile); err != nil {
+		t.Fatalf("resolveUser: %v", err)
+	}
+	if store.users[existing.ID.String()].Password != hash {
+		t.Error("a real password was cleared")
+	}
+}
+
+func TestResolveUserRequiresEmail(t *testing.T) {
+	store := newFakeIdentityStore()
+	profile := &Profile{Provider: "github", Subject: "42"}
+
+	_, status, err := resolveUser(context.Background(), store, profile)
+	if err == nil || status != http.StatusBadRequest {
+		t.Errorf("resolveUser = %d, %v, want a bad request", status, err)
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	shared "github.com/ecofriends/authentication-backend/handler/auth/shared"
+	"github.com/ecofriends/authentication-backend/mailer"
+	"github.com/ecofriends/authentication-backend/service"
+	"github.com/ecofriends/authentication-backend/util"
+	validators "github.com/ecofriends/authentication-backend/validator"
+)
+
+// Minimum time between two reset emails to the same user
+const passwordResetInterval = time.Minute
+
+// ForgotPassword emails a password reset link
+// @Summary Request a password reset
+// @Description Emails a single-use password reset link. The response is the same whether or not the email is registered.
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body util.ForgotPasswordRequestBody true "Account email"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Router /auth/forgot-password [post]
+func ForgotPassword(dbService *service.DatabaseProvider, mail mailer.Mailer, w http.ResponseWriter, r *http.Request) {
+	var body = util.ForgotPasswordRequestBody{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		msg := "Bad request, email not present"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	util.SanitizeUserInput(&body)
+
+	if !util.IsValidEmail(body.Email) {
+		msg := util.CapitalizeFirstLetter(util.ErrEmailInvalid.Error())
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	// Send the email in the background so the response time does not reveal
+	// whether the email is registered
+	go func(email string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := sendPasswordReset(ctx, dbService, mail, email); err != nil {
+			log.Println("[FAIL]: could not send password reset:", err)
+		}
+	}(body.Email)
+
+	msg := "If an account with that email exists, a password reset link has been sent"
+	util.JsonResponse(w, msg, http.StatusOK, nil)
+}
+
+/*
+Creates a password reset token and emails the reset link
+
+Objectives:
+  - Do nothing if no user has the email
+  - Throttle reset emails to the same user
+  - Store the hash of a new reset token
+  - Email the reset link
+
+Params:
+  - ctx:       The context to run in
+  - dbService: The database service provider
+  - mail:      The mailer to send the email with
+  - email:     The email the reset was requested for
+
+Returns:
+  - An error if any step fails
+*/
+func sendPasswordReset(ctx context.Context, dbService *service.DatabaseProvider, mail mailer.Mailer, email string) error {
+	userExists, err := dbService.Repo.UserExists(ctx, email, "")
+	if err != nil || !userExists {
+		return err
+	}
+
+	user, err := dbService.Repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	lastSent, err := dbService.Repo.GetLatestPasswordResetTime(ctx, user.ID.String())
+	if err != nil {
+		return err
+	}
+
+	if lastSent != nil && time.Since(*lastSent) < passwordResetInterval {
+		log.Println("[LOG]: password reset throttled for user", user.ID)
+		return nil
+	}
+
+	token, err := authentication.CreatePasswordResetToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(authentication.PasswordResetTTL)
+	err = dbService.Repo.CreatePasswordReset(ctx, user.ID.String(), authentication.HashPasswordResetToken(token), expiresAt)
+	if err != nil {
+		return err
+	}
+
+	// The link opens the client's reset form, which posts to /auth/reset-password
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = os.Getenv("APP_URL") + "/reset-password"
+	}
+
+	message := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Ecofriends password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nReset your password by opening the link below:\n\n%s?token=%s\n\nThe link expires in %d minutes and can only be used once. If you did not ask for a reset, you can ignore this email.\n",
+			user.Username,
+			resetURL,
+			url.QueryEscape(token),
+			int(authentication.PasswordResetTTL.Minutes()),
+		),
+	}
+
+	return mail.Send(ctx, message)
+}
+
+// ResetPassword sets a new password using an emailed reset token
+// @Summary Reset the password
+// @Description Sets a new password using the single-use token from the reset email, then signs the user out of every device
+// @Tags authentication
+// @Accept json
+// @Produce json
+// @Param request body util.ResetPasswordRequestBody true "Reset token and new password"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /auth/reset-password [post]
+func ResetPassword(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
+	var body = util.ResetPasswordRequestBody{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		msg := "Bad request, token or password not present"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	util.SanitizeUserInput(&body)
+
+	if body.Token == "" {
+		msg := "Bad request, token or password not present"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := validators.ValidatePassword(body.Password); err != nil {
+		msg := util.CapitalizeFirstLetter(err.Error())
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := dbService.Repo.UsePasswordReset(r.Context(), authentication.HashPasswordResetToken(body.Token), body.Password)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg := "Invalid or expired password reset link"
+			util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+			return
+		}
+		log.Println("[FAIL]:", err)
+		msg := "Internal server error, could not reset password"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Whoever knew the old password must not stay signed in
+	if err := shared.EndAllSessions(r.Context(), dbService, userID); err != nil {
+		log.Println(err)
+	}
+
+	util.ExpireCookie(w, "token")
+	util.ExpireCookie(w, "refresh_token")
+
+	util.JsonResponse(w, "Successfully reset password", http.StatusOK, nil)
+}
@@ -5,7 +5,7 @@ import (
 	"log"
 	"net/http"
 
-	"github.com/ecofriends/authentication-backend/authentication"
+	shared "github.com/ecofriends/authentication-backend/handler/auth/shared"
 	"github.com/ecofriends/authentication-backend/service"
 	"github.com/ecofriends/authentication-backend/util"
 )
@@ -62,6 +62,13 @@ func SignIn(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.
 		return
 	}
 
+	// Users that signed up through a provider have no password to compare
+	if user.Password == "" {
+		msg := "This account signs in through a linked provider"
+		util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		return
+	}
+
 	// Check that the password matches the hash
 	if !util.CompareWithHash([]byte(user.Password), body.Password) {
 		msg := "Provided passwords mismatch"
@@ -69,8 +76,17 @@ func SignIn(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Generate a new token and send response
-	token, err := authentication.CreateJWToken(user.ID)
+	// Signing in during the grace period cancels a pending account deletion
+	restored, err := shared.RestoreDeletedAccount(r.Context(), dbService, &user)
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error, could not restore account"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Start a new session, setting the token cookies
+	err = shared.StartSession(r.Context(), dbService, w, user.ID)
 	if err != nil {
 		log.Println(err)
 		msg := "Failed to create token"
@@ -79,14 +95,12 @@ func SignIn(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.
 	}
 
 	// Create the user payload
-	var userPayload = util.UserPayload{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-	}
+	var userPayload = util.NewUserPayload(user)
 
-	// Set the token cookie and send the response
-	cookie := util.CreateTokenCookie(token)
-	http.SetCookie(w, &cookie)
-	util.JsonResponse(w, "Successfully signed-in", http.StatusOK, userPayload)
+	// Send the response
+	msg := "Successfully signed-in"
+	if restored {
+		msg = "Successfully signed-in, account deletion cancelled"
+	}
+	util.JsonResponse(w, msg, http.StatusOK, userPayload)
 }
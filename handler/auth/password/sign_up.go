@@ -5,7 +5,8 @@ import (
 	"log"
 	"net/http"
 
-	"github.com/ecofriends/authentication-backend/authentication"
+	shared "github.com/ecofriends/authentication-backend/handler/auth/shared"
+	"github.com/ecofriends/authentication-backend/mailer"
 	"github.com/ecofriends/authentication-backend/model"
 	"github.com/ecofriends/authentication-backend/service"
 	"github.com/ecofriends/authentication-backend/util"
@@ -15,7 +16,7 @@ import (
 
 // SignUp handles user registration
 // @Summary Register a new user
-// @Description Create a new user account
+// @Description Create a new user account and email a link verifying the address
 // @Tags authentication
 // @Accept json
 // @Produce json
@@ -24,7 +25,7 @@ import (
 // @Failure 400 {object} util.Response
 // @Failure 500 {object} util.Response
 // @Router /auth/sign-up [post]
-func SignUp(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
+func SignUp(dbService *service.DatabaseProvider, mail mailer.Mailer, w http.ResponseWriter, r *http.Request) {
 	// Store the request body
 	var body = util.SignUpRequestBody{}
 
@@ -70,33 +71,33 @@ func SignUp(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.
 		Password: body.Password,
 	}
 
-	// Generate a JSON Web token that can be sent to the user
-	token, err := authentication.CreateJWToken(user.ID)
+	// Insert the user into the database
+	err = dbService.Repo.InsertUser(r.Context(), user)
 	if err != nil {
 		log.Println(err)
-		msg := "Failed to create token"
+		msg := "Could not insert user into database"
 		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
 		return
 	}
 
-	// Insert the user into the database
-	err = dbService.Repo.InsertUser(r.Context(), user)
+	// Start a new session, setting the token cookies
+	err = shared.StartSession(r.Context(), dbService, w, user.ID)
 	if err != nil {
 		log.Println(err)
-		msg := "Could not insert user into database"
+		msg := "Failed to create token"
 		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
 		return
 	}
 
-	// Create the user payload
-	var userPayload = util.UserPayload{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+	// Ask the user to confirm their email address, the account works without it
+	err = shared.SendVerificationEmail(r.Context(), dbService, mail, user)
+	if err != nil {
+		log.Println("[FAIL]: could not send verification email:", err)
 	}
 
-	// Set the token cookie and send the response
-	cookie := util.CreateTokenCookie(token)
-	http.SetCookie(w, &cookie)
+	// Create the user payload
+	var userPayload = util.NewUserPayload(user)
+
+	// Send the response
 	util.JsonResponse(w, "Successfully inserted user into database", http.StatusOK, userPayload)
 }
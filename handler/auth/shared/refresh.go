@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	"github.com/ecofriends/authentication-backend/service"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/google/uuid"
+)
+
+// Refresh rotates the refresh token and issues a new access token
+// @Summary Refresh the access token
+// @Description Exchange the refresh token cookie for a new access token and a rotated refresh token. Replaying an already rotated refresh token revokes every session in its family.
+// @Tags authentication
+// @Produce json
+// @Success 200 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /auth/refresh [post]
+func Refresh(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
+	refreshCookie, err := r.Cookie("refresh_token")
+	if err != nil || refreshCookie.Value == "" {
+		msg := "Refresh token not present"
+		util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		return
+	}
+
+	// Find the session the refresh token belongs to
+	session, err := dbService.Repo.GetSessionByTokenHash(r.Context(), authentication.HashRefreshToken(refreshCookie.Value))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg := "Invalid refresh token"
+			util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+			return
+		}
+		log.Println("[FAIL]:", err)
+		msg := "Internal server error, could not look up session"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// A revoked token being presented means it was stolen or replayed
+	if session.RevokedAt != nil {
+		log.Printf("[AUTH]: refresh token reuse detected for session family %s", session.FamilyID)
+		revokeFamily(dbService, w, r, session.FamilyID)
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		util.ExpireCookie(w, "refresh_token")
+		msg := "Refresh token has expired"
+		util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		return
+	}
+
+	// Rotate the session within the same family
+	next, nextToken, err := newSession(session.UserID, session.FamilyID)
+	if err != nil {
+		log.Println(err)
+		msg := "Failed to create refresh token"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	err = dbService.Repo.RotateSession(r.Context(), session.ID, next)
+	if err != nil {
+		if strings.Contains(err.Error(), "already rotated") {
+			log.Printf("[AUTH]: concurrent refresh detected for session family %s", session.FamilyID)
+			revokeFamily(dbService, w, r, session.FamilyID)
+			return
+		}
+		log.Println("[FAIL]:", err)
+		msg := "Internal server error, could not rotate session"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if err := setSessionCookies(w, next.UserID, nextToken, next.ExpiresAt); err != nil {
+		log.Println(err)
+		msg := "Failed to create token"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully refreshed token", http.StatusOK, nil)
+}
+
+/*
+Revokes a whole session family and clears the token cookies
+
+Params:
+  - dbService: The database service provider
+  - w:         A http response writer
+  - r:         A pointer to a http request object
+  - familyID:  The session family to revoke
+
+Returns:
+  - No return value
+*/
+func revokeFamily(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request, familyID uuid.UUID) {
+	util.ExpireCookie(w, "token")
+	util.ExpireCookie(w, "refresh_token")
+
+	if err := dbService.Repo.RevokeSessionFamily(r.Context(), familyID); err != nil {
+		log.Println("[FAIL]:", err)
+		msg := "Internal server error, could not revoke session"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	msg := "Refresh token has been revoked"
+	util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/service"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/google/uuid"
+)
+
+/*
+Starts a new session for a user that has just signed in
+
+Objectives:
+  - Create an access token and an opaque refresh token
+  - Persist the refresh token as the first session of a new family
+  - Set both token cookies on the response
+
+Params:
+  - ctx:       The request context
+  - dbService: The database service provider
+  - w:         A http response writer
+  - userID:    The ID of the signed-in user
+
+Returns:
+  - An error if any step fails
+*/
+func StartSession(ctx context.Context, dbService *service.DatabaseProvider, w http.ResponseWriter, userID uuid.UUID) error {
+	session, refreshToken, err := newSession(userID, uuid.New())
+	if err != nil {
+		return err
+	}
+
+	if err := dbService.Repo.CreateSession(ctx, session); err != nil {
+		return fmt.Errorf("[FAIL]: could not store session: %w", err)
+	}
+
+	return setSessionCookies(w, userID, refreshToken, session.ExpiresAt)
+}
+
+/*
+Builds a session and its refresh token without persisting it
+
+Params:
+  - userID:   The ID of the user the session belongs to
+  - familyID: The rotation family of the session
+
+Returns:
+  - The session model
+  - The plain refresh token, which is only ever sent to the client
+  - An error if the token could not be generated
+*/
+func newSession(userID uuid.UUID, familyID uuid.UUID) (model.Session, string, error) {
+	refreshToken, err := authentication.CreateRefreshToken()
+	if err != nil {
+		return model.Session{}, "", err
+	}
+
+	session := model.Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: authentication.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(authentication.RefreshTokenTTL),
+	}
+
+	return session, refreshToken, nil
+}
+
+/*
+Creates an access token and sets it alongside the refresh token cookie
+
+Params:
+  - w:            A http response writer
+  - userID:       The ID of the user the tokens are issued to
+  - refreshToken: The plain refresh token
+  - expiresAt:    When the refresh token expires
+
+Returns:
+  - An error if the access token could not be created
+*/
+func setSessionCookies(w http.ResponseWriter, userID uuid.UUID, refreshToken string, expiresAt time.Time) error {
+	token, err := authentication.CreateJWToken(userID)
+	if err != nil {
+		return err
+	}
+
+	tokenCookie := util.CreateTokenCookie(token)
+	refreshCookie := util.CreateRefreshTokenCookie(refreshToken, expiresAt)
+	http.SetCookie(w, &tokenCookie)
+	http.SetCookie(w, &refreshCookie)
+
+	return nil
+}
+
+/*
+Ends every session a user has open
+
+Objectives:
+  - Revoke all access tokens issued to the user so far
+  - Revoke all of the user's refresh tokens
+
+Params:
+  - ctx:       The request context
+  - dbService: The database service provider
+  - userID:    The ID of the user
+
+Returns:
+  - An error if any step fails
+*/
+func EndAllSessions(ctx context.Context, dbService *service.DatabaseProvider, userID string) error {
+	if err := authentication.RevokeAllTokens(ctx, userID); err != nil {
+		return fmt.Errorf("[FAIL]: could not revoke access tokens: %w", err)
+	}
+
+	if err := dbService.Repo.RevokeUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("[FAIL]: could not revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Cancels the pending deletion of an account that signs in again
+
+Params:
+  - ctx:       The request context
+  - dbService: The database service provider
+  - user:      The signed-in user, updated in place
+
+Returns:
+  - True if a pending deletion was cancelled
+  - An error if the account could not be restored
+*/
+func RestoreDeletedAccount(ctx context.Context, dbService *service.DatabaseProvider, user *model.User) (bool, error) {
+	if user.DeletedAt == nil {
+		return false, nil
+	}
+
+	if err := dbService.Repo.RestoreUser(ctx, user.ID.String()); err != nil {
+		return false, fmt.Errorf("[FAIL]: could not restore account: %w", err)
+	}
+
+	user.DeletedAt = nil
+	return true, nil
+}
@@ -4,22 +4,79 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/ecofriends/authentication-backend/authentication"
+	"github.com/ecofriends/authentication-backend/service"
 	"github.com/ecofriends/authentication-backend/util"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // SignOut handles user log out
 // @Summary Log out the user
-// @Description Log out an existing user
+// @Description Log out an existing user, revoking the current access token and refresh token
 // @Tags authentication
 // @Accept json
 // @Produce json
 // @Success 200 {object} util.Response
 // @Failure 500 {object} util.Response
 // @Router /auth/sign-out [post]
-func SignOut(w http.ResponseWriter, r *http.Request) {
-	// Expire the token cookie
+func SignOut(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
+	// Revoke the access token so it stops working before it expires
+	if tokenCookie, err := r.Cookie("token"); err == nil {
+		if token, err := authentication.VerifyToken(tokenCookie.Value); err == nil {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				if err := authentication.RevokeToken(r.Context(), claims); err != nil {
+					log.Println(err)
+				}
+			}
+		}
+	}
+
+	// Revoke the refresh token along with every token rotated from it
+	if refreshCookie, err := r.Cookie("refresh_token"); err == nil {
+		tokenHash := authentication.HashRefreshToken(refreshCookie.Value)
+		if session, err := dbService.Repo.GetSessionByTokenHash(r.Context(), tokenHash); err == nil {
+			if err := dbService.Repo.RevokeSessionFamily(r.Context(), session.FamilyID); err != nil {
+				log.Println("[FAIL]:", err)
+			}
+		}
+	}
+
+	// Expire the token cookies
 	util.ExpireCookie(w, "token")
+	util.ExpireCookie(w, "refresh_token")
 
 	util.JsonResponse(w, "Successfully signed-out", http.StatusOK, nil)
 	log.Println("[LOG]: Successfully signed user out")
 }
+
+// SignOutAll handles user log out on every device
+// @Summary Log out the user from all devices
+// @Description Revoke every access token and refresh token issued to the authenticated user
+// @Tags authentication
+// @Produce json
+// @Success 200 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /auth/sign-out-all [post]
+func SignOutAll(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := EndAllSessions(r.Context(), dbService, userID); err != nil {
+		log.Println(err)
+		msg := "Internal server error, could not sign out of all devices"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Expire the token cookies
+	util.ExpireCookie(w, "token")
+	util.ExpireCookie(w, "refresh_token")
+
+	util.JsonResponse(w, "Successfully signed-out of all devices", http.StatusOK, nil)
+	log.Println("[LOG]: Successfully signed user out of all devices")
+}
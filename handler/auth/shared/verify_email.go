@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	"github.com/ecofriends/authentication-backend/mailer"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/service"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+// Throttling of verification emails
+const (
+	verificationResendInterval = time.Minute
+	verificationDailyLimit     = 5
+)
+
+/*
+Sends an email with a single-use link verifying the user's address
+
+Objectives:
+  - Create a signed verification token for the user's current address
+  - Record the token so it can only be used once
+  - Email the verification link
+
+Params:
+  - ctx:       The request context
+  - dbService: The database service provider
+  - mail:      The mailer to send the email with
+  - user:      The user to verify
+
+Returns:
+  - An error if any step fails
+*/
+func SendVerificationEmail(ctx context.Context, dbService *service.DatabaseProvider, mail mailer.Mailer, user model.User) error {
+	token, jti, err := authentication.CreateEmailVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	if err := dbService.Repo.CreateEmailVerification(ctx, jti, user.ID.String(), user.Email); err != nil {
+		return fmt.Errorf("[FAIL]: could not store email verification: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/auth/verify-email?token=%s", os.Getenv("APP_URL"), url.QueryEscape(token))
+
+	message := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Ecofriends email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours. If you did not create an account, you can ignore this email.\n",
+			user.Username,
+			link,
+			int(authentication.EmailVerificationTTL.Hours()),
+		),
+	}
+
+	return mail.Send(ctx, message)
+}
+
+// VerifyEmail verifies a user's email address
+// @Summary Verify an email address
+// @Description Consumes the single-use token sent by email and marks the address as verified
+// @Tags authentication
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /auth/verify-email [get]
+func VerifyEmail(dbService *service.DatabaseProvider, w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		msg := "Bad request, verification token not present"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	claims, err := authentication.VerifyEmailVerificationToken(token)
+	if err != nil {
+		log.Println("[FAIL]: email verification token rejected:", err)
+		msg := "Invalid or expired verification link"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	err = dbService.Repo.UseEmailVerification(r.Context(), claims.JTI, claims.UserID, claims.Email)
+	if err != nil {
+		if strings.Contains(err.Error(), "already used") || strings.Contains(err.Error(), "has changed") {
+			msg := "Invalid or expired verification link"
+			util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+			return
+		}
+		log.Println("[FAIL]:", err)
+		msg := "Internal server error, could not verify email"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully verified email", http.StatusOK, nil)
+}
+
+// ResendVerification sends a new verification email
+// @Summary Resend the verification email
+// @Description Sends a new verification link to the authenticated user, at most once a minute and five times a day
+// @Tags authentication
+// @Produce json
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 429 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /auth/verify-email/resend [post]
+func ResendVerification(dbService *service.DatabaseProvider, mail mailer.Mailer, w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	user, err := dbService.Repo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error, failed to get user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if user.EmailVerified {
+		msg := "Email is already verified"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	// Throttle resends
+	sentToday, lastSent, err := dbService.Repo.GetEmailVerificationStats(r.Context(), userID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error, could not check previous verification emails"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if sentToday >= verificationDailyLimit || (lastSent != nil && time.Since(*lastSent) < verificationResendInterval) {
+		msg := "Too many verification emails requested, try again later"
+		util.JsonResponse(w, msg, http.StatusTooManyRequests, nil)
+		return
+	}
+
+	if err := SendVerificationEmail(r.Context(), dbService, mail, user); err != nil {
+		log.Println(err)
+		msg := "Failed to send verification email"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully sent verification email", http.StatusOK, nil)
+}
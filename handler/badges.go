@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ecofriends/authentication-backend/achievement"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+type Badge struct {
+	repo *repository.PostGreSQL
+}
+
+func (badge *Badge) New(repo *repository.PostGreSQL) {
+	badge.repo = repo
+}
+
+// GetBadges lists every badge users can earn
+// @Summary Get badges
+// @Description Returns every badge with the metric and threshold that earns it, in the order clients should show them. Earned badges are listed on the public profile of a user.
+// @Tags badges
+// @Produce json
+// @Success 200 {object} util.Response{payload=[]achievement.Rule}
+// @Router /badges [get]
+func (badge *Badge) GetBadges(w http.ResponseWriter, r *http.Request) {
+	util.JsonResponse(w, "Successfully got badges", http.StatusOK, achievement.Rules)
+}
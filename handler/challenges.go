@@ -0,0 +1,410 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ecofriends/authentication-backend/hashtag"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Maximum lengths of the text of a challenge, in characters
+const (
+	maxChallengeTitleLength       = 100
+	maxChallengeDescriptionLength = 2000
+)
+
+type Challenge struct {
+	repo *repository.PostGreSQL
+}
+
+func (challenge *Challenge) New(repo *repository.PostGreSQL) {
+	challenge.repo = repo
+}
+
+// CreateChallenge creates a challenge
+// @Summary Create a challenge
+// @Description Creates a community challenge, admins only. The goal is counted in units of an eco-action type (goal_type eco_action with action_type) or in posts with a hashtag (goal_type tagged_posts with tag), done between starts_at and ends_at.
+// @Tags challenges
+// @Accept json
+// @Produce json
+// @Param request body util.CreateChallengeRequestBody true "Challenge"
+// @Success 200 {object} util.Response{payload=model.Challenge}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 403 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /challenges [post]
+func (challenge *Challenge) CreateChallenge(w http.ResponseWriter, r *http.Request) {
+	var body = util.CreateChallengeRequestBody{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		util.JsonResponse(w, "Bad request, could not read request body", http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	theChallenge, msg := newChallenge(body)
+	if msg != "" {
+		util.JsonResponse(w, "Bad request, "+msg, http.StatusBadRequest, nil)
+		return
+	}
+	theChallenge.CreatedBy = &userID
+
+	created, err := challenge.repo.CreateChallenge(r.Context(), theChallenge)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			util.JsonResponse(w, "Eco action type not found", http.StatusNotFound, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to create challenge", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully created challenge", http.StatusOK, created)
+}
+
+// DeleteChallenge deletes a challenge
+// @Summary Delete a challenge
+// @Description Deletes a challenge with its participants, admins only
+// @Tags challenges
+// @Produce json
+// @Param id path int true "Challenge ID"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 403 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /challenges/{id} [delete]
+func (challenge *Challenge) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
+	challengeID, ok := readChallengeID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := challenge.repo.DeleteChallenge(r.Context(), challengeID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			util.JsonResponse(w, "Challenge not found", http.StatusNotFound, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to delete challenge", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully deleted challenge", http.StatusOK, nil)
+}
+
+// GetChallenges lists the challenges
+// @Summary Get challenges
+// @Description Returns the challenges, latest starting first, one page at a time
+// @Tags challenges
+// @Produce json
+// @Param status query string false "upcoming, active or ended, every challenge by default"
+// @Param limit query int false "Number of challenges, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[model.Challenge]}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /challenges [get]
+func (challenge *Challenge) GetChallenges(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && status != model.ChallengeStatusUpcoming && status != model.ChallengeStatusActive && status != model.ChallengeStatusEnded {
+		util.JsonResponse(w, "Bad request, status must be upcoming, active or ended", http.StatusBadRequest, nil)
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	challenges, err := challenge.repo.GetChallenges(r.Context(), status, time.Now(), page)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid cursor") {
+			util.JsonResponse(w, "Invalid cursor", http.StatusBadRequest, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to get challenges", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(challenges, page, challengeCursor, asIs[model.Challenge])
+	util.JsonResponse(w, "Successfully got challenges", http.StatusOK, response)
+}
+
+// GetChallenge returns a challenge
+// @Summary Get a challenge
+// @Description Returns a challenge with its number of participants, how many of them completed it and the completion rate
+// @Tags challenges
+// @Produce json
+// @Param id path int true "Challenge ID"
+// @Success 200 {object} util.Response{payload=model.Challenge}
+// @Failure 400 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /challenges/{id} [get]
+func (challenge *Challenge) GetChallenge(w http.ResponseWriter, r *http.Request) {
+	challengeID, ok := readChallengeID(w, r)
+	if !ok {
+		return
+	}
+
+	theChallenge, err := challenge.repo.GetChallengeByID(r.Context(), challengeID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			util.JsonResponse(w, "Challenge not found", http.StatusNotFound, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to get challenge", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully got challenge", http.StatusOK, theChallenge)
+}
+
+// JoinChallenge adds the authenticated user to a challenge
+// @Summary Join a challenge
+// @Description Adds the authenticated user to the participants of a challenge that hasn't ended
+// @Tags challenges
+// @Produce json
+// @Param id path int true "Challenge ID"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /challenges/{id}/join [post]
+func (challenge *Challenge) JoinChallenge(w http.ResponseWriter, r *http.Request) {
+	challengeID, ok := readChallengeID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := challenge.repo.JoinChallenge(r.Context(), challengeID, userID); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			util.JsonResponse(w, "Challenge not found", http.StatusNotFound, nil)
+		case strings.Contains(err.Error(), "has ended"), strings.Contains(err.Error(), "already joined"):
+			util.JsonResponse(w, "Bad request, "+err.Error(), http.StatusBadRequest, nil)
+		default:
+			util.JsonResponse(w, "Internal server error, failed to join challenge", http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	util.JsonResponse(w, "Successfully joined challenge", http.StatusOK, nil)
+}
+
+// LeaveChallenge removes the authenticated user from a challenge
+// @Summary Leave a challenge
+// @Description Removes the authenticated user from the participants of a challenge
+// @Tags challenges
+// @Produce json
+// @Param id path int true "Challenge ID"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /challenges/{id}/join [delete]
+func (challenge *Challenge) LeaveChallenge(w http.ResponseWriter, r *http.Request) {
+	challengeID, ok := readChallengeID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := challenge.repo.LeaveChallenge(r.Context(), challengeID, userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			util.JsonResponse(w, "User hasn't joined this challenge", http.StatusNotFound, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to leave challenge", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully left challenge", http.StatusOK, nil)
+}
+
+// GetParticipants lists the participants of a challenge with their progress
+// @Summary Get challenge participants
+// @Description Returns the participants of a challenge with their progress, latest to join first, one page at a time
+// @Tags challenges
+// @Produce json
+// @Param id path int true "Challenge ID"
+// @Param limit query int false "Number of participants, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[model.ChallengeProgress]}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /challenges/{id}/participants [get]
+func (challenge *Challenge) GetParticipants(w http.ResponseWriter, r *http.Request) {
+	challengeID, ok := readChallengeID(w, r)
+	if !ok {
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	participants, err := challenge.repo.GetChallengeParticipants(r.Context(), challengeID, page)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid cursor") {
+			util.JsonResponse(w, "Invalid cursor", http.StatusBadRequest, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to get challenge participants", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(participants, page, challengeProgressCursor, asIs[model.ChallengeProgress])
+	util.JsonResponse(w, "Successfully got challenge participants", http.StatusOK, response)
+}
+
+// GetProgress returns the progress of a participant of a challenge
+// @Summary Get challenge progress of a user
+// @Description Returns how far a participant got in a challenge and whether they completed it
+// @Tags challenges
+// @Produce json
+// @Param id path int true "Challenge ID"
+// @Param userID path string true "User ID"
+// @Success 200 {object} util.Response{payload=model.ChallengeProgress}
+// @Failure 400 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /challenges/{id}/participants/{userID} [get]
+func (challenge *Challenge) GetProgress(w http.ResponseWriter, r *http.Request) {
+	challengeID, ok := readChallengeID(w, r)
+	if !ok {
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	if _, err := uuid.Parse(userID); err != nil {
+		util.JsonResponse(w, "Bad request, invalid user ID", http.StatusBadRequest, nil)
+		return
+	}
+
+	progress, err := challenge.repo.GetChallengeProgress(r.Context(), challengeID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			util.JsonResponse(w, "User hasn't joined this challenge", http.StatusNotFound, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to get challenge progress", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully got challenge progress", http.StatusOK, progress)
+}
+
+// Reads the challenge ID path parameter, responding with a bad request when it is malformed
+func readChallengeID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	challengeID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, "Bad request, invalid challenge ID", http.StatusBadRequest, nil)
+		return 0, false
+	}
+	return challengeID, true
+}
+
+/*
+Validates the create challenge request body
+
+Objectives:
+  - The title is required and the text must not exceed its maximum length
+  - The challenge must end after it starts
+  - The goal must be a positive quantity of an eco-action type or of posts with a valid hashtag
+
+Params:
+  - body: Create challenge request body
+
+Returns:
+  - The challenge to create
+  - What is wrong with the body, empty when it is valid
+*/
+func newChallenge(body util.CreateChallengeRequestBody) (model.Challenge, string) {
+	title := strings.TrimSpace(body.Title)
+	description := strings.TrimSpace(body.Description)
+
+	if title == "" || utf8.RuneCountInString(title) > maxChallengeTitleLength {
+		return model.Challenge{}, fmt.Sprintf("title is required and must be at most %d characters", maxChallengeTitleLength)
+	}
+
+	if utf8.RuneCountInString(description) > maxChallengeDescriptionLength {
+		return model.Challenge{}, fmt.Sprintf("description must be at most %d characters", maxChallengeDescriptionLength)
+	}
+
+	if body.StartsAt.IsZero() || !body.EndsAt.After(body.StartsAt) {
+		return model.Challenge{}, "ends_at must be after starts_at"
+	}
+
+	if body.GoalQuantity <= 0 || math.IsInf(body.GoalQuantity, 0) || math.IsNaN(body.GoalQuantity) {
+		return model.Challenge{}, "goal_quantity must be greater than 0"
+	}
+
+	challenge := model.Challenge{
+		Title:        title,
+		Description:  description,
+		StartsAt:     body.StartsAt,
+		EndsAt:       body.EndsAt,
+		GoalType:     body.GoalType,
+		GoalQuantity: body.GoalQuantity,
+	}
+
+	switch body.GoalType {
+	case model.ChallengeGoalEcoAction:
+		if body.ActionType == nil || *body.ActionType == "" || body.Tag != nil {
+			return model.Challenge{}, "eco_action goals need an action_type and no tag"
+		}
+		challenge.ActionType = body.ActionType
+	case model.ChallengeGoalTaggedPosts:
+		if body.Tag == nil || body.ActionType != nil {
+			return model.Challenge{}, "tagged_posts goals need a tag and no action_type"
+		}
+		tag, ok := hashtag.Normalize(*body.Tag)
+		if !ok {
+			return model.Challenge{}, "invalid tag"
+		}
+		challenge.Tag = &tag
+	default:
+		return model.Challenge{}, "goal_type must be eco_action or tagged_posts"
+	}
+
+	return challenge, ""
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
 	repository "github.com/ecofriends/authentication-backend/repository"
 	"github.com/ecofriends/authentication-backend/util"
 	"github.com/go-chi/chi/v5"
@@ -20,7 +23,7 @@ func (comment *Comment) New(repo *repository.PostGreSQL) {
 }
 
 // @Summary Create comment
-// @Description Creates a new comment on a post
+// @Description Creates a new comment on a post, or a reply to another comment of the post when parent_id is set. Replies can be nested at most 5 levels deep.
 // @Tags comments
 // @Accept json
 // @Produce json
@@ -51,13 +54,13 @@ func (comment *Comment) CreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	theComment, err := comment.repo.CreateComment(context.Background(), body.UserID.String(), body.PostID, body.Text)
+	theComment, err := comment.repo.CreateComment(context.Background(), body.UserID.String(), body.PostID, body.ParentID, body.Text)
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	util.JsonResponse(w, "Successfully created comment", http.StatusOK, theComment)
+	util.JsonResponse(w, "Successfully created comment", http.StatusOK, util.NewCommentPayload(theComment))
 }
 
 // @Summary Delete comment
@@ -140,6 +143,63 @@ func (comment *Comment) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	util.JsonResponse(w, "Successfully updated comment", http.StatusOK, nil)
 }
 
+// @Summary Get comment revisions
+// @Description Returns the previous versions of the authenticated user's comment, most recently replaced first, one page at a time
+// @Tags comments
+// @Produce json
+// @Security CookieAuth
+// @Param id path int true "Comment ID"
+// @Param limit query int false "Number of revisions, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[model.Revision]}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /comments/{id}/revisions [get]
+func (comment *Comment) GetCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	var msg = ""
+
+	commentID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	// Only the author can see what a comment used to say
+	theComment, err := comment.repo.GetCommentByID(r.Context(), commentID)
+	if err != nil || theComment.UserID != userID {
+		if err == nil || strings.Contains(err.Error(), "not found") {
+			msg = "A comment with that id doesn't exist or isn't yours"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to get comment with that id"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	revisions, err := comment.repo.GetCommentRevisions(r.Context(), commentID, page)
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	response := pagination.NewResponse(revisions, page, revisionCursor, asIs[model.Revision])
+	util.JsonResponse(w, "Successfully got comment revisions", http.StatusOK, response)
+}
+
 // @Summary Get comment by ID
 // @Description Returns a single comment by its ID
 // @Tags comments
@@ -163,48 +223,113 @@ func (comment *Comment) GetCommentByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	util.JsonResponse(w, "Successfully got comments by id", http.StatusOK, theComment)
+	comments := []model.Comment{theComment}
+	if err := attachCommentReactions(r, comment.repo, comments, commentItself); err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get comment reactions", http.StatusInternalServerError, nil)
+		return
+	}
+	theComment = comments[0]
+
+	util.JsonResponse(w, "Successfully got comments by id", http.StatusOK, util.NewCommentPayload(theComment))
 }
 
 // @Summary Get comments by post
-// @Description Returns comments on a specific post with pagination
+// @Description Returns the top-level comments on a specific post, newest first, one page at a time. Replies are fetched per comment.
 // @Tags comments
 // @Produce json
 // @Param post_id query int true "Post ID"
-// @Param limit query int true "Limit number of comments"
-// @Param offset query int true "Offset for pagination"
-// @Success 200 {object} util.Response
+// @Param limit query int false "Number of comments, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.CommentPayload]}
 // @Failure 400 {object} util.Response
 // @Router /comments/post [get]
 func (comment *Comment) GetCommentsByPost(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
-	limit := query.Get("limit")
-	offset := query.Get("offset")
 	postId := query.Get("post_id")
 
-	limitInt, err := strconv.Atoi(limit)
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	postIdInt, err := strconv.Atoi(postId)
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	offsetInt, err := strconv.Atoi(offset)
+	comments, err := comment.repo.GetCommentsByPost(context.Background(), postIdInt, page)
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	postIdInt, err := strconv.Atoi(postId)
+	if err := attachCommentReactions(r, comment.repo, comments, commentOfCommentWithUser); err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get comment reactions", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(comments, page, commentWithUserCursor, util.NewCommentWithUserPayload)
+	util.JsonResponse(w, "Successfully got comments by post", http.StatusOK, response)
+}
+
+// @Summary Get replies to a comment
+// @Description Returns the direct replies to a comment, oldest first, one page at a time
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param limit query int false "Number of replies, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.CommentPayload]}
+// @Failure 400 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /comments/{id}/replies [get]
+func (comment *Comment) GetReplies(w http.ResponseWriter, r *http.Request) {
+	var msg = ""
+
+	commentID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	comments, err := comment.repo.GetCommentsByPost(context.Background(), postIdInt, limitInt, offsetInt)
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := comment.repo.GetCommentByID(r.Context(), commentID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = "A comment with that id doesn't exist"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to get comment with that id"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	replies, err := comment.repo.GetReplies(r.Context(), commentID, page)
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	util.JsonResponse(w, "Successfully got comments by post", http.StatusOK, comments)
+	if err := attachCommentReactions(r, comment.repo, replies, commentOfCommentWithUser); err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get comment reactions", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(replies, page, commentWithUserCursor, util.NewCommentWithUserPayload)
+	util.JsonResponse(w, "Successfully got replies to the comment", http.StatusOK, response)
+}
+
+// Accessors of the comment inside the rows of comment listings
+func commentItself(comment *model.Comment) *model.Comment {
+	return comment
+}
+
+func commentOfCommentWithUser(comment *model.CommentWithUser) *model.Comment {
+	return &comment.Comment
 }
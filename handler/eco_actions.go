@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Eco struct {
+	repo *repository.PostGreSQL
+}
+
+func (eco *Eco) New(repo *repository.PostGreSQL) {
+	eco.repo = repo
+}
+
+// GetActionTypes lists the eco-actions users can log
+// @Summary Get eco-action types
+// @Description Returns the catalog of eco-actions with the unit their quantity is measured in and the CO2e, water and waste saved per unit
+// @Tags eco
+// @Produce json
+// @Success 200 {object} util.Response{payload=[]model.EcoActionType}
+// @Failure 500 {object} util.Response
+// @Router /eco/action_types [get]
+func (eco *Eco) GetActionTypes(w http.ResponseWriter, r *http.Request) {
+	actionTypes, err := eco.repo.GetEcoActionTypes(r.Context())
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get eco action types", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully got eco action types", http.StatusOK, actionTypes)
+}
+
+// LogAction logs an eco-action of the authenticated user
+// @Summary Log an eco-action
+// @Description Logs an action with a quantity in the unit of its type and estimates its savings. The action can be attached to one of the user's posts and backdated by up to 30 days.
+// @Tags eco
+// @Accept json
+// @Produce json
+// @Param request body util.LogEcoActionRequestBody true "Action to log"
+// @Success 200 {object} util.Response{payload=model.EcoAction}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /eco/actions [post]
+func (eco *Eco) LogAction(w http.ResponseWriter, r *http.Request) {
+	var body = util.LogEcoActionRequestBody{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		util.JsonResponse(w, "Bad request, could not read request body", http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if body.Quantity <= 0 || math.IsInf(body.Quantity, 0) || math.IsNaN(body.Quantity) {
+		util.JsonResponse(w, "Bad request, quantity must be greater than 0", http.StatusBadRequest, nil)
+		return
+	}
+
+	now := time.Now()
+	performedAt := now
+	if body.PerformedAt != nil {
+		performedAt = *body.PerformedAt
+	}
+
+	// A little leeway for clocks running ahead
+	if performedAt.After(now.Add(time.Minute)) || performedAt.Before(now.Add(-model.EcoActionBackdateLimit)) {
+		util.JsonResponse(w, "Bad request, actions can only be logged for the last 30 days", http.StatusBadRequest, nil)
+		return
+	}
+
+	action, err := eco.repo.LogEcoAction(r.Context(), userID, body.ActionType, body.Quantity, body.PostID, performedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			util.JsonResponse(w, util.CapitalizeFirstLetter(err.Error()), http.StatusNotFound, nil)
+		case strings.Contains(err.Error(), "exceeds the maximum"):
+			util.JsonResponse(w, "Bad request, "+err.Error(), http.StatusBadRequest, nil)
+		default:
+			util.JsonResponse(w, "Internal server error, failed to log eco action", http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	util.JsonResponse(w, "Successfully logged eco action", http.StatusOK, action)
+}
+
+// DeleteAction deletes an eco-action of the authenticated user
+// @Summary Delete an eco-action
+// @Description Deletes an action the authenticated user logged, removing it from their totals
+// @Tags eco
+// @Produce json
+// @Param id path int true "Eco-action ID"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /eco/actions/{id} [delete]
+func (eco *Eco) DeleteAction(w http.ResponseWriter, r *http.Request) {
+	actionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, "Bad request, invalid eco action ID", http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := eco.repo.DeleteEcoAction(r.Context(), actionID, userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			util.JsonResponse(w, "Eco action not found", http.StatusNotFound, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to delete eco action", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully deleted eco action", http.StatusOK, nil)
+}
+
+// GetActionsByUser lists the eco-actions of a user
+// @Summary Get eco-actions by user
+// @Description Returns the actions of the user, most recently performed first, one page at a time
+// @Tags eco
+// @Produce json
+// @Param user_id query string true "User ID"
+// @Param limit query int false "Number of actions, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[model.EcoAction]}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /eco/actions/user [get]
+func (eco *Eco) GetActionsByUser(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		util.JsonResponse(w, "Bad request, invalid user ID", http.StatusBadRequest, nil)
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	actions, err := eco.repo.GetEcoActionsByUser(r.Context(), userID, page)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid cursor") {
+			util.JsonResponse(w, "Invalid cursor", http.StatusBadRequest, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to get eco actions", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(actions, page, ecoActionCursor, asIs[model.EcoAction])
+	util.JsonResponse(w, "Successfully got eco actions by the user", http.StatusOK, response)
+}
+
+// GetTotals totals the savings of a user
+// @Summary Get eco-action totals
+// @Description Returns the number of actions of the user and their combined savings today, this week, this month and of all time. Periods are calendar periods, weeks start on Monday.
+// @Tags eco
+// @Produce json
+// @Param user_id query string true "User ID"
+// @Param tz query string false "IANA time zone the periods are measured in, e.g. Europe/Berlin, UTC by default"
+// @Success 200 {object} util.Response{payload=[]model.EcoTotals}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /eco/totals [get]
+func (eco *Eco) GetTotals(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		util.JsonResponse(w, "Bad request, invalid user ID", http.StatusBadRequest, nil)
+		return
+	}
+
+	location, ok := readTimeZone(w, r)
+	if !ok {
+		return
+	}
+
+	totals, err := eco.repo.GetEcoTotals(r.Context(), userID, time.Now().In(location))
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get eco action totals", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully got eco action totals", http.StatusOK, totals)
+}
+
+/*
+Reads the time zone of a request from the tz query parameter
+
+Params:
+  - w: The response writer, written to when the time zone is unknown
+  - r: The request
+
+Returns:
+  - The time zone, UTC when none was given
+  - False if a response was already written
+*/
+func readTimeZone(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return time.UTC, true
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		msg := fmt.Sprintf("Bad request, unknown time zone %q", name)
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return nil, false
+	}
+
+	return location, true
+}
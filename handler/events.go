@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	"github.com/ecofriends/authentication-backend/realtime"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/net/websocket"
+)
+
+// Most posts a client can watch at once
+const maxWatchedPosts = 50
+
+// How often idle event streams are written to so proxies keep them open
+const heartbeatInterval = 25 * time.Second
+
+type Events struct {
+	hub *realtime.Hub
+}
+
+func (events *Events) New(hub *realtime.Hub) {
+	events.hub = hub
+}
+
+/*
+eventsMessage struct, a message sent by a WebSocket client
+
+Fields:
+  - Action: string - "subscribe" or "unsubscribe"
+  - PostID: int    - The post to start or stop watching
+*/
+type eventsMessage struct {
+	Action string `json:"action"`
+	PostID int    `json:"post_id"`
+}
+
+// Stream sends real-time events to the authenticated user with Server-Sent Events
+// @Summary Stream real-time events
+// @Description Keeps the connection open and sends the notifications of the user, plus the new comments and reaction changes of the watched posts, as Server-Sent Events. Every event is named after its type and carries the JSON encoded event; data is null when the event was too large and has to be refetched. The stream ends with an error event once the token expires or is revoked.
+// @Tags events
+// @Produce text/event-stream
+// @Param posts query string false "Comma separated IDs of the posts to watch, at most 50"
+// @Success 200 {object} realtime.Event
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Security CookieAuth
+// @Router /events [get]
+func (events *Events) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	topics, err := readWatchedPosts(r.URL.Query().Get("posts"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+
+	// The stream outlives any write timeout of the server
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		log.Println("[FAIL]: could not clear event stream deadline:", err)
+	}
+
+	subscription := events.hub.Subscribe(append(topics, realtime.UserTopic(userID))...)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	if _, err := fmt.Fprint(w, "retry: 5000\n\n"); err != nil {
+		return
+	}
+
+	if err := controller.Flush(); err != nil {
+		log.Println("[FAIL]: could not stream events:", err)
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := checkStreamToken(r.Context()); err != nil {
+				encoded, _ := json.Marshal(streamClosedEvent(err))
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", realtime.EventError, encoded)
+				controller.Flush()
+				return
+			}
+
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				log.Println("[FAIL]: could not encode event:", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, encoded); err != nil {
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// WebSocket sends real-time events to the authenticated user over a WebSocket
+// @Summary Stream real-time events over a WebSocket
+// @Description Upgrades the connection to a WebSocket authenticated with the token cookie and sends the same JSON encoded events as GET /events. Clients watch posts by sending {"action": "subscribe", "post_id": 1} and stop with {"action": "unsubscribe", "post_id": 1}, at most 50 posts at once. Only the API's own origin and ALLOWED_ORIGINS may connect. The connection is closed after an error event once the token expires or is revoked.
+// @Tags events
+// @Param posts query string false "Comma separated IDs of the posts to watch from the start, at most 50"
+// @Success 101
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 403 {object} util.Response
+// @Security CookieAuth
+// @Router /events/ws [get]
+func (events *Events) WebSocket(w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	topics, err := readWatchedPosts(r.URL.Query().Get("posts"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// The cookie is sent by any page, so other sites must not be able to connect
+	if !isAllowedOrigin(r) {
+		msg := "Forbidden: Access to this resource is denied"
+		util.JsonResponse(w, msg, http.StatusForbidden, nil)
+		return
+	}
+
+	server := websocket.Server{
+		// The origin was checked above
+		Handshake: func(config *websocket.Config, r *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+
+			subscription := events.hub.Subscribe(append(topics, realtime.UserTopic(userID))...)
+			defer subscription.Close()
+
+			var sendMutex sync.Mutex
+			send := func(value interface{}) error {
+				sendMutex.Lock()
+				defer sendMutex.Unlock()
+				return websocket.JSON.Send(conn, value)
+			}
+
+			go events.readMessages(conn, subscription, send)
+
+			heartbeat := time.NewTicker(heartbeatInterval)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case <-heartbeat.C:
+					if err := checkStreamToken(r.Context()); err != nil {
+						send(streamClosedEvent(err))
+						return
+					}
+
+					if err := send(realtime.Event{Type: realtime.EventHeartbeat}); err != nil {
+						return
+					}
+				case <-r.Context().Done():
+					return
+				case event, ok := <-subscription.Events():
+					if !ok {
+						return
+					}
+					if err := send(event); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+/*
+Applies the subscribe and unsubscribe messages of a WebSocket client until
+the connection closes, then ends the subscription
+
+Params:
+  - conn:         The WebSocket connection
+  - subscription: The subscription of the connection
+  - send:         Sends a message to the client
+
+Returns:
+  - No return value
+*/
+func (events *Events) readMessages(conn *websocket.Conn, subscription *realtime.Subscription, send func(value interface{}) error) {
+	defer subscription.Close()
+
+	for {
+		var message eventsMessage
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				continue
+			}
+			return
+		}
+
+		var problem string
+		switch {
+		case message.PostID <= 0:
+			problem = "invalid post ID"
+		case message.Action == "subscribe":
+			// The user topic is always subscribed to
+			if subscription.TopicCount() > maxWatchedPosts {
+				problem = fmt.Sprintf("at most %d posts can be watched", maxWatchedPosts)
+				break
+			}
+			subscription.Add(realtime.PostTopic(message.PostID))
+		case message.Action == "unsubscribe":
+			subscription.Remove(realtime.PostTopic(message.PostID))
+		default:
+			problem = "unknown action"
+		}
+
+		if problem != "" {
+			data, _ := json.Marshal(map[string]string{"message": problem})
+			if err := send(realtime.Event{Type: realtime.EventError, Data: data}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+/*
+Checks that the token an event stream was opened with is still good, streams
+outlive the request that authenticated them
+
+Params:
+  - ctx: The context of the stream request, carrying the token claims
+
+Returns:
+  - An error if the token has expired or been revoked since
+*/
+func checkStreamToken(ctx context.Context) error {
+	claims, ok := ctx.Value(util.TokenClaimsKey).(jwt.MapClaims)
+	if !ok {
+		return errors.New("Unauthorized")
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil || !time.Now().Before(exp.Time) {
+		return errors.New("Token has expired")
+	}
+
+	revoked, err := authentication.IsTokenRevoked(ctx, claims)
+	if err != nil {
+		log.Printf("[FAIL]: could not check token revocation: %v", err)
+		return errors.New("Failed to verify token")
+	}
+
+	if revoked {
+		return errors.New("Token has been revoked")
+	}
+
+	return nil
+}
+
+// The error event sent before a stream is closed
+func streamClosedEvent(reason error) realtime.Event {
+	data, _ := json.Marshal(map[string]string{"message": reason.Error()})
+	return realtime.Event{Type: realtime.EventError, Data: data}
+}
+
+/*
+Reads the posts a client wants to watch
+
+Params:
+  - value: Comma separated post IDs, may be empty
+
+Returns:
+  - The topics of the posts
+  - An error if an ID is invalid or there are too many
+*/
+func readWatchedPosts(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	ids := strings.Split(value, ",")
+	if len(ids) > maxWatchedPosts {
+		return nil, fmt.Errorf("Bad request, at most %d posts can be watched", maxWatchedPosts)
+	}
+
+	topics := make([]string, 0, len(ids))
+	for _, id := range ids {
+		postID, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil || postID <= 0 {
+			return nil, fmt.Errorf("Bad request, invalid post ID")
+		}
+		topics = append(topics, realtime.PostTopic(postID))
+	}
+
+	return topics, nil
+}
+
+/*
+Reports whether a WebSocket may be opened from the origin of a request
+
+Objectives:
+  - Allow clients that send no origin, browsers always send one
+  - Allow the API's own host
+  - Allow the origins listed in ALLOWED_ORIGINS, separated by commas
+
+Params:
+  - r: The upgrade request
+
+Returns:
+  - Whether the origin is allowed
+*/
+func isAllowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed != "" && strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
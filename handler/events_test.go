@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Revocation store that reports every token as revoked
+type revokedEverything struct{}
+
+func (revokedEverything) RevokeToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error {
+	return nil
+}
+
+func (revokedEverything) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return true, nil
+}
+
+func (revokedEverything) RevokeAllUserTokens(ctx context.Context, userID string) (time.Time, error) {
+	return time.Now(), nil
+}
+
+func (revokedEverything) GetTokensRevokedAt(ctx context.Context, userID string) (*time.Time, error) {
+	return nil, nil
+}
+
+func streamContext(expiresAt time.Time) context.Context {
+	claims := jwt.MapClaims{
+		"sub": "user",
+		"jti": "jti",
+		"iat": float64(time.Now().Add(-time.Minute).Unix()),
+		"exp": float64(expiresAt.Unix()),
+	}
+	return context.WithValue(context.Background(), util.TokenClaimsKey, claims)
+}
+
+func TestCheckStreamToken(t *testing.T) {
+	if err := checkStreamToken(streamContext(time.Now().Add(time.Minute))); err != nil {
+		t.Errorf("valid token: %v", err)
+	}
+
+	if err := checkStreamToken(streamContext(time.Now().Add(-time.Second))); err == nil {
+		t.Error("expired token was accepted")
+	}
+
+	if err := checkStreamToken(context.Background()); err == nil {
+		t.Error("stream without claims was accepted")
+	}
+}
+
+func TestCheckStreamTokenRevoked(t *testing.T) {
+	authentication.UseDenylist(authentication.NewDenylist(revokedEverything{}))
+	t.Cleanup(func() { authentication.UseDenylist(nil) })
+
+	if err := checkStreamToken(streamContext(time.Now().Add(time.Minute))); err == nil {
+		t.Error("revoked token was accepted")
+	}
+}
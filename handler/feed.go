@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+// One popular post is mixed in for every this many posts of the timeline
+const popularPostRatio = 10
+
+type Feed struct {
+	repo    *repository.PostGreSQL
+	storage storage.Storage
+}
+
+func (feed *Feed) New(repo *repository.PostGreSQL) {
+	feed.repo = repo
+}
+
+// Sets the storage post images are kept in
+func (feed *Feed) WithStorage(store storage.Storage) {
+	feed.storage = store
+}
+
+// GetFeed returns the authenticated user's home feed
+// @Summary Get the home feed
+// @Description Returns the posts of the user and of the users they follow, newest first, with a few popular posts mixed in. Pass the returned next_cursor to get the next page.
+// @Tags feed
+// @Produce json
+// @Param limit query int false "Number of posts from followed users, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.FeedPostPayload]}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /feed [get]
+func (feed *Feed) GetFeed(w http.ResponseWriter, r *http.Request) {
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	timeline, err := feed.repo.GetTimelinePosts(r.Context(), userID, page)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid cursor") {
+			util.JsonResponse(w, "Invalid cursor", http.StatusBadRequest, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to get feed", http.StatusInternalServerError, nil)
+		return
+	}
+
+	if err := attachPostDetails(r, feed.repo, feed.storage, timeline); err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get feed", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(timeline, page, postCursor, func(post model.Post) model.FeedPost {
+		return model.FeedPost{Post: post, Source: model.FeedSourceFollowing}
+	})
+
+	// Popular posts come from the same time window as this page, so the
+	// window of the next page never shows them again
+	var after *pagination.Cursor
+	if response.HasMore {
+		last := postCursor(response.Items[len(response.Items)-1].Post)
+		after = &last
+	}
+
+	popularLimit := (page.Limit + popularPostRatio - 1) / popularPostRatio
+	popular, err := feed.repo.GetPopularPosts(r.Context(), userID, page.After, after, popularLimit)
+	if err == nil {
+		err = attachPostDetails(r, feed.repo, feed.storage, popular)
+	}
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get feed", http.StatusInternalServerError, nil)
+		return
+	}
+
+	posts := response.Items
+	for _, post := range popular {
+		posts = append(posts, model.FeedPost{Post: post, Source: model.FeedSourcePopular})
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		if !posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].CreatedAt.After(posts[j].CreatedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	payload := pagination.Response[util.FeedPostPayload]{
+		Items:      util.NewFeedPostPayloads(posts),
+		NextCursor: response.NextCursor,
+		HasMore:    response.HasMore,
+	}
+
+	util.JsonResponse(w, "Successfully got feed", http.StatusOK, payload)
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// FollowUser makes the authenticated user follow another user
+// @Summary Follow a user
+// @Description Follows the user with the given ID as the authenticated user
+// @Tags user
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 409 {object} util.Response
+// @Security CookieAuth
+// @Router /user/{id}/follow [post]
+func (user *User) FollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID := chi.URLParam(r, "id")
+	var msg = ""
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if userID == followeeID {
+		msg = "Users cannot follow themselves"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	if !user.userExists(r, followeeID) {
+		msg = "A user with that id doesn't exist"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := user.repo.FollowUser(r.Context(), userID, followeeID); err != nil {
+		if strings.Contains(err.Error(), "already follows") {
+			msg = "You already follow this user"
+			util.JsonResponse(w, msg, http.StatusConflict, nil)
+			return
+		}
+		msg = "Internal server error, failed to follow user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully followed user", http.StatusOK, nil)
+}
+
+// UnfollowUser makes the authenticated user unfollow another user
+// @Summary Unfollow a user
+// @Description Unfollows the user with the given ID as the authenticated user
+// @Tags user
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/{id}/follow [delete]
+func (user *User) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID := chi.URLParam(r, "id")
+	var msg = ""
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if _, err := uuid.Parse(followeeID); err != nil {
+		msg = "You don't follow this user"
+		util.JsonResponse(w, msg, http.StatusNotFound, nil)
+		return
+	}
+
+	if err := user.repo.UnfollowUser(r.Context(), userID, followeeID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = "You don't follow this user"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to unfollow user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully unfollowed user", http.StatusOK, nil)
+}
+
+// GetFollowers lists the users following a user
+// @Summary Get followers
+// @Description Returns the users following the user, most recent first, one page at a time
+// @Tags user
+// @Produce json
+// @Param id path string true "User ID"
+// @Param limit query int false "Number of users, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[model.Follow]}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /user/{id}/followers [get]
+func (user *User) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	user.listFollows(w, r, "followers", user.repo.GetFollowers)
+}
+
+// GetFollowing lists the users a user follows
+// @Summary Get followed users
+// @Description Returns the users the user follows, most recent first, one page at a time
+// @Tags user
+// @Produce json
+// @Param id path string true "User ID"
+// @Param limit query int false "Number of users, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[model.Follow]}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /user/{id}/following [get]
+func (user *User) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	user.listFollows(w, r, "followed users", user.repo.GetFollowing)
+}
+
+// Shared implementation of the followers and following listings
+func (user *User) listFollows(w http.ResponseWriter, r *http.Request, name string, fetch func(ctx context.Context, userID string, page pagination.Page) ([]model.Follow, error)) {
+	requestedID := chi.URLParam(r, "id")
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	if !user.userExists(r, requestedID) {
+		msg := "A user with that id doesn't exist"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	follows, err := fetch(r.Context(), requestedID, page)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid cursor") {
+			util.JsonResponse(w, "Invalid cursor", http.StatusBadRequest, nil)
+			return
+		}
+		msg := fmt.Sprintf("Internal server error, failed to get %s", name)
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(follows, page, followCursor, asIs[model.Follow])
+
+	util.JsonResponse(w, fmt.Sprintf("Successfully got %s", name), http.StatusOK, response)
+}
+
+// Reports whether the ID belongs to a user that is not awaiting deletion
+func (user *User) userExists(r *http.Request, userID string) bool {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false
+	}
+
+	theUser, err := user.repo.GetUserByID(r.Context(), userID)
+	return err == nil && theUser.DeletedAt == nil
+}
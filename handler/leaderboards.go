@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/ecofriends/authentication-backend/model"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+// Number of entries returned by default and at most, and the deepest page that can be requested
+const (
+	defaultLeaderboardLimit = 20
+	maxLeaderboardLimit     = 100
+	maxLeaderboardPage      = 50
+)
+
+type Leaderboard struct {
+	repo *repository.PostGreSQL
+}
+
+func (leaderboard *Leaderboard) New(repo *repository.PostGreSQL) {
+	leaderboard.repo = repo
+}
+
+// GetLeaderboard ranks users by likes received, posts made or eco impact
+// @Summary Get a leaderboard
+// @Description Ranks the users by the likes their posts received, the posts they made or the kilograms of CO2e their eco-actions saved this week, this month or of all time. Weeks start on Monday in UTC. Scores are refreshed every few minutes, see refreshed_at. The following scope ranks the signed in user against the users they follow, and me holds their own place when signed in.
+// @Tags leaderboards
+// @Produce json
+// @Param metric query string false "likes_received, posts or eco_impact, likes_received by default"
+// @Param period query string false "week, month or all_time, week by default"
+// @Param scope query string false "global or following, global by default"
+// @Param limit query int false "Number of entries, 20 by default and at most 100"
+// @Param page query int false "Page of entries, starting at 1"
+// @Success 200 {object} util.Response{payload=model.Leaderboard}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /leaderboards [get]
+func (leaderboard *Leaderboard) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	metric := queryOr(r, "metric", model.LeaderboardLikesReceived)
+	if !slices.Contains(model.LeaderboardMetrics, metric) {
+		util.JsonResponse(w, "Bad request, metric must be likes_received, posts or eco_impact", http.StatusBadRequest, nil)
+		return
+	}
+
+	period := queryOr(r, "period", model.EcoPeriodWeek)
+	if !slices.Contains(model.LeaderboardPeriods, period) {
+		util.JsonResponse(w, "Bad request, period must be week, month or all_time", http.StatusBadRequest, nil)
+		return
+	}
+
+	scope := queryOr(r, "scope", model.LeaderboardScopeGlobal)
+	if scope != model.LeaderboardScopeGlobal && scope != model.LeaderboardScopeFollowing {
+		util.JsonResponse(w, "Bad request, scope must be global or following", http.StatusBadRequest, nil)
+		return
+	}
+
+	userID := callerID(r)
+	if scope == model.LeaderboardScopeFollowing && userID == "" {
+		util.JsonResponse(w, "Unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	limit, ok := readQueryInt(w, r, "limit", defaultLeaderboardLimit, maxLeaderboardLimit)
+	if !ok {
+		return
+	}
+
+	page, ok := readQueryInt(w, r, "page", 1, maxLeaderboardPage)
+	if !ok {
+		return
+	}
+
+	theLeaderboard, err := leaderboard.repo.GetLeaderboard(r.Context(), metric, period, scope, userID, limit, (page-1)*limit)
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get leaderboard", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully got leaderboard", http.StatusOK, theLeaderboard)
+}
+
+// Returns a query parameter, or the fallback when it is missing
+func queryOr(r *http.Request, name string, fallback string) string {
+	if value := r.URL.Query().Get(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/ecofriends/authentication-backend/pagination"
 	repository "github.com/ecofriends/authentication-backend/repository"
 	"github.com/ecofriends/authentication-backend/util"
 )
@@ -19,7 +21,7 @@ func (like *Like) New(repo *repository.PostGreSQL) {
 }
 
 // @Summary Like a post
-// @Description Like a post as an authenticated user
+// @Description Like a post as an authenticated user. Fails with a conflict when the user already left another reaction, which only the reactions endpoints can change.
 // @Tags likes
 // @Accept json
 // @Produce json
@@ -29,6 +31,7 @@ func (like *Like) New(repo *repository.PostGreSQL) {
 // @Failure 400 {object} util.Response
 // @Failure 401 {object} util.Response
 // @Failure 403 {object} util.Response
+// @Failure 409 {object} util.Response
 // @Router /likes/like [post]
 func (like *Like) LikePost(w http.ResponseWriter, r *http.Request) {
 	var body = util.LikePostRequestBody{}
@@ -51,6 +54,10 @@ func (like *Like) LikePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := like.repo.LikePost(context.Background(), body.UserID.String(), body.PostID); err != nil {
+		if strings.Contains(err.Error(), "already reacted") {
+			util.JsonResponse(w, err.Error(), http.StatusConflict, nil)
+			return
+		}
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
@@ -153,38 +160,29 @@ func (like *Like) HasLiked(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get liked posts by user
-// @Description Returns a paginated list of posts liked by the user
+// @Description Returns the likes of the user, newest first, one page at a time
 // @Tags likes
 // @Produce json
 // @Param user_id query string true "User ID"
-// @Param limit query int true "Limit"
-// @Param offset query int true "Offset"
-// @Success 200 {object} util.Response
+// @Param limit query int false "Number of likes, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.LikePayload]}
 // @Failure 400 {object} util.Response
 // @Router /likes/user_likes [get]
 func (like *Like) GetLikesByUser(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	limit := query.Get("limit")
-	offset := query.Get("offset")
-	userId := query.Get("user_id")
-
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
-		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
-		return
-	}
+	userId := r.URL.Query().Get("user_id")
 
-	offsetInt, err := strconv.Atoi(offset)
-	if err != nil {
-		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+	page, ok := readPage(w, r)
+	if !ok {
 		return
 	}
 
-	postsLike, err := like.repo.GetLikesByUser(context.Background(), userId, limitInt, offsetInt)
+	postsLike, err := like.repo.GetLikesByUser(context.Background(), userId, page)
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	util.JsonResponse(w, "Successfully got likes by the user", http.StatusOK, postsLike)
+	response := pagination.NewResponse(postsLike, page, likeCursor, util.NewLikePayload)
+	util.JsonResponse(w, "Successfully got likes by the user", http.StatusOK, response)
 }
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ecofriends/authentication-backend/media"
+	"github.com/ecofriends/authentication-backend/model"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// UploadMedia attaches an image to a post
+// @Summary Upload an image to a post
+// @Description Attaches a jpeg, png, gif or webp image of at most 10 MB to the authenticated user's post, up to 4 per post. A thumbnail is generated server-side.
+// @Tags posts
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param file formData file true "Image file"
+// @Success 200 {object} util.Response{payload=util.MediaPayload}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 413 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /posts/{id}/media [post]
+func (post *Post) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	var msg = ""
+
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	// Check ownership before anything is written to storage
+	thePost, err := post.repo.GetPostByID(r.Context(), postID)
+	if err != nil || thePost.UserID != userID {
+		if err == nil || strings.Contains(err.Error(), "not found") {
+			msg = "A post with that id doesn't exist or isn't yours"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to get post with that id"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadBytes+1<<20)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			util.JsonResponse(w, util.CapitalizeFirstLetter(media.ErrTooLarge.Error()), http.StatusRequestEntityTooLarge, nil)
+			return
+		}
+		msg = "Bad request, an image must be sent in the file field"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadBytes+1))
+	if err != nil {
+		msg = "Bad request, could not read the uploaded file"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	image, err := media.Process(data)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, media.ErrTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		util.JsonResponse(w, util.CapitalizeFirstLetter(err.Error()), status, nil)
+		return
+	}
+
+	name := uuid.New().String()
+	theMedia := model.Media{
+		PostID:       postID,
+		StorageKey:   fmt.Sprintf("posts/%d/%s.%s", postID, name, image.Extension),
+		ThumbnailKey: fmt.Sprintf("posts/%d/%s_thumb.jpg", postID, name),
+		MimeType:     image.MimeType,
+		Width:        image.Width,
+		Height:       image.Height,
+		SizeBytes:    len(image.Data),
+	}
+
+	if err := post.storage.Put(r.Context(), theMedia.StorageKey, image.Data, image.MimeType); err != nil {
+		log.Println("[FAIL]: could not store media:", err)
+		msg = "Internal server error, failed to store image"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if err := post.storage.Put(r.Context(), theMedia.ThumbnailKey, image.Thumbnail, "image/jpeg"); err != nil {
+		log.Println("[FAIL]: could not store thumbnail:", err)
+		deleteStoredFiles(post.storage, theMedia.StorageKey)
+		msg = "Internal server error, failed to store image"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	created, err := post.repo.CreateMedia(r.Context(), userID, theMedia)
+	if err != nil {
+		deleteStoredFiles(post.storage, theMedia.StorageKey, theMedia.ThumbnailKey)
+		if strings.Contains(err.Error(), "maximum number") {
+			msg = fmt.Sprintf("A post can have at most %d images", model.MaxMediaPerPost)
+			util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			msg = "A post with that id doesn't exist or isn't yours"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to save image"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	created.URL = post.storage.URL(created.StorageKey)
+	created.ThumbnailURL = post.storage.URL(created.ThumbnailKey)
+
+	util.JsonResponse(w, "Successfully uploaded image", http.StatusOK, util.NewMediaPayload(created))
+}
+
+// DeleteMedia removes an image from a post
+// @Summary Delete an image from a post
+// @Description Removes an image from the authenticated user's post
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param mediaID path int true "Media ID"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /posts/{id}/media/{mediaID} [delete]
+func (post *Post) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	var msg = ""
+
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	mediaID, err := strconv.Atoi(chi.URLParam(r, "mediaID"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	theMedia, err := post.repo.DeleteMedia(r.Context(), mediaID, postID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = "An image with that id doesn't exist or isn't yours"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to delete image"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	deleteStoredFiles(post.storage, theMedia.StorageKey, theMedia.ThumbnailKey)
+
+	util.JsonResponse(w, "Successfully deleted image", http.StatusOK, nil)
+}
+
+/*
+Loads the images of the posts and resolves their URLs
+
+Params:
+  - ctx:   The request context
+  - repo:  The repository to load the media from
+  - store: The storage the media is served from
+  - posts: The posts, their Media field is set in place
+
+Returns:
+  - An error if the media could not be loaded
+*/
+func attachMedia(ctx context.Context, repo *repository.PostGreSQL, store storage.Storage, posts []model.Post) error {
+	postIDs := make([]int, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	mediaByPost, err := repo.GetMediaByPosts(ctx, postIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Media = mediaByPost[posts[i].ID]
+		resolveMediaURLs(store, posts[i].Media)
+	}
+
+	return nil
+}
+
+// Sets the public URLs of media loaded from the database
+func resolveMediaURLs(store storage.Storage, media []model.Media) {
+	for i := range media {
+		media[i].URL = store.URL(media[i].StorageKey)
+		media[i].ThumbnailURL = store.URL(media[i].ThumbnailKey)
+	}
+}
+
+// Removes files from storage, failures only leave orphaned files behind and are logged
+func deleteStoredFiles(store storage.Storage, keys ...string) {
+	for _, key := range keys {
+		if err := store.Delete(context.Background(), key); err != nil {
+			log.Println("[FAIL]: could not delete stored file:", err)
+		}
+	}
+}
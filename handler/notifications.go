@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ecofriends/authentication-backend/pagination"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+)
+
+type Notification struct {
+	repo *repository.PostGreSQL
+}
+
+func (notification *Notification) New(repo *repository.PostGreSQL) {
+	notification.repo = repo
+}
+
+// GetNotifications lists the authenticated user's notifications
+// @Summary Get notifications
+// @Description Returns the notifications of the user, newest first, one page at a time. Similar events, such as reactions on the same post, are coalesced into one notification until it is read.
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Only return unread notifications"
+// @Param limit query int false "Number of notifications, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.NotificationPayload]}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /notifications [get]
+func (notification *Notification) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, err := notification.repo.GetNotifications(r.Context(), userID, unreadOnly, page)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid cursor") {
+			util.JsonResponse(w, "Invalid cursor", http.StatusBadRequest, nil)
+			return
+		}
+		util.JsonResponse(w, "Internal server error, failed to get notifications", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(notifications, page, notificationCursor, util.NewNotificationPayload)
+	util.JsonResponse(w, "Successfully got notifications", http.StatusOK, response)
+}
+
+// GetUnreadCount counts the authenticated user's unread notifications
+// @Summary Count unread notifications
+// @Description Returns the number of unread notifications of the user
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /notifications/unread_count [get]
+func (notification *Notification) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	count, err := notification.repo.GetUnreadNotificationCount(r.Context(), userID)
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to count notifications", http.StatusInternalServerError, nil)
+		return
+	}
+
+	payload := map[string]int{"unread": count}
+	util.JsonResponse(w, "Successfully counted unread notifications", http.StatusOK, payload)
+}
+
+// MarkRead marks a notification as read
+// @Summary Mark a notification as read
+// @Description Marks one of the user's notifications as read, later events of the same kind start a new notification
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /notifications/{id}/read [post]
+func (notification *Notification) MarkRead(w http.ResponseWriter, r *http.Request) {
+	var msg = ""
+
+	notificationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := notification.repo.MarkNotificationRead(r.Context(), notificationID, userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = "A notification with that id doesn't exist"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to mark notification as read"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully marked notification as read", http.StatusOK, nil)
+}
+
+// MarkAllRead marks every notification as read
+// @Summary Mark all notifications as read
+// @Description Marks every unread notification of the user as read
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /notifications/read_all [post]
+func (notification *Notification) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	marked, err := notification.repo.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to mark notifications as read", http.StatusInternalServerError, nil)
+		return
+	}
+
+	payload := map[string]int64{"marked": marked}
+	util.JsonResponse(w, "Successfully marked all notifications as read", http.StatusOK, payload)
+}
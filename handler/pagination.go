@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+/*
+Reads the page of a list request
+
+Objectives:
+  - Parse the limit and cursor query parameters
+  - Respond with a bad request if they are malformed
+
+Params:
+  - w: The response writer, written to when the page is invalid
+  - r: The request
+
+Returns:
+  - The page
+  - False if a response was already written
+*/
+func readPage(w http.ResponseWriter, r *http.Request) (pagination.Page, bool) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		util.JsonResponse(w, util.CapitalizeFirstLetter(err.Error()), http.StatusBadRequest, nil)
+		return pagination.Page{}, false
+	}
+	return page, true
+}
+
+// Sends the rows of a listing to the client unchanged
+func asIs[T any](row T) T {
+	return row
+}
+
+// Cursors of the rows of each listing
+func postCursor(post model.Post) pagination.Cursor {
+	return pagination.NewCursor(post.CreatedAt, post.ID)
+}
+
+func commentCursor(comment model.Comment) pagination.Cursor {
+	return pagination.NewCursor(comment.CreatedAt, comment.ID)
+}
+
+func commentWithUserCursor(comment model.CommentWithUser) pagination.Cursor {
+	return commentCursor(comment.Comment)
+}
+
+func likeCursor(like model.PostLike) pagination.Cursor {
+	return pagination.NewCursor(like.CreatedAt, like.PostID)
+}
+
+func revisionCursor(revision model.Revision) pagination.Cursor {
+	return pagination.NewCursor(revision.RevisedAt, revision.ID)
+}
+
+func followCursor(follow model.Follow) pagination.Cursor {
+	return pagination.NewCursor(follow.FollowedAt, follow.UserID)
+}
+
+func reactionCursor(reaction model.Reaction) pagination.Cursor {
+	return pagination.NewCursor(reaction.CreatedAt, reaction.TargetID)
+}
+
+func notificationCursor(notification model.Notification) pagination.Cursor {
+	return pagination.NewCursor(notification.CreatedAt, notification.ID)
+}
+
+// Actions can be backdated, so they are listed by when they were performed
+func ecoActionCursor(action model.EcoAction) pagination.Cursor {
+	return pagination.NewCursor(action.PerformedAt, action.ID)
+}
+
+func challengeCursor(challenge model.Challenge) pagination.Cursor {
+	return pagination.NewCursor(challenge.StartsAt, challenge.ID)
+}
+
+func challengeProgressCursor(participant model.ChallengeProgress) pagination.Cursor {
+	return pagination.NewCursor(participant.JoinedAt, participant.UserID)
+}
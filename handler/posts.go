@@ -8,19 +8,28 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
 	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
 	"github.com/ecofriends/authentication-backend/util"
 	"github.com/go-chi/chi/v5"
 )
 
 type Post struct {
-	repo *repository.PostGreSQL
+	repo    *repository.PostGreSQL
+	storage storage.Storage
 }
 
 func (post *Post) New(repo *repository.PostGreSQL) {
 	post.repo = repo
 }
 
+// Sets the storage post images are kept in
+func (post *Post) WithStorage(store storage.Storage) {
+	post.storage = store
+}
+
 // CreatePost creates a new post
 // @Summary Create a new post
 // @Description Allows an authenticated user to create a new post
@@ -60,7 +69,7 @@ func (post *Post) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	util.JsonResponse(w, "Successfully created post", http.StatusOK, thePost)
+	util.JsonResponse(w, "Successfully created post", http.StatusOK, util.NewPostPayload(thePost))
 }
 
 // DeletePost deletes a post
@@ -96,11 +105,20 @@ func (post *Post) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Collected first, the rows are removed together with the post
+	keys, err := post.repo.GetMediaKeysByPost(r.Context(), body.PostID)
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get post details", http.StatusInternalServerError, nil)
+		return
+	}
+
 	if err := post.repo.DeletePost(context.Background(), body.PostID, body.UserID.String()); err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
+	deleteStoredFiles(post.storage, keys...)
+
 	util.JsonResponse(w, "Successfully deleted post", http.StatusOK, nil)
 }
 
@@ -137,76 +155,200 @@ func (post *Post) GetPostByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	posts := []model.Post{thePost}
+	if err := attachPostDetails(r, post.repo, post.storage, posts); err != nil {
+		msg = "Internal server error, failed to get post details"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+	thePost = posts[0]
+
 	msg = fmt.Sprintf("Successfully fetched user with the id: %s", id)
-	util.JsonResponse(w, msg, http.StatusOK, thePost)
+	util.JsonResponse(w, msg, http.StatusOK, util.NewPostPayload(thePost))
 }
 
-// @Summary Get all posts
-// @Description Returns all posts with pagination
+// UpdatePost edits a post
+// @Summary Edit a post
+// @Description Replaces the text of the authenticated user's post, the previous version is kept in the revision history
 // @Tags posts
+// @Accept json
 // @Produce json
-// @Param limit query int true "Limit number of posts"
-// @Param offset query int true "Offset for pagination"
-// @Success 200 {object} util.Response
+// @Param id path int true "Post ID"
+// @Param request body util.UpdatePostRequestBody true "New post text"
+// @Success 200 {object} util.Response{payload=util.PostPayload}
 // @Failure 400 {object} util.Response
-// @Router /posts/all [get]
-func (post *Post) GetAllPosts(w http.ResponseWriter, r *http.Request) {
-	limit := r.URL.Query().Get("limit")
-	offset := r.URL.Query().Get("offset")
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /posts/{id} [put]
+func (post *Post) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	var body = util.UpdatePostRequestBody{}
+	var msg = ""
 
-	limitInt, err := strconv.Atoi(limit)
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	offsetInt, err := strconv.Atoi(offset)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	if strings.TrimSpace(body.Text) == "" {
+		msg = "Bad request, post text is empty"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	thePost, err := post.repo.UpdatePost(r.Context(), postID, userID, body.Text)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = "A post with that id doesn't exist or isn't yours"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to update post"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	posts := []model.Post{thePost}
+	if err := attachPostDetails(r, post.repo, post.storage, posts); err != nil {
+		msg = "Internal server error, failed to get post details"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+	thePost = posts[0]
+
+	util.JsonResponse(w, "Successfully updated post", http.StatusOK, util.NewPostPayload(thePost))
+}
+
+// GetPostRevisions lists the previous versions of a post
+// @Summary Get post revisions
+// @Description Returns the previous versions of the authenticated user's post, most recently replaced first, one page at a time
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param limit query int false "Number of revisions, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[model.Revision]}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /posts/{id}/revisions [get]
+func (post *Post) GetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	var msg = ""
+
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	posts, err := post.repo.GetAllPosts(context.Background(), limitInt, offsetInt)
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	// Only the author can see what a post used to say
+	thePost, err := post.repo.GetPostByID(r.Context(), postID)
+	if err != nil || thePost.UserID != userID {
+		if err == nil || strings.Contains(err.Error(), "not found") {
+			msg = "A post with that id doesn't exist or isn't yours"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to get post with that id"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	revisions, err := post.repo.GetPostRevisions(r.Context(), postID, page)
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	util.JsonResponse(w, "Successfully got all posts", http.StatusOK, posts)
+	response := pagination.NewResponse(revisions, page, revisionCursor, asIs[model.Revision])
+	util.JsonResponse(w, "Successfully got post revisions", http.StatusOK, response)
+}
+
+// @Summary Get all posts
+// @Description Returns all posts, newest first, one page at a time
+// @Tags posts
+// @Produce json
+// @Param limit query int false "Number of posts, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.PostPayload]}
+// @Failure 400 {object} util.Response
+// @Router /posts/all [get]
+func (post *Post) GetAllPosts(w http.ResponseWriter, r *http.Request) {
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := post.repo.GetAllPosts(context.Background(), page)
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := attachPostDetails(r, post.repo, post.storage, posts); err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get post details", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(posts, page, postCursor, util.NewPostPayload)
+	util.JsonResponse(w, "Successfully got all posts", http.StatusOK, response)
 }
 
 // @Summary Get posts by user
-// @Description Returns posts made by a specific user with pagination
+// @Description Returns posts made by a specific user, newest first, one page at a time
 // @Tags posts
 // @Produce json
 // @Param user_id query string true "User ID"
-// @Param limit query int true "Limit number of posts"
-// @Param offset query int true "Offset for pagination"
-// @Success 200 {object} util.Response
+// @Param limit query int false "Number of posts, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.PostPayload]}
 // @Failure 400 {object} util.Response
 // @Router /posts/user [get]
 func (post *Post) GetPostsByUser(w http.ResponseWriter, r *http.Request) {
-	limit := r.URL.Query().Get("limit")
-	offset := r.URL.Query().Get("offset")
 	userId := r.URL.Query().Get("user_id")
 
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
-		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+	page, ok := readPage(w, r)
+	if !ok {
 		return
 	}
 
-	offsetInt, err := strconv.Atoi(offset)
+	posts, err := post.repo.GetPostsByUser(context.Background(), userId, page)
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	posts, err := post.repo.GetPostsByUser(context.Background(), userId, limitInt, offsetInt)
-	if err != nil {
-		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+	if err := attachPostDetails(r, post.repo, post.storage, posts); err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get post details", http.StatusInternalServerError, nil)
 		return
 	}
 
-	util.JsonResponse(w, "Successfully got posts by user", http.StatusOK, posts)
+	response := pagination.NewResponse(posts, page, postCursor, util.NewPostPayload)
+	util.JsonResponse(w, "Successfully got posts by user", http.StatusOK, response)
 }
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ecofriends/authentication-backend/util"
+	validators "github.com/ecofriends/authentication-backend/validator"
+)
+
+// UpdateProfile updates the authenticated user's public profile
+// @Summary Update the public profile
+// @Description Changes the display name, bio, location and/or avatar URL; omitted fields are left unchanged and empty strings clear a field
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body util.UpdateProfileRequestBody true "Fields to change"
+// @Success 200 {object} util.Response{payload=util.PublicUserPayload}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me/profile [patch]
+func (user *User) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var body = util.UpdateProfileRequestBody{}
+	var msg = ""
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		msg = "Bad request, invalid profile fields"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	util.SanitizeUserInput(&body)
+
+	if err := validators.ValidateProfileUpdate(&body); err != nil {
+		msg = util.CapitalizeFirstLetter(err.Error())
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	profile, err := user.repo.GetProfile(r.Context(), userID)
+	if err != nil {
+		msg = "Internal server error, failed to get profile"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	if body.DisplayName != nil {
+		profile.DisplayName = *body.DisplayName
+	}
+	if body.Bio != nil {
+		profile.Bio = *body.Bio
+	}
+	if body.Location != nil {
+		profile.Location = *body.Location
+	}
+	if body.AvatarURL != nil {
+		profile.AvatarURL = *body.AvatarURL
+	}
+
+	if err := user.repo.UpsertProfile(r.Context(), profile); err != nil {
+		msg = "Internal server error, failed to update profile"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	stats, err := user.repo.GetUserStats(r.Context(), userID)
+	if err != nil {
+		msg = "Internal server error, failed to get profile"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	badges, err := user.repo.GetBadgesByUser(r.Context(), userID)
+	if err != nil {
+		msg = "Internal server error, failed to get profile"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully updated profile", http.StatusOK, util.NewPublicUserPayload(profile, stats, badges))
+}
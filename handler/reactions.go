@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ecofriends/authentication-backend/model"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+)
+
+type Reaction struct {
+	repo *repository.PostGreSQL
+}
+
+func (reaction *Reaction) New(repo *repository.PostGreSQL) {
+	reaction.repo = repo
+}
+
+// ReactToPost leaves a reaction on a post
+// @Summary React to a post
+// @Description Leaves a like, inspiring, helpful or planted_a_tree reaction on a post, replacing the user's previous reaction
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param request body util.ReactionRequestBody true "Reaction type"
+// @Success 200 {object} util.Response{payload=model.ReactionSummary}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /posts/{id}/reactions [put]
+func (reaction *Reaction) ReactToPost(w http.ResponseWriter, r *http.Request) {
+	reaction.react(w, r, model.ReactionTargetPost)
+}
+
+// RemovePostReaction removes the user's reaction from a post
+// @Summary Remove a reaction from a post
+// @Description Removes the authenticated user's reaction from a post
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} util.Response{payload=model.ReactionSummary}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /posts/{id}/reactions [delete]
+func (reaction *Reaction) RemovePostReaction(w http.ResponseWriter, r *http.Request) {
+	reaction.unreact(w, r, model.ReactionTargetPost)
+}
+
+// ReactToComment leaves a reaction on a comment
+// @Summary React to a comment
+// @Description Leaves a like, inspiring, helpful or planted_a_tree reaction on a comment, replacing the user's previous reaction
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param request body util.ReactionRequestBody true "Reaction type"
+// @Success 200 {object} util.Response{payload=model.ReactionSummary}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /comments/{id}/reactions [put]
+func (reaction *Reaction) ReactToComment(w http.ResponseWriter, r *http.Request) {
+	reaction.react(w, r, model.ReactionTargetComment)
+}
+
+// RemoveCommentReaction removes the user's reaction from a comment
+// @Summary Remove a reaction from a comment
+// @Description Removes the authenticated user's reaction from a comment
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} util.Response{payload=model.ReactionSummary}
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /comments/{id}/reactions [delete]
+func (reaction *Reaction) RemoveCommentReaction(w http.ResponseWriter, r *http.Request) {
+	reaction.unreact(w, r, model.ReactionTargetComment)
+}
+
+// Sets the reaction of the user on the post or comment in the URL and responds with its new summary
+func (reaction *Reaction) react(w http.ResponseWriter, r *http.Request, targetType string) {
+	var body = util.ReactionRequestBody{}
+	var msg = ""
+
+	targetID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	if !model.IsReactionType(body.Type) {
+		msg = fmt.Sprintf("Bad request, type must be one of %s", strings.Join(model.ReactionTypes, ", "))
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if _, err := reaction.repo.SetReaction(r.Context(), userID, targetType, targetID, body.Type); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = fmt.Sprintf("A %s with that id doesn't exist", targetType)
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to save reaction"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	reaction.respondWithSummary(w, r, targetType, targetID, userID, "Successfully saved reaction")
+}
+
+// Removes the reaction of the user from the post or comment in the URL and responds with its new summary
+func (reaction *Reaction) unreact(w http.ResponseWriter, r *http.Request, targetType string) {
+	var msg = ""
+
+	targetID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	if _, err := reaction.repo.RemoveReaction(r.Context(), userID, targetType, targetID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = fmt.Sprintf("You haven't reacted to a %s with that id", targetType)
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to remove reaction"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	reaction.respondWithSummary(w, r, targetType, targetID, userID, "Successfully removed reaction")
+}
+
+// Responds with the current reactions on a post or comment
+func (reaction *Reaction) respondWithSummary(w http.ResponseWriter, r *http.Request, targetType string, targetID int, userID string, msg string) {
+	summaries, err := reaction.repo.GetReactionSummaries(r.Context(), targetType, []int{targetID}, userID)
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get reactions", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, msg, http.StatusOK, summaries[targetID])
+}
+
+/*
+Loads the images and reactions of posts
+
+Params:
+  - r:     The request, the reaction of the signed in user is reported
+  - repo:  The repository to load them from
+  - store: The storage the images are served from
+  - posts: The posts, updated in place
+
+Returns:
+  - An error if they could not be loaded
+*/
+func attachPostDetails(r *http.Request, repo *repository.PostGreSQL, store storage.Storage, posts []model.Post) error {
+	if err := attachMedia(r.Context(), repo, store, posts); err != nil {
+		return err
+	}
+
+	postIDs := make([]int, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	summaries, err := repo.GetReactionSummaries(r.Context(), model.ReactionTargetPost, postIDs, callerID(r))
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		summary := summaries[posts[i].ID]
+		posts[i].Reactions = &summary
+	}
+
+	return nil
+}
+
+/*
+Loads the reactions of comments
+
+Params:
+  - r:        The request, the reaction of the signed in user is reported
+  - repo:     The repository to load them from
+  - comments: The comments, updated in place
+  - comment:  Returns the comment inside an element of comments
+
+Returns:
+  - An error if they could not be loaded
+*/
+func attachCommentReactions[T any](r *http.Request, repo *repository.PostGreSQL, comments []T, comment func(*T) *model.Comment) error {
+	commentIDs := make([]int, 0, len(comments))
+	for i := range comments {
+		commentIDs = append(commentIDs, comment(&comments[i]).ID)
+	}
+
+	summaries, err := repo.GetReactionSummaries(r.Context(), model.ReactionTargetComment, commentIDs, callerID(r))
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		target := comment(&comments[i])
+		summary := summaries[target.ID]
+		target.Reactions = &summary
+	}
+
+	return nil
+}
+
+// Returns the ID of the signed in user, empty for anonymous requests
+func callerID(r *http.Request) string {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		return ""
+	}
+	return userID
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ecofriends/authentication-backend/model"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+// Number of results per type returned by default and at most, and the deepest page that can be requested
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxSearchPage      = 20
+)
+
+// Longest accepted search query, in characters
+const maxSearchQueryLength = 200
+
+type Search struct {
+	repo    *repository.PostGreSQL
+	storage storage.Storage
+}
+
+func (search *Search) New(repo *repository.PostGreSQL) {
+	search.repo = repo
+}
+
+// Sets the storage post images are kept in
+func (search *Search) WithStorage(store storage.Storage) {
+	search.storage = store
+}
+
+// Search looks through posts, comments and users
+// @Summary Search
+// @Description Full-text searches posts and comments, ranked by relevance and recency, and searches users by username. The query supports "quoted phrases", -excluded words and or. Headlines are HTML escaped with the matches wrapped in <mark> tags.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param type query string false "What to search: all (default), posts, comments or users"
+// @Param limit query int false "Number of results per type, 20 by default and at most 50"
+// @Param page query int false "Page of results, starting at 1"
+// @Success 200 {object} util.Response{payload=util.SearchPayload}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /search [get]
+func (search *Search) Search(w http.ResponseWriter, r *http.Request) {
+	var msg = ""
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQueryLength {
+		msg = fmt.Sprintf("Bad request, the search query must be between 1 and %d characters", maxSearchQueryLength)
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	searchType := r.URL.Query().Get("type")
+	switch searchType {
+	case "":
+		searchType = model.SearchTypeAll
+	case model.SearchTypeAll, model.SearchTypePosts, model.SearchTypeComments, model.SearchTypeUsers:
+	default:
+		msg = "Bad request, type must be all, posts, comments or users"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	limit, ok := readQueryInt(w, r, "limit", defaultSearchLimit, maxSearchLimit)
+	if !ok {
+		return
+	}
+
+	page, ok := readQueryInt(w, r, "page", 1, maxSearchPage)
+	if !ok {
+		return
+	}
+	offset := (page - 1) * limit
+
+	payload := util.SearchPayload{
+		Posts:    []util.PostSearchPayload{},
+		Comments: []util.CommentSearchPayload{},
+		Users:    []model.UserSearchResult{},
+	}
+
+	if searchType == model.SearchTypeAll || searchType == model.SearchTypePosts {
+		results, err := search.repo.SearchPosts(r.Context(), query, limit, offset)
+		if err == nil {
+			err = search.attachSearchDetails(r, results)
+		}
+		if err != nil {
+			util.JsonResponse(w, "Internal server error, failed to search posts", http.StatusInternalServerError, nil)
+			return
+		}
+
+		for _, result := range results {
+			payload.Posts = append(payload.Posts, util.NewPostSearchPayload(result))
+		}
+	}
+
+	if searchType == model.SearchTypeAll || searchType == model.SearchTypeComments {
+		results, err := search.repo.SearchComments(r.Context(), query, limit, offset)
+		if err == nil {
+			err = attachCommentReactions(r, search.repo, results, func(result *model.CommentSearchResult) *model.Comment {
+				return &result.Comment
+			})
+		}
+		if err != nil {
+			util.JsonResponse(w, "Internal server error, failed to search comments", http.StatusInternalServerError, nil)
+			return
+		}
+
+		for _, result := range results {
+			payload.Comments = append(payload.Comments, util.NewCommentSearchPayload(result))
+		}
+	}
+
+	if searchType == model.SearchTypeAll || searchType == model.SearchTypeUsers {
+		// Users are often looked up by their handle
+		results, err := search.repo.SearchUsers(r.Context(), strings.TrimPrefix(query, "@"), limit, offset)
+		if err != nil {
+			util.JsonResponse(w, "Internal server error, failed to search users", http.StatusInternalServerError, nil)
+			return
+		}
+
+		payload.Users = results
+	}
+
+	util.JsonResponse(w, "Successfully searched", http.StatusOK, payload)
+}
+
+// Loads the images and reactions of the posts found by a search
+func (search *Search) attachSearchDetails(r *http.Request, results []model.PostSearchResult) error {
+	posts := make([]model.Post, 0, len(results))
+	for _, result := range results {
+		posts = append(posts, result.Post)
+	}
+
+	if err := attachPostDetails(r, search.repo, search.storage, posts); err != nil {
+		return err
+	}
+
+	for i := range results {
+		results[i].Post = posts[i]
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/hashtag"
+	"github.com/ecofriends/authentication-backend/pagination"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// Number of tags returned by the trending and autocomplete endpoints by default, and at most
+const (
+	defaultTagLimit = 10
+	maxTagLimit     = 50
+)
+
+// Window trending tags are counted over by default, and at most, in hours
+const (
+	defaultTrendingHours = 24
+	maxTrendingHours     = 30 * 24
+)
+
+type Tag struct {
+	repo    *repository.PostGreSQL
+	storage storage.Storage
+}
+
+func (tag *Tag) New(repo *repository.PostGreSQL) {
+	tag.repo = repo
+}
+
+// Sets the storage post images are kept in
+func (tag *Tag) WithStorage(store storage.Storage) {
+	tag.storage = store
+}
+
+// GetPostsByTag lists the posts with a hashtag
+// @Summary Get posts by tag
+// @Description Returns the posts containing the hashtag, newest first, one page at a time
+// @Tags tags
+// @Produce json
+// @Param name path string true "Tag, with or without its #"
+// @Param limit query int false "Number of posts, 20 by default and at most 100"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} util.Response{payload=pagination.Response[util.PostPayload]}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /tags/{name}/posts [get]
+func (tag *Tag) GetPostsByTag(w http.ResponseWriter, r *http.Request) {
+	name, ok := hashtag.Normalize(chi.URLParam(r, "name"))
+	if !ok {
+		util.JsonResponse(w, "Bad request, invalid tag", http.StatusBadRequest, nil)
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := tag.repo.GetPostsByTag(r.Context(), name, page)
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := attachPostDetails(r, tag.repo, tag.storage, posts); err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get post details", http.StatusInternalServerError, nil)
+		return
+	}
+
+	response := pagination.NewResponse(posts, page, postCursor, util.NewPostPayload)
+	util.JsonResponse(w, fmt.Sprintf("Successfully got posts tagged #%s", name), http.StatusOK, response)
+}
+
+// GetTrendingTags lists the most used hashtags
+// @Summary Get trending tags
+// @Description Returns the hashtags used by the most posts written within the last hours
+// @Tags tags
+// @Produce json
+// @Param hours query int false "Length of the window in hours, 24 by default and at most 720"
+// @Param limit query int false "Number of tags, 10 by default and at most 50"
+// @Success 200 {object} util.Response{payload=[]model.Tag}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /tags/trending [get]
+func (tag *Tag) GetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	hours, ok := readQueryInt(w, r, "hours", defaultTrendingHours, maxTrendingHours)
+	if !ok {
+		return
+	}
+
+	limit, ok := readQueryInt(w, r, "limit", defaultTagLimit, maxTagLimit)
+	if !ok {
+		return
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+
+	tags, err := tag.repo.GetTrendingTags(r.Context(), since, limit)
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to get trending tags", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully got trending tags", http.StatusOK, tags)
+}
+
+// SearchTags autocompletes a hashtag
+// @Summary Autocomplete tags
+// @Description Returns the hashtags starting with the query, most used first
+// @Tags tags
+// @Produce json
+// @Param q query string true "Beginning of the tag, with or without its #"
+// @Param limit query int false "Number of tags, 10 by default and at most 50"
+// @Success 200 {object} util.Response{payload=[]model.Tag}
+// @Failure 400 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Router /tags/search [get]
+func (tag *Tag) SearchTags(w http.ResponseWriter, r *http.Request) {
+	prefix, ok := hashtag.NormalizePrefix(r.URL.Query().Get("q"))
+	if !ok {
+		util.JsonResponse(w, "Bad request, invalid tag", http.StatusBadRequest, nil)
+		return
+	}
+
+	limit, ok := readQueryInt(w, r, "limit", defaultTagLimit, maxTagLimit)
+	if !ok {
+		return
+	}
+
+	tags, err := tag.repo.SearchTags(r.Context(), prefix, limit)
+	if err != nil {
+		util.JsonResponse(w, "Internal server error, failed to search tags", http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully searched tags", http.StatusOK, tags)
+}
+
+/*
+Reads a positive integer query parameter
+
+Params:
+  - w:        The response writer, written to when the parameter is invalid
+  - r:        The request
+  - name:     The name of the parameter
+  - fallback: The value used when the parameter is missing
+  - max:      The largest accepted value
+
+Returns:
+  - The value
+  - False if a response was already written
+*/
+func readQueryInt(w http.ResponseWriter, r *http.Request, name string, fallback int, max int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 || number > max {
+		msg := fmt.Sprintf("Bad request, %s must be a number between 1 and %d", name, max)
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return 0, false
+	}
+
+	return number, true
+}
@@ -1,23 +1,35 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 
+	shared "github.com/ecofriends/authentication-backend/handler/auth/shared"
+	"github.com/ecofriends/authentication-backend/mailer"
 	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/service"
 	"github.com/ecofriends/authentication-backend/util"
+	validators "github.com/ecofriends/authentication-backend/validator"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type User struct {
-	repo *repository.PostGreSQL
+	repo   *repository.PostGreSQL
+	mailer mailer.Mailer
 }
 
 func (user *User) New(repo *repository.PostGreSQL) {
 	user.repo = repo
 }
 
+func (user *User) WithMailer(mailer mailer.Mailer) {
+	user.mailer = mailer
+}
+
 // Home is the base route for the user handler
 // @Summary User route home
 // @Description Basic health check or welcome route for user-related endpoints
@@ -30,47 +42,338 @@ func (user *User) Home(w http.ResponseWriter, r *http.Request) {
 	util.JsonResponse(w, msg, http.StatusOK, nil)
 }
 
-// GetUserByID retrieves a user by their ID
+// GetUserByID retrieves the public profile of a user by their ID
 // @Summary Get user by ID
-// @Description Returns the user data if the requested ID matches the authenticated user
+// @Description Returns the public profile of a user with their post count, comment count, likes received and badges. Private fields such as the email are never included.
 // @Tags user
 // @Produce json
 // @Param id path string true "User ID"
-// @Success 200 {object} util.Response
+// @Success 200 {object} util.Response{payload=util.PublicUserPayload}
 // @Failure 400 {object} util.Response
-// @Failure 401 {object} util.Response
-// @Failure 403 {object} util.Response
 // @Failure 500 {object} util.Response
-// @Security CookieAuth
 // @Router /user/{id} [get]
 func (user *User) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	requestedID := chi.URLParam(r, "id")
 	var msg = ""
 
+	if _, err := uuid.Parse(requestedID); err != nil {
+		msg = "A user with that id doesn't exist"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	// Accounts awaiting deletion have no profile
+	profile, err := user.repo.GetProfile(r.Context(), requestedID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			msg = "A user with that id doesn't exist"
+			util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+			return
+		}
+		msg = "Internal server error, failed to get user with that id"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	stats, err := user.repo.GetUserStats(r.Context(), requestedID)
+	if err != nil {
+		msg = "Internal server error, failed to get user with that id"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	badges, err := user.repo.GetBadgesByUser(r.Context(), requestedID)
+	if err != nil {
+		msg = "Internal server error, failed to get user with that id"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	msg = fmt.Sprintf("Successfully fetched user with the id: %s", requestedID)
+	util.JsonResponse(w, msg, http.StatusOK, util.NewPublicUserPayload(profile, stats, badges))
+}
+
+// GetMe retrieves the authenticated user
+// @Summary Get the authenticated user
+// @Description Returns the private view of the authenticated user
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.Response{payload=util.UserPayload}
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me [get]
+func (user *User) GetMe(w http.ResponseWriter, r *http.Request) {
 	userID, err := util.ExtractUserIDFromClaims(r.Context())
 	if err != nil {
 		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
 		return
 	}
 
-	if userID != requestedID {
-		msg = "Forbidden: Access to this resource is denied"
-		util.JsonResponse(w, msg, http.StatusForbidden, nil)
+	theUser, err := user.repo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		msg := "Internal server error, failed to get user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
 		return
 	}
 
-	theUser, err := user.repo.GetUserByID(r.Context(), requestedID)
+	util.JsonResponse(w, "Successfully fetched user", http.StatusOK, util.NewUserPayload(theUser))
+}
+
+// GetIdentities lists the OAuth providers linked to the authenticated user
+// @Summary List linked OAuth providers
+// @Description Returns the OAuth provider accounts linked to the authenticated user
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me/identities [get]
+func (user *User) GetIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			msg = "A user with that id doesn't exist"
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	identities, err := user.repo.GetIdentitiesByUser(r.Context(), userID)
+	if err != nil {
+		msg := "Internal server error, failed to get linked accounts"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	util.JsonResponse(w, "Successfully fetched linked accounts", http.StatusOK, identities)
+}
+
+// UnlinkIdentity unlinks an OAuth provider from the authenticated user
+// @Summary Unlink an OAuth provider
+// @Description Unlinks the provider account; the only sign-in method of a user without a password cannot be unlinked
+// @Tags user
+// @Produce json
+// @Param provider path string true "OAuth provider"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 404 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me/identities/{provider} [delete]
+func (user *User) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	provider := strings.ToLower(chi.URLParam(r, "provider"))
+	var msg = ""
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	err = user.repo.DeleteIdentity(r.Context(), userID, provider)
+	if err != nil {
+		if strings.Contains(err.Error(), "only sign-in method") {
+			msg = "Cannot unlink the only sign-in method, set a password first"
 			util.JsonResponse(w, msg, http.StatusBadRequest, nil)
 			return
 		}
-		msg = "Internal server error, failed to get user with that id"
+		if strings.Contains(err.Error(), "not found") {
+			msg = "No account from that provider is linked"
+			util.JsonResponse(w, msg, http.StatusNotFound, nil)
+			return
+		}
+		msg = "Internal server error, failed to unlink account"
 		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
 		return
 	}
 
-	msg = fmt.Sprintf("Successfully fetched user with the id: %s", requestedID)
-	util.JsonResponse(w, msg, http.StatusOK, theUser)
+	msg = fmt.Sprintf("Successfully unlinked %s account", util.CapitalizeFirstLetter(provider))
+	util.JsonResponse(w, msg, http.StatusOK, nil)
+}
+
+// UpdateMe updates the authenticated user's profile
+// @Summary Update the authenticated user
+// @Description Changes the username and/or email; a new email must be verified again
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body util.UpdateUserRequestBody true "Fields to change"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 409 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me [patch]
+func (user *User) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	var body = util.UpdateUserRequestBody{}
+	var msg = ""
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		msg = "Bad request, username or email not present"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	util.SanitizeUserInput(&body)
+
+	if err := validators.ValidateUserUpdate(&body); err != nil {
+		msg = util.CapitalizeFirstLetter(err.Error())
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	theUser, err := user.repo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		msg = "Internal server error, failed to get user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	username, email := theUser.Username, theUser.Email
+	if body.Username != nil {
+		username = *body.Username
+	}
+	if body.Email != nil {
+		email = *body.Email
+	}
+
+	// Only check the fields that actually change, the user owns the current ones
+	checkUsername, checkEmail := "", ""
+	if username != theUser.Username {
+		checkUsername = username
+	}
+	if email != theUser.Email {
+		checkEmail = email
+	}
+
+	if checkUsername != "" || checkEmail != "" {
+		userExists, err := user.repo.UserExists(r.Context(), checkEmail, checkUsername)
+		if err != nil {
+			msg = "Could not check if user already exists"
+			util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+			return
+		}
+
+		if userExists {
+			msg = "A user with those credentials already exists"
+			util.JsonResponse(w, msg, http.StatusConflict, nil)
+			return
+		}
+	}
+
+	err = user.repo.UpdateUser(r.Context(), userID, username, email)
+	if err != nil {
+		if strings.Contains(err.Error(), "already taken") {
+			msg = "A user with those credentials already exists"
+			util.JsonResponse(w, msg, http.StatusConflict, nil)
+			return
+		}
+		msg = "Internal server error, failed to update user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	emailChanged := checkEmail != ""
+	theUser.Username = username
+	theUser.Email = email
+	theUser.EmailVerified = theUser.EmailVerified && !emailChanged
+
+	// A new email address has to be verified again
+	if emailChanged {
+		dbService := &service.DatabaseProvider{Repo: user.repo}
+		if err := shared.SendVerificationEmail(r.Context(), dbService, user.mailer, theUser); err != nil {
+			log.Println("[FAIL]: could not send verification email:", err)
+		}
+	}
+
+	var userPayload = util.NewUserPayload(theUser)
+
+	util.JsonResponse(w, "Successfully updated user", http.StatusOK, userPayload)
+}
+
+// ChangePassword changes the authenticated user's password
+// @Summary Change the password
+// @Description Verifies the current password, sets the new one and signs out every other device. Users without a password set one through the password reset link instead.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body util.ChangePasswordRequestBody true "Current and new password"
+// @Success 200 {object} util.Response
+// @Failure 400 {object} util.Response
+// @Failure 401 {object} util.Response
+// @Failure 403 {object} util.Response
+// @Failure 500 {object} util.Response
+// @Security CookieAuth
+// @Router /user/me/password [post]
+func (user *User) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var body = util.ChangePasswordRequestBody{}
+	var msg = ""
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		msg = "Bad request, current or new password not present"
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	util.SanitizeUserInput(&body)
+
+	if err := validators.ValidatePassword(body.NewPassword); err != nil {
+		msg = util.CapitalizeFirstLetter(err.Error())
+		util.JsonResponse(w, msg, http.StatusBadRequest, nil)
+		return
+	}
+
+	userID, err := util.ExtractUserIDFromClaims(r.Context())
+	if err != nil {
+		util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+		return
+	}
+
+	theUser, err := user.repo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		msg = "Internal server error, failed to get user"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Users that signed up through a provider have no current password to prove
+	// who they are, so they set one through the emailed reset link instead
+	if theUser.Password == "" {
+		msg = "This account has no password, set one through the password reset link"
+		util.JsonResponse(w, msg, http.StatusForbidden, nil)
+		return
+	}
+
+	if !util.CompareWithHash([]byte(theUser.Password), body.CurrentPassword) {
+		msg = "Provided passwords mismatch"
+		util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := user.repo.UpdatePassword(r.Context(), userID, body.NewPassword); err != nil {
+		msg = "Internal server error, failed to update password"
+		util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Sign out every device, then start a fresh session for this one
+	dbService := &service.DatabaseProvider{Repo: user.repo}
+	if err := shared.EndAllSessions(r.Context(), dbService, userID); err != nil {
+		log.Println(err)
+	}
+
+	if err := shared.StartSession(r.Context(), dbService, w, theUser.ID); err != nil {
+		log.Println(err)
+		util.ExpireCookie(w, "token")
+		util.ExpireCookie(w, "refresh_token")
+	}
+
+	util.JsonResponse(w, "Successfully changed password", http.StatusOK, nil)
 }
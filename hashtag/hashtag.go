@@ -0,0 +1,124 @@
+package hashtag
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on the hashtags taken from a single post
+const (
+	MaxLength  = 50
+	MaxPerPost = 10
+)
+
+/*
+Extracts the hashtags of a text
+
+Objectives:
+  - A hashtag is a '#' at the start of the text or after a character that
+    cannot be part of a tag, followed by letters, digits or underscores
+  - Tags are normalized, see Normalize, and invalid ones are skipped
+  - Every tag is returned once, in order of first appearance, and at most
+    MaxPerPost tags are returned
+
+Params:
+  - text: The text of a post
+
+Returns:
+  - The normalized tags
+*/
+func Parse(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	previous := ' '
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		if r != '#' || isTagRune(previous) {
+			previous = r
+			i += size
+			continue
+		}
+
+		end := i + size
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !isTagRune(next) {
+				break
+			}
+			end += nextSize
+		}
+
+		if tag, ok := Normalize(text[i+size : end]); ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+			if len(tags) == MaxPerPost {
+				break
+			}
+		}
+
+		previous = '#'
+		i = end
+	}
+
+	return tags
+}
+
+/*
+Normalizes a tag so differently written forms of it match
+
+Objectives:
+  - Drop a leading '#' and lower-case the tag
+  - The tag must only contain letters, digits or underscores, be at most
+    MaxLength characters long and contain at least one letter, so numbers
+    such as "#1" are not tags
+
+Params:
+  - tag: The tag, with or without its '#'
+
+Returns:
+  - The normalized tag
+  - Whether the tag is valid
+*/
+func Normalize(tag string) (string, bool) {
+	tag, ok := NormalizePrefix(tag)
+	if !ok || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+		return "", false
+	}
+
+	return tag, true
+}
+
+/*
+Normalizes the beginning of a tag, like Normalize but without requiring a
+letter since one may still follow
+
+Params:
+  - prefix: The beginning of the tag, with or without its '#'
+
+Returns:
+  - The normalized prefix
+  - Whether the prefix can begin a valid tag
+*/
+func NormalizePrefix(prefix string) (string, bool) {
+	prefix = strings.ToLower(strings.TrimPrefix(prefix, "#"))
+
+	if prefix == "" || utf8.RuneCountInString(prefix) > MaxLength {
+		return "", false
+	}
+
+	for _, r := range prefix {
+		if !isTagRune(r) {
+			return "", false
+		}
+	}
+
+	return prefix, true
+}
+
+// Reports whether the rune can be part of a tag
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,95 @@
+package hashtag
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"no tags here", nil},
+		{"#Recycle today", []string{"recycle"}},
+		{"Planted a tree #ZeroWaste, #GoGreen!", []string{"zerowaste", "gogreen"}},
+		{"#reuse #Reuse #REUSE", []string{"reuse"}},
+		{"email me at me#home or foo#bar", nil},
+		{"we are #1 and #2nd", []string{"2nd"}},
+		{"#eco_friends (#bike)", []string{"eco_friends", "bike"}},
+		{"#Ünïcode #日本", []string{"ünïcode", "日本"}},
+		{"#" + strings.Repeat("a", MaxLength+1) + " #ok", []string{"ok"}},
+		{"# lonely hash", nil},
+	}
+
+	for _, test := range tests {
+		if got := Parse(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestParseKeepsAtMostMaxPerPost(t *testing.T) {
+	var text strings.Builder
+	for i := 0; i < MaxPerPost+5; i++ {
+		fmt.Fprintf(&text, "#tag%d ", i)
+	}
+
+	tags := Parse(text.String())
+	if len(tags) != MaxPerPost {
+		t.Fatalf("got %d tags, want %d", len(tags), MaxPerPost)
+	}
+	if tags[0] != "tag0" || tags[MaxPerPost-1] != fmt.Sprintf("tag%d", MaxPerPost-1) {
+		t.Errorf("tags = %q, want the first %d in order", tags, MaxPerPost)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		ok   bool
+	}{
+		{"#GoGreen", "gogreen", true},
+		{"gogreen", "gogreen", true},
+		{"eco_2024", "eco_2024", true},
+		{"#2024", "", false},
+		{"#", "", false},
+		{"", "", false},
+		{"go-green", "", false},
+		{"go green", "", false},
+		{strings.Repeat("a", MaxLength), strings.Repeat("a", MaxLength), true},
+		{strings.Repeat("a", MaxLength+1), "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := Normalize(test.tag)
+		if got != test.want || ok != test.ok {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", test.tag, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestNormalizePrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+		ok     bool
+	}{
+		{"#Eco", "eco", true},
+		{"20", "20", true},
+		{"#", "", false},
+		{"eco-", "", false},
+		{strings.Repeat("a", MaxLength+1), "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := NormalizePrefix(test.prefix)
+		if got != test.want || ok != test.ok {
+			t.Errorf("NormalizePrefix(%q) = %q, %v, want %q, %v", test.prefix, got, ok, test.want, test.ok)
+		}
+	}
+}
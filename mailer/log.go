@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+Mailer that writes messages to files or the log instead of sending them,
+for local development
+
+Fields:
+  - Directory: string - Directory to write one file per message to, empty to log their recipient and subject
+*/
+type LogMailer struct {
+	Directory string
+}
+
+func (mailer *LogMailer) Send(ctx context.Context, message Message) error {
+	// Bodies carry single-use links, so only the envelope goes to the log
+	if mailer.Directory == "" {
+		log.Printf("[MAIL]: %q to %s", message.Subject, message.To)
+		return nil
+	}
+
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", message.To, message.Subject, message.Body)
+
+	if err := os.MkdirAll(mailer.Directory, 0o755); err != nil {
+		return fmt.Errorf("[FAIL]: could not create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	if err := os.WriteFile(filepath.Join(mailer.Directory, name), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("[FAIL]: could not write email: %w", err)
+	}
+
+	return nil
+}
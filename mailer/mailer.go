@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+/*
+Email message
+
+Fields:
+  - To:      string - Recipient address
+  - Subject: string - Subject line
+  - Body:    string - Plain text body
+*/
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+/*
+Mailer Interface
+
+Defines anything that can deliver an email message
+*/
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+/*
+Creates the mailer configured in the environment
+
+Objectives:
+  - Use SMTP when MAIL_DRIVER is "smtp"
+  - Refuse to run in production without SMTP, since no email would be delivered
+  - Otherwise write messages to MAIL_LOG_DIR, or the log when it is empty
+
+Params:
+  - No parameters
+
+Returns:
+  - The configured mailer
+  - An error if production is not configured to send email
+*/
+func FromEnvironment() (Mailer, error) {
+	// Load environment variables from .env file in development
+	if env := os.Getenv("ENVIRONMENT"); env != "production" {
+		err := godotenv.Load()
+		if err != nil {
+			log.Println("[FAIL]: could not load environment variables:", err)
+		}
+	}
+
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}, nil
+	}
+
+	if os.Getenv("ENVIRONMENT") == "production" {
+		return nil, fmt.Errorf("MAIL_DRIVER must be \"smtp\" in production")
+	}
+
+	return &LogMailer{Directory: os.Getenv("MAIL_LOG_DIR")}, nil
+}
@@ -0,0 +1,195 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogMailerWritesMessageFile(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "mail")
+	mailer := &LogMailer{Directory: directory}
+
+	message := Message{To: "jane@example.com", Subject: "Verify your email", Body: "Hello Jane"}
+	if err := mailer.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+
+	content, err := os.ReadFile(filepath.Join(directory, files[0].Name()))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	want := "To: jane@example.com\nSubject: Verify your email\n\nHello Jane\n"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestLogMailerWithoutDirectoryLeavesOutTheBody(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	mailer := &LogMailer{}
+
+	message := Message{To: "jane@example.com", Subject: "Reset your password", Body: "https://example.com/reset?token=secret"}
+	if err := mailer.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if !strings.Contains(output.String(), "jane@example.com") {
+		t.Errorf("log = %q, want the recipient", output.String())
+	}
+	if strings.Contains(output.String(), "secret") {
+		t.Errorf("log = %q, leaks the body", output.String())
+	}
+}
+
+func TestFromEnvironmentRequiresSMTPInProduction(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("MAIL_DRIVER", "")
+
+	if _, err := FromEnvironment(); err == nil {
+		t.Error("FromEnvironment returned a mailer that does not deliver email in production")
+	}
+
+	t.Setenv("MAIL_DRIVER", "smtp")
+
+	mailer, err := FromEnvironment()
+	if err != nil {
+		t.Fatalf("FromEnvironment: %v", err)
+	}
+	if _, ok := mailer.(*SMTPMailer); !ok {
+		t.Errorf("mailer = %T, want *SMTPMailer", mailer)
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	mailer := &SMTPMailer{Host: "127.0.0.1", Port: "1", From: "noreply@example.com"}
+
+	message := Message{To: "jane@example.com", Subject: "Hi\r\nBcc: everyone@example.com"}
+	if err := mailer.Send(context.Background(), message); err == nil {
+		t.Error("Send accepted a subject with a line break")
+	}
+}
+
+// Minimal SMTP server that accepts one message and hands over its data
+func serveSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ready")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					reply("250 queued")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case command == "DATA":
+				inData = true
+				reply("354 send data")
+			case command == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	address, received := serveSMTP(t)
+	host, port, _ := net.SplitHostPort(address)
+	mailer := &SMTPMailer{Host: host, Port: port, From: "noreply@example.com"}
+
+	message := Message{To: "jane@example.com", Subject: "Reset your password", Body: "line one\nline two"}
+	if err := mailer.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		for _, want := range []string{
+			"From: noreply@example.com\r\n",
+			"To: jane@example.com\r\n",
+			"Subject: Reset your password\r\n",
+			"\r\n\r\nline one\r\nline two",
+		} {
+			if !strings.Contains(data, want) {
+				t.Errorf("message %q does not contain %q", data, want)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestSMTPMailerHonoursCancellation(t *testing.T) {
+	// A listener that never answers keeps the client waiting for the greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	mailer := &SMTPMailer{Host: host, Port: port, From: "noreply@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := mailer.Send(ctx, Message{To: "jane@example.com"}); err != context.DeadlineExceeded {
+		t.Errorf("Send = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
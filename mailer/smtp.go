@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+/*
+Mailer that delivers messages through an SMTP server
+
+Fields:
+  - Host:     string - SMTP server host
+  - Port:     string - SMTP server port
+  - Username: string - SMTP username, empty to skip authentication
+  - Password: string - SMTP password
+  - From:     string - Sender address
+*/
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (mailer *SMTPMailer) Send(ctx context.Context, message Message) error {
+	var auth smtp.Auth
+	if mailer.Username != "" {
+		auth = smtp.PlainAuth("", mailer.Username, mailer.Password, mailer.Host)
+	}
+
+	// Refuse header injection through the recipient or subject
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return fmt.Errorf("[FAIL]: invalid recipient or subject")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", mailer.From)
+	fmt.Fprintf(&body, "To: %s\r\n", message.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	address := net.JoinHostPort(mailer.Host, mailer.Port)
+
+	// smtp.SendMail does not take a context, so honour cancellation around it
+	errorChan := make(chan error, 1)
+	go func() {
+		errorChan <- smtp.SendMail(address, auth, mailer.From, []string{message.To}, []byte(body.String()))
+	}()
+
+	select {
+	case err := <-errorChan:
+		if err != nil {
+			return fmt.Errorf("[FAIL]: could not send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,151 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+
+	// Register the decoders of the accepted formats
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Limits on uploaded images
+const (
+	MaxUploadBytes = 10 << 20
+	MinDimension   = 16
+	MaxDimension   = 8000
+	MaxPixels      = 40_000_000
+)
+
+// Longest side of a generated thumbnail, in pixels
+const ThumbnailSize = 400
+
+// File extensions of the accepted MIME types
+var allowedTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// Errors returned while processing an upload
+var (
+	ErrUnsupportedType = errors.New("only jpeg, png, gif and webp images are supported")
+	ErrTooLarge        = fmt.Errorf("images must be at most %d MB", MaxUploadBytes>>20)
+	ErrDimensions      = fmt.Errorf("images must be between %d and %d pixels wide and high", MinDimension, MaxDimension)
+)
+
+/*
+Image struct, an uploaded image that passed validation
+
+Fields:
+  - Data:      []byte - The original file
+  - MimeType:  string - The MIME type sniffed from the content
+  - Extension: string - The file extension of the MIME type
+  - Width:     int    - Width in pixels
+  - Height:    int    - Height in pixels
+  - Thumbnail: []byte - A JPEG at most ThumbnailSize pixels on its longest side
+*/
+type Image struct {
+	Data      []byte
+	MimeType  string
+	Extension string
+	Width     int
+	Height    int
+	Thumbnail []byte
+}
+
+/*
+Validates an uploaded image and generates its thumbnail
+
+Objectives:
+  - Reject files over MaxUploadBytes
+  - Sniff the MIME type from the content, never trusting the client
+  - Check the dimensions from the header before decoding the whole image
+  - Decode the image and scale it down into a JPEG thumbnail
+
+Params:
+  - data: The uploaded file
+
+Returns:
+  - The processed image
+  - An error if the file is not an acceptable image
+*/
+func Process(data []byte) (Image, error) {
+	if len(data) > MaxUploadBytes {
+		return Image{}, ErrTooLarge
+	}
+
+	mimeType := http.DetectContentType(data)
+	extension, ok := allowedTypes[mimeType]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+
+	// Reading the header first keeps decompression bombs from being decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrUnsupportedType
+	}
+
+	if config.Width < MinDimension || config.Height < MinDimension ||
+		config.Width > MaxDimension || config.Height > MaxDimension ||
+		config.Width*config.Height > MaxPixels {
+		return Image{}, ErrDimensions
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrUnsupportedType
+	}
+
+	thumbnail, err := createThumbnail(decoded)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		Data:      data,
+		MimeType:  mimeType,
+		Extension: extension,
+		Width:     config.Width,
+		Height:    config.Height,
+		Thumbnail: thumbnail,
+	}, nil
+}
+
+// Scales an image down to fit ThumbnailSize and encodes it as a JPEG
+func createThumbnail(source image.Image) ([]byte, error) {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > ThumbnailSize || height > ThumbnailSize {
+		if width >= height {
+			height = max(1, height*ThumbnailSize/width)
+			width = ThumbnailSize
+		} else {
+			width = max(1, width*ThumbnailSize/height)
+			height = ThumbnailSize
+		}
+	}
+
+	// JPEG has no transparency, so transparent areas become white
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(thumbnail, thumbnail.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), source, bounds, draw.Over, nil)
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, thumbnail, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("could not encode thumbnail: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
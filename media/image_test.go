@@ -0,0 +1,143 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	source := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range source.Pix {
+		source.Pix[i] = 0x80
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, source); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// Rewrites the dimensions in a PNG header without touching the pixel data
+func withPNGDimensions(data []byte, width uint32, height uint32) []byte {
+	patched := append([]byte(nil), data...)
+
+	// Signature (8), chunk length (4) and type (4) precede the IHDR fields
+	binary.BigEndian.PutUint32(patched[16:], width)
+	binary.BigEndian.PutUint32(patched[20:], height)
+	binary.BigEndian.PutUint32(patched[29:], crc32.ChecksumIEEE(patched[12:29]))
+
+	return patched
+}
+
+func decodeThumbnail(t *testing.T, data []byte) image.Image {
+	t.Helper()
+
+	thumbnail, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	return thumbnail
+}
+
+func TestProcessScalesDownThumbnail(t *testing.T) {
+	data := encodePNG(t, 800, 400)
+
+	processed, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if processed.MimeType != "image/png" || processed.Extension != "png" {
+		t.Errorf("type = %s (%s), want image/png (png)", processed.MimeType, processed.Extension)
+	}
+	if processed.Width != 800 || processed.Height != 400 {
+		t.Errorf("size = %dx%d, want 800x400", processed.Width, processed.Height)
+	}
+	if !bytes.Equal(processed.Data, data) {
+		t.Error("original data was modified")
+	}
+
+	bounds := decodeThumbnail(t, processed.Thumbnail).Bounds()
+	if bounds.Dx() != ThumbnailSize || bounds.Dy() != ThumbnailSize/2 {
+		t.Errorf("thumbnail = %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), ThumbnailSize, ThumbnailSize/2)
+	}
+}
+
+func TestProcessKeepsSmallImageSize(t *testing.T) {
+	processed, err := Process(encodePNG(t, 50, 120))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	bounds := decodeThumbnail(t, processed.Thumbnail).Bounds()
+	if bounds.Dx() != 50 || bounds.Dy() != 120 {
+		t.Errorf("thumbnail = %dx%d, want 50x120", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestProcessFillsTransparencyWithWhite(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, image.NewNRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	processed, err := Process(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	r, g, b, _ := decodeThumbnail(t, processed.Thumbnail).At(16, 16).RGBA()
+	if r>>8 < 0xf0 || g>>8 < 0xf0 || b>>8 < 0xf0 {
+		t.Errorf("transparent pixel = %d,%d,%d, want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestProcessAcceptsGIF(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+
+	var buffer bytes.Buffer
+	if err := gif.Encode(&buffer, image.NewPaletted(image.Rect(0, 0, 20, 20), palette), nil); err != nil {
+		t.Fatalf("gif.Encode: %v", err)
+	}
+
+	processed, err := Process(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if processed.MimeType != "image/gif" || processed.Extension != "gif" {
+		t.Errorf("type = %s (%s), want image/gif (gif)", processed.MimeType, processed.Extension)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	small := encodePNG(t, 16, 16)
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"too large", make([]byte, MaxUploadBytes+1), ErrTooLarge},
+		{"not an image", []byte("<html><body>hello</body></html>"), ErrUnsupportedType},
+		{"truncated image", small[:40], ErrUnsupportedType},
+		{"too small", encodePNG(t, MinDimension-1, 100), ErrDimensions},
+		{"too wide", withPNGDimensions(small, MaxDimension+1, 16), ErrDimensions},
+		{"too many pixels", withPNGDimensions(small, 7000, 7000), ErrDimensions},
+	}
+
+	for _, test := range tests {
+		if _, err := Process(test.data); err != test.want {
+			t.Errorf("%s: Process = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+/*
+Only lets admins through
+
+Must run after AuthenticateMiddleware, which provides the user ID.
+
+Params:
+  - repo: The repository to look up the admin status in
+
+Returns:
+  - A middleware
+*/
+func RequireAdmin(repo *repository.PostGreSQL) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := util.ExtractUserIDFromClaims(r.Context())
+			if err != nil {
+				util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+				return
+			}
+
+			isAdmin, err := repo.IsAdmin(r.Context(), userID)
+			if err != nil {
+				log.Printf("[FAIL]: could not check admin status: %v", err)
+				msg := "Internal server error, could not check admin status"
+				util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+				return
+			}
+
+			if !isAdmin {
+				msg := "Forbidden: Access to this resource is denied"
+				util.JsonResponse(w, msg, http.StatusForbidden, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
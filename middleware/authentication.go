@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 
@@ -14,33 +15,81 @@ func AuthenticateMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Println("[LOG]: authentication requested on:", r.URL)
 
-		tokenCookie, err := r.Cookie("token")
+		claims, err := verifyRequest(r)
 		if err != nil {
-			log.Println("[FAIL]: token not present in cookie")
-			msg := "Unauthorized request to a protected endpoint"
-			util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+			util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
 			return
 		}
 
-		token, err := authentication.VerifyToken(tokenCookie.Value)
-		if err != nil {
-			log.Printf("[FAIL]: token verification failed: %v", err)
-			msg := "Failed to verify token"
-			util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		log.Printf("[SUCCESS]: token successfully verified: %v", claims)
+
+		ctx := context.WithValue(r.Context(), util.TokenClaimsKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+/*
+Authenticates the request when it carries a token, for public endpoints whose
+response depends on who is asking
+
+Requests without a valid token are let through anonymously instead of being
+rejected, handlers tell them apart by ExtractUserIDFromClaims failing.
+*/
+func OptionalAuthenticateMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("token"); err != nil {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			log.Println("[FAIL]: could not parse token claims")
-			msg := "Invalid token claims"
-			util.JsonResponse(w, msg, http.StatusUnauthorized, nil)
+		claims, err := verifyRequest(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		log.Printf("[SUCCESS]: token successfully verified: %v", claims)
-
 		ctx := context.WithValue(r.Context(), util.TokenClaimsKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+/*
+Verifies the token cookie of a request
+
+Returns:
+  - The claims of the token
+  - An error with the message sent to the client if the token is missing, invalid or revoked
+*/
+func verifyRequest(r *http.Request) (jwt.MapClaims, error) {
+	tokenCookie, err := r.Cookie("token")
+	if err != nil {
+		log.Println("[FAIL]: token not present in cookie")
+		return nil, errors.New("Unauthorized request to a protected endpoint")
+	}
+
+	token, err := authentication.VerifyToken(tokenCookie.Value)
+	if err != nil {
+		log.Printf("[FAIL]: token verification failed: %v", err)
+		return nil, errors.New("Failed to verify token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		log.Println("[FAIL]: could not parse token claims")
+		return nil, errors.New("Invalid token claims")
+	}
+
+	// Reject tokens that were signed out before they expired
+	revoked, err := authentication.IsTokenRevoked(r.Context(), claims)
+	if err != nil {
+		log.Printf("[FAIL]: could not check token revocation: %v", err)
+		return nil, errors.New("Failed to verify token")
+	}
+
+	if revoked {
+		log.Println("[FAIL]: revoked token presented")
+		return nil, errors.New("Token has been revoked")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/authentication"
+	"github.com/google/uuid"
+)
+
+// In-memory revocation store standing in for the database
+type fakeRevocationStore struct {
+	mu        sync.Mutex
+	revokedAt map[string]time.Time
+}
+
+func (store *fakeRevocationStore) RevokeToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error {
+	return nil
+}
+
+func (store *fakeRevocationStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+func (store *fakeRevocationStore) RevokeAllUserTokens(ctx context.Context, userID string) (time.Time, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	now := time.Now().Truncate(time.Microsecond)
+	store.revokedAt[userID] = now
+	return now, nil
+}
+
+func (store *fakeRevocationStore) GetTokensRevokedAt(ctx context.Context, userID string) (*time.Time, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if revokedAt, ok := store.revokedAt[userID]; ok {
+		return &revokedAt, nil
+	}
+	return nil, nil
+}
+
+func authenticatedRequest(t *testing.T, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	handler := AuthenticateMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user/me", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// A password change signs out every device and then issues a new token for the
+// current one, which has to keep working
+func TestTokenIssuedAfterPasswordChangeIsAccepted(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	authentication.UseDenylist(authentication.NewDenylist(&fakeRevocationStore{revokedAt: make(map[string]time.Time)}))
+	t.Cleanup(func() { authentication.UseDenylist(nil) })
+
+	userID := uuid.New()
+	if err := authentication.RevokeAllTokens(context.Background(), userID.String()); err != nil {
+		t.Fatalf("RevokeAllTokens: %v", err)
+	}
+
+	// Starting the new session takes a few database round trips
+	time.Sleep(time.Millisecond)
+
+	token, err := authentication.CreateJWToken(userID)
+	if err != nil {
+		t.Fatalf("CreateJWToken: %v", err)
+	}
+
+	if rec := authenticatedRequest(t, token); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+func TestTokenIssuedBeforePasswordChangeIsRejected(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	store := &fakeRevocationStore{revokedAt: make(map[string]time.Time)}
+	authentication.UseDenylist(authentication.NewDenylist(store))
+	t.Cleanup(func() { authentication.UseDenylist(nil) })
+
+	userID := uuid.New()
+	token, err := authentication.CreateJWToken(userID)
+	if err != nil {
+		t.Fatalf("CreateJWToken: %v", err)
+	}
+
+	if err := authentication.RevokeAllTokens(context.Background(), userID.String()); err != nil {
+		t.Fatalf("RevokeAllTokens: %v", err)
+	}
+
+	if rec := authenticatedRequest(t, token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+/*
+Blocks users with an unverified email address when REQUIRE_VERIFIED_EMAIL is "true"
+
+Must run after AuthenticateMiddleware, which provides the user ID.
+
+Params:
+  - repo: The repository to look up the verification status in
+
+Returns:
+  - A middleware, which lets every request through while the option is off
+*/
+func RequireVerifiedEmail(repo *repository.PostGreSQL) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Read on every request, routes are built before the environment is loaded
+			if os.Getenv("REQUIRE_VERIFIED_EMAIL") != "true" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID, err := util.ExtractUserIDFromClaims(r.Context())
+			if err != nil {
+				util.JsonResponse(w, err.Error(), http.StatusUnauthorized, nil)
+				return
+			}
+
+			verified, err := repo.IsEmailVerified(r.Context(), userID)
+			if err != nil {
+				log.Printf("[FAIL]: could not check email verification: %v", err)
+				msg := "Internal server error, could not check email verification"
+				util.JsonResponse(w, msg, http.StatusInternalServerError, nil)
+				return
+			}
+
+			if !verified {
+				msg := "Forbidden: Verify your email address first"
+				util.JsonResponse(w, msg, http.StatusForbidden, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireVerifiedEmailReadsOptionPerRequest(t *testing.T) {
+	// Built before the option is set, like the routes are
+	t.Setenv("REQUIRE_VERIFIED_EMAIL", "")
+	handler := RequireVerifiedEmail(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/posts/create", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status with the option off = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// Without claims the user cannot be checked, so the request is rejected
+	t.Setenv("REQUIRE_VERIFIED_EMAIL", "true")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/posts/create", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status with the option on = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS email_verifications CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
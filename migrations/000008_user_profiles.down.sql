@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_profiles;
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS following_count;
+ALTER TABLE users DROP COLUMN IF EXISTS follower_count;
+DROP TABLE IF EXISTS follows;
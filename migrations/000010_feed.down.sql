@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comments_post_id_created_at;
+DROP INDEX IF EXISTS idx_posts_created_at;
+DROP INDEX IF EXISTS idx_posts_user_id_created_at;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_follows_follower_id;
+DROP INDEX IF EXISTS idx_post_likes_user_id_created_at;
+DROP INDEX IF EXISTS idx_comments_user_id_created_at;
@@ -0,0 +1,4 @@
+-- Keyset pagination walks every listing by (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_comments_user_id_created_at ON comments (user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_post_likes_user_id_created_at ON post_likes (user_id, created_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_follows_follower_id ON follows (follower_id, created_at DESC);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (post_id, tag_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags (tag_id);
+CREATE INDEX IF NOT EXISTS idx_tags_name_prefix ON tags (name text_pattern_ops);
+
+-- Tag the posts written before hashtags were parsed
+INSERT INTO tags (name)
+SELECT DISTINCT LOWER(m[1])
+FROM posts p
+CROSS JOIN LATERAL regexp_matches(p.text, '(?:^|[^[:alnum:]_])#([[:alnum:]_]{1,50})(?![[:alnum:]_])', 'g') AS m
+WHERE m[1] ~ '[[:alpha:]]'
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO post_tags (post_id, tag_id)
+SELECT DISTINCT p.id, t.id
+FROM posts p
+CROSS JOIN LATERAL regexp_matches(p.text, '(?:^|[^[:alnum:]_])#([[:alnum:]_]{1,50})(?![[:alnum:]_])', 'g') AS m
+JOIN tags t ON t.name = LOWER(m[1])
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS post_likes (
+    user_id UUID NOT NULL,
+    post_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_likes_user_id_created_at ON post_likes (user_id, created_at DESC, post_id DESC);
+
+-- Only like reactions can be kept
+INSERT INTO post_likes (user_id, post_id, created_at)
+SELECT user_id, post_id, created_at
+FROM post_reactions
+WHERE type = 'like'
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS comment_reactions;
+DROP TABLE IF EXISTS post_reactions;
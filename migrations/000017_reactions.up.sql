@@ -0,0 +1,33 @@
+-- A user has at most one reaction on each post and comment
+CREATE TABLE IF NOT EXISTS post_reactions (
+    user_id UUID NOT NULL,
+    post_id INTEGER NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('like', 'inspiring', 'helpful', 'planted_a_tree')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS comment_reactions (
+    user_id UUID NOT NULL,
+    comment_id INTEGER NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('like', 'inspiring', 'helpful', 'planted_a_tree')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, comment_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_post_id ON post_reactions (post_id, type);
+CREATE INDEX IF NOT EXISTS idx_comment_reactions_comment_id ON comment_reactions (comment_id, type);
+CREATE INDEX IF NOT EXISTS idx_post_reactions_user_id_created_at ON post_reactions (user_id, created_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_comment_reactions_user_id_created_at ON comment_reactions (user_id, created_at DESC, comment_id DESC);
+
+-- Existing likes become like reactions, posts.like_count keeps counting them
+INSERT INTO post_reactions (user_id, post_id, type, created_at)
+SELECT user_id, post_id, 'like', created_at
+FROM post_likes
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS post_likes;
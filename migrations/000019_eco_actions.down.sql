@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS eco_actions;
+DROP TABLE IF EXISTS eco_action_types;
@@ -0,0 +1,40 @@
+-- Catalog of the actions users can log, the factors are savings per unit of quantity
+CREATE TABLE IF NOT EXISTS eco_action_types (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    unit VARCHAR(20) NOT NULL,
+    co2e_kg_per_unit NUMERIC(12, 4) NOT NULL DEFAULT 0 CHECK (co2e_kg_per_unit >= 0),
+    water_litres_per_unit NUMERIC(12, 4) NOT NULL DEFAULT 0 CHECK (water_litres_per_unit >= 0),
+    waste_kg_per_unit NUMERIC(12, 4) NOT NULL DEFAULT 0 CHECK (waste_kg_per_unit >= 0),
+    max_quantity NUMERIC(12, 4) NOT NULL CHECK (max_quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO eco_action_types (id, name, unit, co2e_kg_per_unit, water_litres_per_unit, waste_kg_per_unit, max_quantity)
+VALUES
+    ('cycled_instead_of_drove', 'Cycled instead of drove', 'km', 0.17, 0, 0, 300),
+    ('composted', 'Composted', 'kg', 0.5, 0, 1, 100),
+    ('refused_single_use_plastic', 'Refused single-use plastic', 'item', 0.08, 3, 0.02, 100),
+    ('planted_a_tree', 'Planted a tree', 'tree', 22, 0, 0, 100)
+ON CONFLICT (id) DO NOTHING;
+
+-- Savings are computed when an action is logged, changing a factor doesn't rewrite history
+CREATE TABLE IF NOT EXISTS eco_actions (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    action_type VARCHAR(50) NOT NULL,
+    quantity NUMERIC(12, 4) NOT NULL CHECK (quantity > 0),
+    co2e_kg NUMERIC(14, 4) NOT NULL,
+    water_litres NUMERIC(14, 4) NOT NULL,
+    waste_kg NUMERIC(14, 4) NOT NULL,
+    post_id INTEGER,
+    performed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (action_type) REFERENCES eco_action_types(id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_eco_actions_user_id_performed_at ON eco_actions (user_id, performed_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_eco_actions_post_id ON eco_actions (post_id) WHERE post_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS challenge_participants;
+DROP TABLE IF EXISTS challenges;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,38 @@
+-- Admins are appointed directly in the database
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Goals are counted in units of an eco-action type, or in posts with a hashtag
+CREATE TABLE IF NOT EXISTS challenges (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    goal_type VARCHAR(20) NOT NULL,
+    action_type VARCHAR(50),
+    tag VARCHAR(50),
+    goal_quantity NUMERIC(12, 4) NOT NULL CHECK (goal_quantity > 0),
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (action_type) REFERENCES eco_action_types(id),
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (ends_at > starts_at),
+    CHECK (
+        (goal_type = 'eco_action' AND action_type IS NOT NULL AND tag IS NULL) OR
+        (goal_type = 'tagged_posts' AND tag IS NOT NULL AND action_type IS NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenges_starts_at ON challenges (starts_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS challenge_participants (
+    challenge_id INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (challenge_id, user_id),
+    FOREIGN KEY (challenge_id) REFERENCES challenges(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenge_participants_joined_at ON challenge_participants (challenge_id, joined_at DESC, user_id DESC);
+CREATE INDEX IF NOT EXISTS idx_challenge_participants_user_id ON challenge_participants (user_id);
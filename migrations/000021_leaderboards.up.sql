@@ -0,0 +1,53 @@
+-- Scores of every user per period, refreshed periodically by the application
+-- instead of scanning posts and reactions on every request. Weeks start on
+-- Monday and periods are measured in UTC.
+CREATE MATERIALIZED VIEW IF NOT EXISTS leaderboard_scores AS
+WITH periods AS (
+    SELECT 'week' AS period, date_trunc('week', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS since
+    UNION ALL
+    SELECT 'month', date_trunc('month', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+    UNION ALL
+    SELECT 'all_time', NULL::timestamptz
+),
+likes AS (
+    SELECT pr.period, p.user_id, COUNT(*) AS likes_received
+    FROM post_reactions r
+    JOIN posts p ON p.id = r.post_id
+    JOIN periods pr ON pr.since IS NULL OR r.created_at >= pr.since
+    WHERE r.type = 'like'
+    GROUP BY pr.period, p.user_id
+),
+posts_made AS (
+    SELECT pr.period, p.user_id, COUNT(*) AS post_count
+    FROM posts p
+    JOIN periods pr ON pr.since IS NULL OR p.created_at >= pr.since
+    GROUP BY pr.period, p.user_id
+),
+eco AS (
+    SELECT pr.period, ea.user_id, SUM(ea.co2e_kg) AS co2e_kg
+    FROM eco_actions ea
+    JOIN periods pr ON pr.since IS NULL OR ea.performed_at >= pr.since
+    GROUP BY pr.period, ea.user_id
+)
+SELECT
+    pr.period,
+    pr.since,
+    u.id AS user_id,
+    COALESCE(l.likes_received, 0) AS likes_received,
+    COALESCE(pm.post_count, 0) AS post_count,
+    COALESCE(e.co2e_kg, 0) AS co2e_kg,
+    now() AS refreshed_at
+FROM users u
+CROSS JOIN periods pr
+LEFT JOIN likes l ON l.user_id = u.id AND l.period = pr.period
+LEFT JOIN posts_made pm ON pm.user_id = u.id AND pm.period = pr.period
+LEFT JOIN eco e ON e.user_id = u.id AND e.period = pr.period
+WHERE u.deleted_at IS NULL
+AND (l.user_id IS NOT NULL OR pm.user_id IS NOT NULL OR e.user_id IS NOT NULL);
+
+-- Required to refresh the view concurrently
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leaderboard_scores_period_user_id ON leaderboard_scores (period, user_id);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_scores_likes_received ON leaderboard_scores (period, likes_received DESC);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_scores_post_count ON leaderboard_scores (period, post_count DESC);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_scores_co2e_kg ON leaderboard_scores (period, co2e_kg DESC);
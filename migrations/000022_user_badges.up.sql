@@ -0,0 +1,8 @@
+-- Badges are defined in code, a user earns each of them at most once
+CREATE TABLE IF NOT EXISTS user_badges (
+    user_id UUID NOT NULL,
+    badge VARCHAR(50) NOT NULL,
+    awarded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, badge),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+/*
+Badge model struct, an achievement awarded to a user
+
+Fields:
+  - ID:          string    - Identifier of the badge, see achievement.Rules
+  - Name:        string    - Human readable name
+  - Description: string    - What earned the badge
+  - AwardedAt:   time.Time - When the user earned the badge
+*/
+type Badge struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AwardedAt   time.Time `json:"awarded_at"`
+}
@@ -0,0 +1,105 @@
+package model
+
+import "time"
+
+// What the goal of a challenge is counted in
+const (
+	ChallengeGoalEcoAction   = "eco_action"
+	ChallengeGoalTaggedPosts = "tagged_posts"
+)
+
+// Where a challenge is in its lifetime
+const (
+	ChallengeStatusUpcoming = "upcoming"
+	ChallengeStatusActive   = "active"
+	ChallengeStatusEnded    = "ended"
+)
+
+// Unit of the goal of ChallengeGoalTaggedPosts challenges
+const ChallengePostUnit = "post"
+
+/*
+Challenge model struct, a campaign such as "Plastic-free July"
+
+Progress is what participants did between the start and the end of the
+challenge: the quantity of their eco-actions of ActionType, or the number of
+their posts tagged with Tag.
+
+Fields:
+  - ID:               int             - Unique identifier for the challenge
+  - Title:            string          - Title of the challenge
+  - Description:      string          - What the challenge is about
+  - StartsAt:         time.Time       - When the challenge starts
+  - EndsAt:           time.Time       - When the challenge ends, exclusive
+  - GoalType:         string          - ChallengeGoalEcoAction or ChallengeGoalTaggedPosts
+  - ActionType:       *string         - The counted eco-action type, for ChallengeGoalEcoAction
+  - Tag:              *string         - The counted hashtag, for ChallengeGoalTaggedPosts
+  - GoalQuantity:     float64         - How much participants must do to complete the challenge
+  - Unit:             string          - What the goal is counted in, e.g. "km" or "post"
+  - CreatedBy:        *string         - ID of the admin who created the challenge (nullable)
+  - CreatedAt:        time.Time       - When the challenge was created
+  - ParticipantCount: int             - Number of participants
+  - Stats:            *ChallengeStats - How participants are doing, only loaded when requested
+*/
+type Challenge struct {
+	ID               int             `json:"id"`
+	Title            string          `json:"title"`
+	Description      string          `json:"description"`
+	StartsAt         time.Time       `json:"starts_at"`
+	EndsAt           time.Time       `json:"ends_at"`
+	GoalType         string          `json:"goal_type"`
+	ActionType       *string         `json:"action_type"`
+	Tag              *string         `json:"tag"`
+	GoalQuantity     float64         `json:"goal_quantity"`
+	Unit             string          `json:"unit"`
+	CreatedBy        *string         `json:"created_by"`
+	CreatedAt        time.Time       `json:"created_at"`
+	ParticipantCount int             `json:"participant_count"`
+	Stats            *ChallengeStats `json:"stats,omitempty"`
+}
+
+// Reports where the challenge is in its lifetime at the given time
+func (challenge Challenge) Status(now time.Time) string {
+	switch {
+	case now.Before(challenge.StartsAt):
+		return ChallengeStatusUpcoming
+	case now.Before(challenge.EndsAt):
+		return ChallengeStatusActive
+	default:
+		return ChallengeStatusEnded
+	}
+}
+
+/*
+ChallengeStats model struct
+
+Fields:
+  - CompletedCount: int     - Number of participants who reached the goal
+  - CompletionRate: float64 - Share of participants who reached the goal, from 0 to 1
+*/
+type ChallengeStats struct {
+	CompletedCount int     `json:"completed_count"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+/*
+ChallengeProgress model struct, how far a participant got
+
+Fields:
+  - ChallengeID: int       - ID of the challenge
+  - UserID:      string    - ID of the participant
+  - Username:    string    - Username of the participant
+  - JoinedAt:    time.Time - When the user joined the challenge
+  - Progress:    float64   - How much the user did, in the unit of the challenge
+  - Goal:        float64   - The goal of the challenge
+  - Completed:   bool      - Whether the user reached the goal
+*/
+type ChallengeProgress struct {
+	ChallengeID int       `json:"challenge_id"`
+	UserID      string    `json:"user_id"`
+	Username    string    `json:"username"`
+	JoinedAt    time.Time `json:"joined_at"`
+	Progress    float64   `json:"progress"`
+	Goal        float64   `json:"goal"`
+	Completed   bool      `json:"completed"`
+}
@@ -2,6 +2,9 @@ package model
 
 import "time"
 
+// Deepest a reply can be nested, top-level comments have depth 0
+const MaxCommentDepth = 5
+
 /*
 Comment model struct
 
@@ -9,22 +12,29 @@ Fields:
   - ID:         int           - Unique identifier for the comment
   - UserID:     string        - ID of the user who created the comment
   - PostID:     int           - ID of the post being commented on
+  - ParentID:   *int          - ID of the comment this one replies to (nullable)
+  - Depth:      int           - How deeply the comment is nested, 0 for top-level comments
+  - ReplyCount: int           - Number of direct replies to the comment
   - Text:       string        - Content of the comment
   - CreatedAt:  time.Time     - When the comment was created
   - UpdatedAt:  *time.Time    - When the comment was last updated (nullable)
+  - Reactions:  *ReactionSummary - Reactions on the comment, only loaded when requested
 */
 type Comment struct {
-	ID        int        `json:"id"`
-	UserID    string     `json:"user_id"`
-	PostID    int        `json:"post_id"`
-	Text      string     `json:"text"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	ID         int              `json:"id"`
+	UserID     string           `json:"user_id"`
+	PostID     int              `json:"post_id"`
+	ParentID   *int             `json:"parent_id"`
+	Depth      int              `json:"depth"`
+	ReplyCount int              `json:"reply_count"`
+	Text       string           `json:"text"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  *time.Time       `json:"updated_at,omitempty"`
+	Reactions  *ReactionSummary `json:"reactions,omitempty"`
 }
 
 // CommentWithUser includes basic user information with the comment
 type CommentWithUser struct {
 	Comment
 	Username string `json:"username"`
-	Email    string `json:"email,omitempty"`
 }
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Periods eco-action savings are totalled over
+const (
+	EcoPeriodDay     = "day"
+	EcoPeriodWeek    = "week"
+	EcoPeriodMonth   = "month"
+	EcoPeriodAllTime = "all_time"
+)
+
+// Every period, shortest first
+var EcoPeriods = []string{EcoPeriodDay, EcoPeriodWeek, EcoPeriodMonth, EcoPeriodAllTime}
+
+// How far back an action can be logged
+const EcoActionBackdateLimit = 30 * 24 * time.Hour
+
+/*
+Returns when the current period began
+
+Days start at midnight, weeks on Monday and months on their first day, in the
+location of now.
+
+Params:
+  - period: One of EcoPeriods
+  - now:    The current time, in the location the period is measured in
+
+Returns:
+  - The start of the period, nil for EcoPeriodAllTime
+  - An error if the period doesn't exist
+*/
+func EcoPeriodStart(period string, now time.Time) (*time.Time, error) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	var start time.Time
+	switch period {
+	case EcoPeriodDay:
+		start = today
+	case EcoPeriodWeek:
+		// Weekday counts from Sunday
+		start = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	case EcoPeriodMonth:
+		start = time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	case EcoPeriodAllTime:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown period %q", period)
+	}
+
+	return &start, nil
+}
+
+/*
+EcoActionType model struct, something users can do for the planet
+
+Fields:
+  - ID:                 string  - Unique identifier, e.g. "composted"
+  - Name:               string  - Human readable name
+  - Unit:               string  - What the quantity of an action is measured in, e.g. "km"
+  - CO2eKgPerUnit:      float64 - Kilograms of CO2 equivalent saved per unit
+  - WaterLitresPerUnit: float64 - Litres of water saved per unit
+  - WasteKgPerUnit:     float64 - Kilograms of waste avoided per unit
+  - MaxQuantity:        float64 - Largest quantity a single action can be logged with
+*/
+type EcoActionType struct {
+	ID                 string  `json:"id"`
+	Name               string  `json:"name"`
+	Unit               string  `json:"unit"`
+	CO2eKgPerUnit      float64 `json:"co2e_kg_per_unit"`
+	WaterLitresPerUnit float64 `json:"water_litres_per_unit"`
+	WasteKgPerUnit     float64 `json:"waste_kg_per_unit"`
+	MaxQuantity        float64 `json:"max_quantity"`
+}
+
+/*
+EcoSavings model struct, the estimated impact of one or more actions
+
+Fields:
+  - CO2eKg:      float64 - Kilograms of CO2 equivalent saved
+  - WaterLitres: float64 - Litres of water saved
+  - WasteKg:     float64 - Kilograms of waste avoided
+*/
+type EcoSavings struct {
+	CO2eKg      float64 `json:"co2e_kg"`
+	WaterLitres float64 `json:"water_litres"`
+	WasteKg     float64 `json:"waste_kg"`
+}
+
+/*
+EcoAction model struct, an action logged by a user
+
+Fields:
+  - ID:          int        - Unique identifier for the action
+  - UserID:      string     - ID of the user who logged the action
+  - ActionType:  string     - ID of the EcoActionType
+  - Quantity:    float64    - How much was done, in the unit of the type
+  - Savings:     EcoSavings - The savings estimated when the action was logged
+  - PostID:      *int       - The post the action is attached to (nullable)
+  - PerformedAt: time.Time  - When the user did it
+  - CreatedAt:   time.Time  - When it was logged
+*/
+type EcoAction struct {
+	ID          int        `json:"id"`
+	UserID      string     `json:"user_id"`
+	ActionType  string     `json:"action_type"`
+	Quantity    float64    `json:"quantity"`
+	Savings     EcoSavings `json:"savings"`
+	PostID      *int       `json:"post_id"`
+	PerformedAt time.Time  `json:"performed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+/*
+EcoTotals model struct, the savings of a user over a period
+
+Fields:
+  - Period:      string     - One of EcoPeriods
+  - Since:       *time.Time - When the period began, nil for EcoPeriodAllTime
+  - ActionCount: int        - Number of actions performed in the period
+  - Savings:     EcoSavings - Their combined savings
+*/
+type EcoTotals struct {
+	Period      string     `json:"period"`
+	Since       *time.Time `json:"since"`
+	ActionCount int        `json:"action_count"`
+	Savings     EcoSavings `json:"savings"`
+}
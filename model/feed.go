@@ -0,0 +1,19 @@
+package model
+
+// Why a post shows up in a user's feed
+const (
+	FeedSourceFollowing = "following"
+	FeedSourcePopular   = "popular"
+)
+
+/*
+FeedPost model struct
+
+Fields:
+  - Post:   Post   - The post
+  - Source: string - FeedSourceFollowing for posts of the user and the users they follow, FeedSourcePopular otherwise
+*/
+type FeedPost struct {
+	Post
+	Source string `json:"source"`
+}
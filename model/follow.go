@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+/*
+Follow model struct, one side of a follow relationship as seen from a user
+
+Fields:
+  - UserID:     string    - ID of the follower or followed user
+  - Username:   string    - Username of that user
+  - FollowedAt: time.Time - When the follow was created
+*/
+type Follow struct {
+	UserID     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	FollowedAt time.Time `json:"followed_at"`
+}
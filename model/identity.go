@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+UserIdentity model struct
+
+Links an account at an OAuth provider to a user.
+
+Fields:
+  - Provider:  string    - Name of the OAuth provider (e.g. google)
+  - Subject:   string    - Stable identifier of the user at the provider
+  - UserID:    uuid      - ID of the linked user
+  - Email:     string    - Email reported by the provider when linked
+  - CreatedAt: time.Time - When the identity was linked
+*/
+type UserIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"-"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
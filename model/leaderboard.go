@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// What users are ranked by
+const (
+	LeaderboardLikesReceived = "likes_received"
+	LeaderboardPosts         = "posts"
+	LeaderboardEcoImpact     = "eco_impact"
+)
+
+// Every leaderboard metric
+var LeaderboardMetrics = []string{LeaderboardLikesReceived, LeaderboardPosts, LeaderboardEcoImpact}
+
+// Who users are ranked against
+const (
+	LeaderboardScopeGlobal    = "global"
+	LeaderboardScopeFollowing = "following"
+)
+
+// Periods leaderboards are kept for, measured in UTC
+var LeaderboardPeriods = []string{EcoPeriodWeek, EcoPeriodMonth, EcoPeriodAllTime}
+
+/*
+LeaderboardEntry model struct, the place of a user on a leaderboard
+
+Fields:
+  - Rank:     int     - Position of the user, users with the same score share it
+  - UserID:   string  - ID of the user
+  - Username: string  - Username of the user
+  - Score:    float64 - Likes received, posts made or kilograms of CO2e saved in the period
+*/
+type LeaderboardEntry struct {
+	Rank     int     `json:"rank"`
+	UserID   string  `json:"user_id"`
+	Username string  `json:"username"`
+	Score    float64 `json:"score"`
+}
+
+/*
+Leaderboard model struct
+
+Fields:
+  - Metric:      string             - One of LeaderboardMetrics
+  - Period:      string             - One of LeaderboardPeriods
+  - Scope:       string             - LeaderboardScopeGlobal or LeaderboardScopeFollowing
+  - Since:       *time.Time         - When the period began, nil for EcoPeriodAllTime
+  - RefreshedAt: *time.Time         - When the scores were computed, nil before anyone scored
+  - Entries:     []LeaderboardEntry - The requested page of the ranking
+  - Me:          *LeaderboardEntry  - The place of the requesting user, nil when signed out or unranked
+*/
+type Leaderboard struct {
+	Metric      string             `json:"metric"`
+	Period      string             `json:"period"`
+	Scope       string             `json:"scope"`
+	Since       *time.Time         `json:"since"`
+	RefreshedAt *time.Time         `json:"refreshed_at"`
+	Entries     []LeaderboardEntry `json:"entries"`
+	Me          *LeaderboardEntry  `json:"me"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Maximum number of images attached to a single post
+const MaxMediaPerPost = 4
+
+/*
+Media model struct, an image attached to a post
+
+Fields:
+  - ID:           int       - Unique identifier for the media
+  - PostID:       int       - ID of the post it is attached to
+  - StorageKey:   string    - Key of the original file in storage
+  - ThumbnailKey: string    - Key of the thumbnail in storage
+  - MimeType:     string    - MIME type of the original file
+  - Width:        int       - Width of the original in pixels
+  - Height:       int       - Height of the original in pixels
+  - SizeBytes:    int       - Size of the original file
+  - Position:     int       - Order of the media within the post, starting at 0
+  - CreatedAt:    time.Time - When the media was uploaded
+  - URL:          string    - Public URL of the original, resolved from storage when read
+  - ThumbnailURL: string    - Public URL of the thumbnail, resolved from storage when read
+*/
+type Media struct {
+	ID           int       `json:"id"`
+	PostID       int       `json:"post_id"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	MimeType     string    `json:"mime_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	SizeBytes    int       `json:"size_bytes"`
+	Position     int       `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// What a notification is about
+const (
+	NotificationPostReaction    = "post_reaction"
+	NotificationCommentReaction = "comment_reaction"
+	NotificationComment         = "comment"
+	NotificationReply           = "reply"
+	NotificationFollow          = "follow"
+)
+
+// Number of actors listed by name on a notification
+const NotificationActorsShown = 3
+
+/*
+NotificationActor model struct, a user who caused a notification
+
+Fields:
+  - UserID:   string - ID of the user
+  - Username: string - Username of the user
+*/
+type NotificationActor struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+/*
+Notification model struct, a group of similar events
+
+Events of the same type on the same post or comment are coalesced into the
+unread notification of the group until the user reads it.
+
+Fields:
+  - ID:         int                 - Unique identifier for the notification
+  - UserID:     string              - ID of the notified user
+  - Type:       string              - One of the Notification* types
+  - PostID:     *int                - The post the events happened on (nullable)
+  - CommentID:  *int                - The comment the events happened on (nullable)
+  - Actors:     []NotificationActor - The most recent actors, at most NotificationActorsShown
+  - ActorCount: int                 - Number of distinct actors in the group
+  - CreatedAt:  time.Time           - When the first event happened
+  - UpdatedAt:  time.Time           - When the latest event happened
+  - ReadAt:     *time.Time          - When the user read the notification (nullable)
+*/
+type Notification struct {
+	ID         int                 `json:"id"`
+	UserID     string              `json:"user_id"`
+	Type       string              `json:"type"`
+	PostID     *int                `json:"post_id,omitempty"`
+	CommentID  *int                `json:"comment_id,omitempty"`
+	Actors     []NotificationActor `json:"actors"`
+	ActorCount int                 `json:"actor_count"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	ReadAt     *time.Time          `json:"read_at,omitempty"`
+}
+
+/*
+Describes the notification, e.g. "alex and 4 others reacted to your post"
+
+Returns:
+  - The message shown to the user
+*/
+func (notification Notification) Message() string {
+	var action string
+	switch notification.Type {
+	case NotificationPostReaction:
+		action = "reacted to your post"
+	case NotificationCommentReaction:
+		action = "reacted to your comment"
+	case NotificationComment:
+		action = "commented on your post"
+	case NotificationReply:
+		action = "replied to your comment"
+	case NotificationFollow:
+		action = "followed you"
+	default:
+		action = "interacted with you"
+	}
+
+	if len(notification.Actors) == 0 {
+		return fmt.Sprintf("Someone %s", action)
+	}
+
+	first := notification.Actors[0].Username
+	switch others := notification.ActorCount - 1; {
+	case others <= 0:
+		return fmt.Sprintf("%s %s", first, action)
+	case others == 1 && len(notification.Actors) > 1:
+		return fmt.Sprintf("%s and %s %s", first, notification.Actors[1].Username, action)
+	case others == 1:
+		return fmt.Sprintf("%s and 1 other %s", first, action)
+	default:
+		return fmt.Sprintf("%s and %d others %s", first, others, action)
+	}
+}
@@ -12,12 +12,16 @@ Fields:
   - LikeCount:  int           - Number of likes the post has received
   - CreatedAt:  time.Time     - When the post was created
   - UpdatedAt:  *time.Time    - When the post was last updated (nullable)
+  - Media:      []Media       - Images attached to the post, only loaded when requested
+  - Reactions:  *ReactionSummary - Reactions on the post, only loaded when requested
 */
 type Post struct {
-	ID        int        `json:"id"`
-	UserID    string     `json:"user_id"`
-	Text      string     `json:"text"`
-	LikeCount int        `json:"like_count"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"` // Pointer to allow null
+	ID        int              `json:"id"`
+	UserID    string           `json:"user_id"`
+	Text      string           `json:"text"`
+	LikeCount int              `json:"like_count"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt *time.Time       `json:"updated_at,omitempty"` // Pointer to allow null
+	Media     []Media          `json:"media,omitempty"`
+	Reactions *ReactionSummary `json:"reactions,omitempty"`
 }
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+Profile model struct, the public face of a user
+
+Fields:
+  - UserID:      uuid       - ID of the user the profile belongs to (references users.id)
+  - Username:    string     - Username of the user
+  - DisplayName: string     - Name shown instead of the username, empty when not set
+  - Bio:         string     - Short description the user wrote about themselves
+  - Location:    string     - Free-form location, e.g. a city
+  - AvatarURL:   string     - URL of the avatar image
+  - JoinedAt:    time.Time  - When the user signed up
+  - UpdatedAt:   *time.Time - When the profile was last updated (nullable)
+*/
+type Profile struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	Username    string     `json:"username"`
+	DisplayName string     `json:"display_name"`
+	Bio         string     `json:"bio"`
+	Location    string     `json:"location"`
+	AvatarURL   string     `json:"avatar_url"`
+	JoinedAt    time.Time  `json:"joined_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"` // Pointer to allow null
+}
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// Types of reactions a user can leave on a post or comment
+const (
+	ReactionLike         = "like"
+	ReactionInspiring    = "inspiring"
+	ReactionHelpful      = "helpful"
+	ReactionPlantedATree = "planted_a_tree"
+)
+
+// Every reaction type, in the order clients should show them
+var ReactionTypes = []string{ReactionLike, ReactionInspiring, ReactionHelpful, ReactionPlantedATree}
+
+// What a reaction is left on
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetComment = "comment"
+)
+
+// Reports whether the reaction type exists
+func IsReactionType(reactionType string) bool {
+	for _, known := range ReactionTypes {
+		if reactionType == known {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Reaction model struct
+
+Fields:
+  - UserID:     string    - ID of the user who reacted
+  - TargetType: string    - ReactionTargetPost or ReactionTargetComment
+  - TargetID:   int       - ID of the post or comment
+  - Type:       string    - One of ReactionTypes
+  - CreatedAt:  time.Time - When the reaction was left or last changed
+*/
+type Reaction struct {
+	UserID     string    `json:"user_id"`
+	TargetType string    `json:"target_type"`
+	TargetID   int       `json:"target_id"`
+	Type       string    `json:"type"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+/*
+ReactionSummary model struct, the reactions on a post or comment
+
+Fields:
+  - Counts:     map[string]int - Number of reactions of every type, including those nobody left
+  - MyReaction: string         - The reaction of the requesting user, empty when they have none or are signed out
+*/
+type ReactionSummary struct {
+	Counts     map[string]int `json:"counts"`
+	MyReaction string         `json:"my_reaction,omitempty"`
+}
+
+// Creates a summary without any reactions
+func NewReactionSummary() ReactionSummary {
+	summary := ReactionSummary{Counts: make(map[string]int, len(ReactionTypes))}
+	for _, reactionType := range ReactionTypes {
+		summary.Counts[reactionType] = 0
+	}
+	return summary
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+/*
+Revision model struct, a previous version of an edited post or comment
+
+Fields:
+  - ID:        int       - Unique identifier for the revision
+  - Text:      string    - Content of the previous version
+  - WrittenAt: time.Time - When the previous version was written
+  - RevisedAt: time.Time - When it was replaced by an edit
+*/
+type Revision struct {
+	ID        int       `json:"id"`
+	Text      string    `json:"text"`
+	WrittenAt time.Time `json:"written_at"`
+	RevisedAt time.Time `json:"revised_at"`
+}
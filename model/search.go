@@ -0,0 +1,55 @@
+package model
+
+// What a search looks through
+const (
+	SearchTypeAll      = "all"
+	SearchTypePosts    = "posts"
+	SearchTypeComments = "comments"
+	SearchTypeUsers    = "users"
+)
+
+/*
+PostSearchResult model struct
+
+Fields:
+  - Post:     Post    - The matching post
+  - Headline: string  - HTML escaped excerpt of the text with the matches wrapped in <mark> tags
+  - Rank:     float64 - Relevance weighted by recency, higher is better
+*/
+type PostSearchResult struct {
+	Post
+	Headline string  `json:"headline"`
+	Rank     float64 `json:"rank"`
+}
+
+/*
+CommentSearchResult model struct
+
+Fields:
+  - CommentWithUser: CommentWithUser - The matching comment and its author
+  - Headline:        string          - HTML escaped excerpt of the text with the matches wrapped in <mark> tags
+  - Rank:            float64         - Relevance weighted by recency, higher is better
+*/
+type CommentSearchResult struct {
+	CommentWithUser
+	Headline string  `json:"headline"`
+	Rank     float64 `json:"rank"`
+}
+
+/*
+UserSearchResult model struct
+
+Fields:
+  - UserID:      string  - ID of the matching user
+  - Username:    string  - Username of the user
+  - DisplayName: string  - Display name of the user, empty when not set
+  - AvatarURL:   string  - URL of the avatar image, empty when not set
+  - Similarity:  float64 - Trigram similarity between the username and the query, from 0 to 1
+*/
+type UserSearchResult struct {
+	UserID      string  `json:"user_id"`
+	Username    string  `json:"username"`
+	DisplayName string  `json:"display_name"`
+	AvatarURL   string  `json:"avatar_url"`
+	Similarity  float64 `json:"similarity"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+Session model struct
+
+A session is a single refresh token issued to a user. Every rotation creates
+a new session in the same family, so a replayed token can be traced back to
+the whole chain it belongs to.
+
+Fields:
+  - ID:         uuid        - Unique identifier for the session
+  - UserID:     uuid        - ID of the user the session belongs to
+  - FamilyID:   uuid        - ID shared by every session in a rotation chain
+  - TokenHash:  string      - SHA-256 hash of the opaque refresh token
+  - ExpiresAt:  time.Time   - When the refresh token stops being accepted
+  - CreatedAt:  time.Time   - When the session was created
+  - RevokedAt:  *time.Time  - When the session was revoked or rotated (nullable)
+  - ReplacedBy: *uuid       - ID of the session that replaced this one (nullable)
+*/
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty"`
+}
@@ -0,0 +1,13 @@
+package model
+
+/*
+Tag model struct, a hashtag with the number of posts using it
+
+Fields:
+  - Name:      string - The normalized tag, without its '#'
+  - PostCount: int    - Number of posts with the tag, within the requested window for trending tags
+*/
+type Tag struct {
+	Name      string `json:"name"`
+	PostCount int    `json:"post_count"`
+}
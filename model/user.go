@@ -1,19 +1,48 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How long a deleted account can be restored by signing in before it is removed for good
+const AccountDeletionGracePeriod = 30 * 24 * time.Hour
 
 /*
 User model struct
 
 Fields:
-  - ID:       uuid
-  - Username: string
-  - Email:    string
-  - Password: string
+  - ID:            uuid
+  - Username:      string
+  - Email:         string
+  - Password:      string
+  - EmailVerified: bool
+  - DeletedAt:     *time.Time (nullable, set while the account awaits deletion)
 */
 type User struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
-	Password string    `json:"password"`
+	ID            uuid.UUID  `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	Password      string     `json:"-"` // Never serialized, use a payload from util instead
+	EmailVerified bool       `json:"email_verified"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+/*
+UserStats struct
+
+Fields:
+  - PostCount:      int - Number of posts the user made
+  - CommentCount:   int - Number of comments the user made
+  - LikesReceived:  int - Number of likes on the user's posts
+  - FollowerCount:  int - Number of users following the user
+  - FollowingCount: int - Number of users the user follows
+*/
+type UserStats struct {
+	PostCount      int `json:"post_count"`
+	CommentCount   int `json:"comment_count"`
+	LikesReceived  int `json:"likes_received"`
+	FollowerCount  int `json:"follower_count"`
+	FollowingCount int `json:"following_count"`
 }
@@ -0,0 +1,181 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Page sizes used when a request does not ask for one, and the most it may ask for
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Errors returned while reading the page of a request
+var (
+	ErrInvalidLimit  = fmt.Errorf("limit must be a number between 1 and %d", MaxLimit)
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+/*
+Cursor struct, the position of a row in a listing ordered by (created_at, id)
+
+The ID is kept as a string so the same cursor works for integer and UUID keys,
+use IntID or UUID to read it back.
+
+Fields:
+  - CreatedAt: time.Time - When the row was created
+  - ID:        string    - ID of the row, breaks ties between equal creation times
+*/
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+/*
+Page struct, what a list request asks for
+
+Fields:
+  - Limit: int     - The number of items on the page
+  - After: *Cursor - Only items after this cursor are returned, nil for the first page
+*/
+type Page struct {
+	Limit int
+	After *Cursor
+}
+
+/*
+Response struct, the envelope every list endpoint responds with
+
+Fields:
+  - Items:      []T
+  - NextCursor: string (empty on the last page)
+  - HasMore:    bool
+*/
+type Response[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Creates a cursor from the creation time and ID of a row
+func NewCursor(createdAt time.Time, id interface{}) Cursor {
+	return Cursor{CreatedAt: createdAt, ID: fmt.Sprint(id)}
+}
+
+/*
+Reads the page from the limit and cursor query parameters
+
+Params:
+  - r: The request
+
+Returns:
+  - The page, with DefaultLimit when no limit is given
+  - ErrInvalidLimit or ErrInvalidCursor if a parameter is malformed
+*/
+func FromRequest(r *http.Request) (Page, error) {
+	query := r.URL.Query()
+	page := Page{Limit: DefaultLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Page{}, ErrInvalidLimit
+		}
+		page.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := Decode(value)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = &cursor
+	}
+
+	return page, nil
+}
+
+// The number of rows to fetch, one more than the limit to know whether there is a next page
+func (page Page) FetchLimit() int {
+	return page.Limit + 1
+}
+
+/*
+Builds the response envelope from rows fetched with FetchLimit
+
+Params:
+  - rows:    The fetched rows, possibly one more than the page limit
+  - page:    The requested page
+  - cursor:  Returns the cursor of a row
+  - payload: Converts a row into the item sent to the client
+
+Returns:
+  - The response with at most page.Limit items
+*/
+func NewResponse[T any, P any](rows []T, page Page, cursor func(T) Cursor, payload func(T) P) Response[P] {
+	response := Response[P]{Items: make([]P, 0, len(rows))}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		response.HasMore = true
+		response.NextCursor = cursor(rows[len(rows)-1]).Encode()
+	}
+
+	for _, row := range rows {
+		response.Items = append(response.Items, payload(row))
+	}
+
+	return response
+}
+
+// Encodes the cursor as an opaque string
+func (cursor Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%s", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decodes a cursor created by Encode
+func Decode(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found || id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedAt: time.Unix(0, createdAt), ID: id}, nil
+}
+
+// Reads the ID of a cursor over a listing with integer keys
+func (cursor Cursor) IntID() (int, error) {
+	id, err := strconv.Atoi(cursor.ID)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// Reads the ID of a cursor over a listing with UUID keys
+func (cursor Cursor) UUID() (uuid.UUID, error) {
+	id, err := uuid.Parse(cursor.ID)
+	if err != nil {
+		return uuid.UUID{}, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,147 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	userID := uuid.New()
+
+	for _, cursor := range []Cursor{
+		NewCursor(createdAt, 42),
+		NewCursor(createdAt, userID),
+	} {
+		decoded, err := Decode(cursor.Encode())
+		if err != nil {
+			t.Fatalf("Decode(%v): %v", cursor, err)
+		}
+		if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+			t.Errorf("Decode(Encode(%v)) = %v", cursor, decoded)
+		}
+	}
+
+	id, err := NewCursor(createdAt, 42).IntID()
+	if err != nil || id != 42 {
+		t.Errorf("IntID = %d, %v, want 42", id, err)
+	}
+
+	parsed, err := NewCursor(createdAt, userID).UUID()
+	if err != nil || parsed != userID {
+		t.Errorf("UUID = %s, %v, want %s", parsed, err, userID)
+	}
+}
+
+func TestDecodeRejectsMalformedCursors(t *testing.T) {
+	for _, value := range []string{
+		"not base64!",
+		"bm8tY29sb24", // "no-colon"
+		"MTIzOg",      // "123:"
+		"YWJjOjQy",    // "abc:42"
+	} {
+		if _, err := Decode(value); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) = %v, want %v", value, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestFromRequestLimit(t *testing.T) {
+	tests := []struct {
+		query string
+		limit int
+		err   error
+	}{
+		{"", DefaultLimit, nil},
+		{"?limit=1", 1, nil},
+		{"?limit=" + strconv.Itoa(MaxLimit), MaxLimit, nil},
+		{"?limit=0", 0, ErrInvalidLimit},
+		{"?limit=-5", 0, ErrInvalidLimit},
+		{"?limit=" + strconv.Itoa(MaxLimit+1), 0, ErrInvalidLimit},
+		{"?limit=ten", 0, ErrInvalidLimit},
+		{"?cursor=not-a-cursor", 0, ErrInvalidCursor},
+	}
+
+	for _, test := range tests {
+		page, err := FromRequest(httptest.NewRequest("GET", "/posts"+test.query, nil))
+		if err != test.err {
+			t.Errorf("FromRequest(%q) error = %v, want %v", test.query, err, test.err)
+			continue
+		}
+		if err == nil && page.Limit != test.limit {
+			t.Errorf("FromRequest(%q) limit = %d, want %d", test.query, page.Limit, test.limit)
+		}
+	}
+}
+
+func TestFromRequestCursor(t *testing.T) {
+	cursor := NewCursor(time.Unix(1700000000, 0), 7)
+
+	page, err := FromRequest(httptest.NewRequest("GET", "/posts?limit=5&cursor="+cursor.Encode(), nil))
+	if err != nil {
+		t.Fatalf("FromRequest: %v", err)
+	}
+	if page.After == nil || page.After.ID != "7" || !page.After.CreatedAt.Equal(cursor.CreatedAt) {
+		t.Errorf("After = %v, want %v", page.After, cursor)
+	}
+	if page.FetchLimit() != 6 {
+		t.Errorf("FetchLimit = %d, want 6", page.FetchLimit())
+	}
+}
+
+func newTestResponse(count int, limit int) Response[int] {
+	rows := make([]int, count)
+	for i := range rows {
+		rows[i] = i + 1
+	}
+
+	cursor := func(row int) Cursor { return NewCursor(time.Unix(int64(row), 0), row) }
+	payload := func(row int) int { return row }
+
+	return NewResponse(rows, Page{Limit: limit}, cursor, payload)
+}
+
+func TestNewResponseLastPage(t *testing.T) {
+	response := newTestResponse(3, 3)
+
+	if len(response.Items) != 3 {
+		t.Errorf("got %d items, want 3", len(response.Items))
+	}
+	if response.HasMore || response.NextCursor != "" {
+		t.Errorf("HasMore = %v, NextCursor = %q, want no next page", response.HasMore, response.NextCursor)
+	}
+}
+
+func TestNewResponseWithNextPage(t *testing.T) {
+	response := newTestResponse(4, 3)
+
+	if len(response.Items) != 3 {
+		t.Errorf("got %d items, want 3", len(response.Items))
+	}
+	if !response.HasMore {
+		t.Error("HasMore = false, want true")
+	}
+
+	next, err := Decode(response.NextCursor)
+	if err != nil {
+		t.Fatalf("Decode(NextCursor): %v", err)
+	}
+	if next.ID != "3" {
+		t.Errorf("NextCursor points at %s, want the last item on the page", next.ID)
+	}
+}
+
+func TestNewResponseEmpty(t *testing.T) {
+	response := newTestResponse(0, 3)
+
+	if response.Items == nil || len(response.Items) != 0 {
+		t.Errorf("Items = %v, want an empty, non-nil slice", response.Items)
+	}
+	if response.HasMore {
+		t.Error("HasMore = true on an empty page")
+	}
+}
@@ -0,0 +1,181 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Types of the events sent to clients
+const (
+	EventCommentCreated   = "comment_created"
+	EventReactionsChanged = "reactions_changed"
+	EventNotification     = "notification"
+	EventBadgeAwarded     = "badge_awarded"
+
+	// Sent to WebSocket clients only
+	EventHeartbeat = "heartbeat"
+
+	// Sent to WebSocket clients for rejected messages, and to every client before its stream is closed
+	EventError = "error"
+)
+
+// Number of events buffered for a subscriber before new ones are dropped
+const subscriptionBuffer = 64
+
+/*
+Event struct, something clients subscribed to a topic are told about
+
+Fields:
+  - Topic: string          - The topic the event is published on, see UserTopic and PostTopic
+  - Type:  string          - One of the Event* types
+  - Data:  json.RawMessage - The event itself, null when it was too large to be sent and has to be refetched
+*/
+type Event struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// The topic of the events only a user is told about
+func UserTopic(userID string) string {
+	return "user:" + userID
+}
+
+// The topic of the activity on a post
+func PostTopic(postID int) string {
+	return fmt.Sprintf("post:%d", postID)
+}
+
+/*
+Hub struct, an in-process publish/subscribe hub
+
+Every server instance has its own hub, fed by Listen so events published by
+any instance reach the clients connected to all of them.
+*/
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: map[string]map[*Subscription]struct{}{}}
+}
+
+/*
+Subscription struct, the events of a set of topics delivered to one client
+
+Events are dropped rather than queued when the client falls behind, so a slow
+connection never blocks the hub.
+*/
+type Subscription struct {
+	hub    *Hub
+	events chan Event
+	topics map[string]struct{}
+	closed bool
+}
+
+/*
+Subscribes to topics
+
+Params:
+  - topics: The topics, more can be added with Add
+
+Returns:
+  - The subscription, which must be closed when the client leaves
+*/
+func (hub *Hub) Subscribe(topics ...string) *Subscription {
+	subscription := &Subscription{
+		hub:    hub,
+		events: make(chan Event, subscriptionBuffer),
+		topics: map[string]struct{}{},
+	}
+
+	for _, topic := range topics {
+		subscription.Add(topic)
+	}
+
+	return subscription
+}
+
+// Delivers an event to every subscriber of its topic
+func (hub *Hub) Publish(event Event) {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+
+	for subscription := range hub.subscribers[event.Topic] {
+		select {
+		case subscription.events <- event:
+		default:
+		}
+	}
+}
+
+// The events of the subscribed topics, closed when the subscription is
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+// Reports how many topics the subscription has
+func (subscription *Subscription) TopicCount() int {
+	hub := subscription.hub
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+
+	return len(subscription.topics)
+}
+
+// Adds a topic to the subscription
+func (subscription *Subscription) Add(topic string) {
+	hub := subscription.hub
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if subscription.closed {
+		return
+	}
+
+	if hub.subscribers[topic] == nil {
+		hub.subscribers[topic] = map[*Subscription]struct{}{}
+	}
+	hub.subscribers[topic][subscription] = struct{}{}
+	subscription.topics[topic] = struct{}{}
+}
+
+// Removes a topic from the subscription
+func (subscription *Subscription) Remove(topic string) {
+	hub := subscription.hub
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	subscription.remove(topic)
+}
+
+// Removes a topic, the hub must be locked
+func (subscription *Subscription) remove(topic string) {
+	hub := subscription.hub
+
+	delete(hub.subscribers[topic], subscription)
+	if len(hub.subscribers[topic]) == 0 {
+		delete(hub.subscribers, topic)
+	}
+	delete(subscription.topics, topic)
+}
+
+// Ends the subscription and closes its events channel
+func (subscription *Subscription) Close() {
+	hub := subscription.hub
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if subscription.closed {
+		return
+	}
+
+	for topic := range subscription.topics {
+		subscription.remove(topic)
+	}
+
+	subscription.closed = true
+	close(subscription.events)
+}
@@ -0,0 +1,160 @@
+package realtime
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Reads the events already delivered to a subscription without blocking
+func drain(subscription *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestPublishDeliversToTopicSubscribers(t *testing.T) {
+	hub := NewHub()
+	post := hub.Subscribe(PostTopic(1))
+	user := hub.Subscribe(UserTopic("jane"))
+	both := hub.Subscribe(PostTopic(1), UserTopic("jane"))
+	defer post.Close()
+	defer user.Close()
+	defer both.Close()
+
+	hub.Publish(Event{Topic: PostTopic(1), Type: EventCommentCreated})
+	hub.Publish(Event{Topic: UserTopic("jane"), Type: EventNotification})
+	hub.Publish(Event{Topic: PostTopic(2), Type: EventCommentCreated})
+
+	if events := drain(post); len(events) != 1 || events[0].Type != EventCommentCreated {
+		t.Errorf("post subscriber got %v, want the comment", events)
+	}
+	if events := drain(user); len(events) != 1 || events[0].Type != EventNotification {
+		t.Errorf("user subscriber got %v, want the notification", events)
+	}
+	if events := drain(both); len(events) != 2 {
+		t.Errorf("subscriber of both topics got %d events, want 2", len(events))
+	}
+}
+
+func TestAddAndRemoveTopics(t *testing.T) {
+	hub := NewHub()
+	subscription := hub.Subscribe()
+	defer subscription.Close()
+
+	subscription.Add(PostTopic(1))
+	subscription.Add(PostTopic(1))
+	subscription.Add(PostTopic(2))
+	if count := subscription.TopicCount(); count != 2 {
+		t.Errorf("TopicCount = %d, want 2", count)
+	}
+
+	subscription.Remove(PostTopic(1))
+	hub.Publish(Event{Topic: PostTopic(1)})
+	hub.Publish(Event{Topic: PostTopic(2)})
+
+	if events := drain(subscription); len(events) != 1 || events[0].Topic != PostTopic(2) {
+		t.Errorf("got %v, want only the event of the remaining topic", events)
+	}
+	if _, ok := hub.subscribers[PostTopic(1)]; ok {
+		t.Error("topic without subscribers was kept in the hub")
+	}
+}
+
+func TestPublishDropsEventsForSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	subscription := hub.Subscribe(PostTopic(1))
+	defer subscription.Close()
+
+	for i := 0; i < subscriptionBuffer+10; i++ {
+		hub.Publish(Event{Topic: PostTopic(1)})
+	}
+
+	if events := drain(subscription); len(events) != subscriptionBuffer {
+		t.Errorf("got %d events, want the %d that fit the buffer", len(events), subscriptionBuffer)
+	}
+}
+
+func TestCloseEndsSubscription(t *testing.T) {
+	hub := NewHub()
+	subscription := hub.Subscribe(PostTopic(1), UserTopic("jane"))
+
+	subscription.Close()
+	subscription.Close()
+
+	if _, ok := <-subscription.Events(); ok {
+		t.Error("events channel is still open")
+	}
+	if len(hub.subscribers) != 0 {
+		t.Errorf("hub still has %d topics", len(hub.subscribers))
+	}
+
+	// Neither publishing to nor adding topics to a closed subscription may panic
+	hub.Publish(Event{Topic: PostTopic(1)})
+	subscription.Add(PostTopic(2))
+	if count := subscription.TopicCount(); count != 0 {
+		t.Errorf("TopicCount after Close = %d, want 0", count)
+	}
+}
+
+func TestConcurrentPublishAndClose(t *testing.T) {
+	hub := NewHub()
+
+	var wait sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wait.Add(2)
+		subscription := hub.Subscribe(PostTopic(1))
+
+		go func() {
+			defer wait.Done()
+			for j := 0; j < 100; j++ {
+				hub.Publish(Event{Topic: PostTopic(1)})
+			}
+		}()
+		go func() {
+			defer wait.Done()
+			subscription.Close()
+		}()
+	}
+	wait.Wait()
+}
+
+func TestPayload(t *testing.T) {
+	payload, err := Payload(PostTopic(1), EventCommentCreated, map[string]int{"id": 7})
+	if err != nil {
+		t.Fatalf("Payload: %v", err)
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatalf("payload is not an event: %v", err)
+	}
+	if event.Topic != PostTopic(1) || event.Type != EventCommentCreated || string(event.Data) != `{"id":7}` {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestPayloadDropsDataTooLargeForNotify(t *testing.T) {
+	payload, err := Payload(PostTopic(1), EventCommentCreated, strings.Repeat("a", maxPayloadSize))
+	if err != nil {
+		t.Fatalf("Payload: %v", err)
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatalf("payload is not an event: %v", err)
+	}
+	if string(event.Data) != "null" || event.Type != EventCommentCreated {
+		t.Errorf("event = %+v, want the type with null data", event)
+	}
+}
@@ -0,0 +1,102 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// The Postgres channel events are published on, see Publish
+const Channel = "events"
+
+// Largest NOTIFY payload Postgres accepts is 8000 bytes, keep some margin
+const maxPayloadSize = 7900
+
+// How long the listener waits for a notification before checking the connection
+const pingInterval = 90 * time.Second
+
+/*
+Forwards the events published on Channel to the hub until the context is
+cancelled
+
+The listener reconnects on its own when the connection drops, events
+published meanwhile are lost.
+
+Params:
+  - ctx:              The application context
+  - connectionString: The database to listen on
+  - hub:              The hub the events are published to
+
+Returns:
+  - An error if listening could not start
+*/
+func Listen(ctx context.Context, connectionString string, hub *Hub) error {
+	listener := pq.NewListener(connectionString, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("[FAIL]: event listener:", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(Channel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// Nil after a reconnection
+			if notification == nil {
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				log.Println("[FAIL]: could not decode event:", err)
+				continue
+			}
+			hub.Publish(event)
+		case <-time.After(pingInterval):
+			go func() {
+				if err := listener.Ping(); err != nil {
+					log.Println("[FAIL]: event listener ping:", err)
+				}
+			}()
+		}
+	}
+}
+
+/*
+Encodes an event as the payload of a NOTIFY on Channel
+
+Params:
+  - topic:     The topic of the event
+  - eventType: One of the Event* types
+  - data:      The event, encoded as JSON
+
+Returns:
+  - The payload, with null data when the event is too large to be sent
+  - An error if the event could not be encoded
+*/
+func Payload(topic string, eventType string, data interface{}) (string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(Event{Topic: topic, Type: eventType, Data: encoded})
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) > maxPayloadSize {
+		payload, err = json.Marshal(Event{Topic: topic, Type: eventType, Data: json.RawMessage("null")})
+	}
+
+	return string(payload), err
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/achievement"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/realtime"
+	"github.com/lib/pq"
+)
+
+// Computes a metric of achievement rules for the user $1
+var badgeMetricQueries = map[string]string{
+	achievement.MetricPosts:         `SELECT COUNT(*) FROM posts WHERE user_id = $1`,
+	achievement.MetricComments:      `SELECT COUNT(*) FROM comments WHERE user_id = $1`,
+	achievement.MetricLikesReceived: `SELECT COALESCE(SUM(like_count), 0) FROM posts WHERE user_id = $1`,
+	achievement.MetricEcoActions:    `SELECT COUNT(*) FROM eco_actions WHERE user_id = $1`,
+	achievement.MetricCO2eSavedKg:   `SELECT COALESCE(SUM(co2e_kg), 0) FROM eco_actions WHERE user_id = $1`,
+	// Consecutive days share the difference between the day and its position
+	achievement.MetricStreakDays: `
+		WITH days AS (
+			SELECT (created_at AT TIME ZONE 'UTC')::date AS day FROM posts WHERE user_id = $1
+			UNION
+			SELECT (created_at AT TIME ZONE 'UTC')::date FROM comments WHERE user_id = $1
+			UNION
+			SELECT (performed_at AT TIME ZONE 'UTC')::date FROM eco_actions WHERE user_id = $1
+		),
+		runs AS (
+			SELECT day - (ROW_NUMBER() OVER (ORDER BY day))::int AS run
+			FROM days
+		)
+		SELECT COALESCE(MAX(length), 0)
+		FROM (SELECT COUNT(*) AS length FROM runs GROUP BY run) AS lengths
+	`,
+}
+
+// Either the database or a transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+/*
+Awards the badges a user earned through an event
+
+Runs in the transaction of the action that caused the event, like notify.
+Connected clients of the user are told about new badges.
+
+Params:
+  - ctx:    The request context
+  - tx:     The transaction of the action
+  - userID: The user the event happened to, e.g. the author of a liked post
+  - event:  One of the achievement.Event* events
+
+Returns:
+  - An error if the rules could not be evaluated
+*/
+func awardBadges(ctx context.Context, tx *sql.Tx, userID string, event string) error {
+	awarded, err := evaluateBadges(ctx, tx, userID, achievement.RulesFor(event))
+	if err != nil {
+		return err
+	}
+
+	for _, badge := range awarded {
+		if err := publishEvent(ctx, tx, realtime.UserTopic(userID), realtime.EventBadgeAwarded, badge); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Awards the badges of the rules a user meets and doesn't have yet
+
+Params:
+  - ctx:    The request context
+  - db:     The database or transaction to evaluate the rules in
+  - userID: The user
+  - rules:  The rules to evaluate
+
+Returns:
+  - The newly awarded badges
+  - An error if a metric could not be computed or a badge saved
+*/
+func evaluateBadges(ctx context.Context, db queryer, userID string, rules []achievement.Rule) ([]model.Badge, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	badges := make([]string, len(rules))
+	for i, rule := range rules {
+		badges[i] = rule.Badge
+	}
+
+	// Only metrics of badges the user doesn't have yet are computed
+	rows, err := db.QueryContext(ctx, `SELECT badge FROM user_badges WHERE user_id = $1 AND badge = ANY($2)`, userID, pq.Array(badges))
+	if err != nil {
+		return nil, fmt.Errorf("could not query badges: %w", err)
+	}
+
+	owned := map[string]bool{}
+	for rows.Next() {
+		var badge string
+		if err := rows.Scan(&badge); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan badge: %w", err)
+		}
+		owned[badge] = true
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating badges: %w", err)
+	}
+
+	var pending []achievement.Rule
+	for _, rule := range rules {
+		if !owned[rule.Badge] {
+			pending = append(pending, rule)
+		}
+	}
+
+	values := map[string]float64{}
+	for _, metric := range achievement.MetricsOf(pending) {
+		query, ok := badgeMetricQueries[metric]
+		if !ok {
+			return nil, fmt.Errorf("unknown achievement metric %q", metric)
+		}
+
+		var value float64
+		if err := db.QueryRowContext(ctx, query, userID).Scan(&value); err != nil {
+			return nil, fmt.Errorf("could not compute %s: %w", metric, err)
+		}
+		values[metric] = value
+	}
+
+	insertQuery := `
+		INSERT INTO user_badges (user_id, badge, awarded_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, badge) DO NOTHING
+		RETURNING awarded_at
+	`
+
+	var awarded []model.Badge
+	for _, rule := range pending {
+		if !rule.Met(values) {
+			continue
+		}
+
+		badge := model.Badge{ID: rule.Badge, Name: rule.Name, Description: rule.Description}
+		err := db.QueryRowContext(ctx, insertQuery, userID, rule.Badge, time.Now()).Scan(&badge.AwardedAt)
+		if err == sql.ErrNoRows {
+			// Awarded meanwhile by a concurrent event
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not award badge: %w", err)
+		}
+
+		awarded = append(awarded, badge)
+	}
+
+	return awarded, nil
+}
+
+/*
+Evaluates every rule against the history of a user, awarding the badges they
+earned before the rules existed
+
+Params:
+  - ctx:    The application context
+  - userID: The user
+
+Returns:
+  - The newly awarded badges
+  - An error if the rules could not be evaluated
+*/
+func (repo *PostGreSQL) BackfillBadges(ctx context.Context, userID string) ([]model.Badge, error) {
+	return evaluateBadges(ctx, repo.Database, userID, achievement.Rules)
+}
+
+// Returns the badges of a user, earliest first
+func (repo *PostGreSQL) GetBadgesByUser(ctx context.Context, userID string) ([]model.Badge, error) {
+	query := `
+		SELECT badge, awarded_at
+		FROM user_badges
+		WHERE user_id = $1
+		ORDER BY awarded_at, badge
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []model.Badge{}
+	for rows.Next() {
+		var badge model.Badge
+		if err := rows.Scan(&badge.ID, &badge.AwardedAt); err != nil {
+			return nil, fmt.Errorf("could not scan badge: %w", err)
+		}
+
+		// Badges of retired rules are kept under their ID
+		badge.Name = badge.ID
+		if rule, ok := achievement.Find(badge.ID); ok {
+			badge.Name = rule.Name
+			badge.Description = rule.Description
+		}
+
+		badges = append(badges, badge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating badges: %w", err)
+	}
+
+	return badges, nil
+}
+
+/*
+Returns the IDs of the users not awaiting deletion, in ID order
+
+Params:
+  - ctx:     The application context
+  - afterID: The last ID of the previous batch, empty for the first one
+  - limit:   Number of IDs to return
+
+Returns:
+  - The IDs
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetUserIDs(ctx context.Context, afterID string, limit int) ([]string, error) {
+	var after sql.NullString
+	if afterID != "" {
+		after = sql.NullString{String: afterID, Valid: true}
+	}
+
+	query := `
+		SELECT id
+		FROM users
+		WHERE deleted_at IS NULL AND ($1::uuid IS NULL OR id > $1)
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("could not scan user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,430 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+)
+
+// Columns of a challenge, its participants awaiting deletion aren't counted
+const challengeColumns = `
+	c.id, c.title, c.description, c.starts_at, c.ends_at, c.goal_type, c.action_type, c.tag, c.goal_quantity,
+	COALESCE(t.unit, 'post'), c.created_by, c.created_at,
+	(
+		SELECT COUNT(*)
+		FROM challenge_participants cp
+		JOIN users u ON u.id = cp.user_id
+		WHERE cp.challenge_id = c.id AND u.deleted_at IS NULL
+	)
+`
+
+const challengeFrom = `
+	FROM challenges c
+	LEFT JOIN eco_action_types t ON t.id = c.action_type
+`
+
+// Progress of the participant cp in the challenge c, see model.Challenge
+const challengeProgress = `
+	CASE c.goal_type
+	WHEN 'eco_action' THEN (
+		SELECT COALESCE(SUM(ea.quantity), 0)
+		FROM eco_actions ea
+		WHERE ea.user_id = cp.user_id
+		AND ea.action_type = c.action_type
+		AND ea.performed_at >= c.starts_at AND ea.performed_at < c.ends_at
+	)
+	ELSE (
+		SELECT COUNT(*)
+		FROM posts p
+		JOIN post_tags pt ON pt.post_id = p.id
+		JOIN tags tg ON tg.id = pt.tag_id
+		WHERE p.user_id = cp.user_id
+		AND tg.name = c.tag
+		AND p.created_at >= c.starts_at AND p.created_at < c.ends_at
+	)
+	END
+`
+
+/*
+Creates a challenge
+
+Params:
+  - ctx:       The request context
+  - challenge: The challenge, its ID, unit, creation time and counts are ignored
+
+Returns:
+  - The created challenge
+  - An error if its eco-action type doesn't exist
+*/
+func (repo *PostGreSQL) CreateChallenge(ctx context.Context, challenge model.Challenge) (model.Challenge, error) {
+	if challenge.ActionType != nil {
+		var exists bool
+		typeQuery := `SELECT EXISTS (SELECT 1 FROM eco_action_types WHERE id = $1)`
+		if err := repo.Database.QueryRowContext(ctx, typeQuery, *challenge.ActionType).Scan(&exists); err != nil {
+			return model.Challenge{}, fmt.Errorf("could not get eco action type: %w", err)
+		}
+		if !exists {
+			return model.Challenge{}, fmt.Errorf("eco action type not found")
+		}
+	}
+
+	query := `
+		INSERT INTO challenges (title, description, starts_at, ends_at, goal_type, action_type, tag, goal_quantity, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	var challengeID int
+	err := repo.Database.QueryRowContext(ctx, query,
+		challenge.Title,
+		challenge.Description,
+		challenge.StartsAt,
+		challenge.EndsAt,
+		challenge.GoalType,
+		challenge.ActionType,
+		challenge.Tag,
+		challenge.GoalQuantity,
+		challenge.CreatedBy,
+		time.Now(),
+	).Scan(&challengeID)
+	if err != nil {
+		return model.Challenge{}, fmt.Errorf("could not create challenge: %w", err)
+	}
+
+	return repo.GetChallengeByID(ctx, challengeID)
+}
+
+func (repo *PostGreSQL) DeleteChallenge(ctx context.Context, challengeID int) error {
+	result, err := repo.Database.ExecContext(ctx, `DELETE FROM challenges WHERE id = $1`, challengeID)
+	if err != nil {
+		return fmt.Errorf("could not delete challenge: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("challenge not found")
+	}
+
+	return nil
+}
+
+/*
+Returns the challenges, latest starting first
+
+Params:
+  - ctx:    The request context
+  - status: One of the model.ChallengeStatus* statuses, empty for every challenge
+  - now:    The time the status is evaluated at
+  - page:   The requested page, one extra challenge is fetched to know whether there is a next one
+
+Returns:
+  - The challenges ordered by (starts_at, id) descending
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetChallenges(ctx context.Context, status string, now time.Time, page pagination.Page) ([]model.Challenge, error) {
+	query := `
+		SELECT ` + challengeColumns + challengeFrom + `
+		WHERE (
+			$1::text = ''
+			OR ($1 = 'upcoming' AND c.starts_at > $2)
+			OR ($1 = 'active' AND c.starts_at <= $2 AND c.ends_at > $2)
+			OR ($1 = 'ended' AND c.ends_at <= $2)
+		)
+		AND ($3::timestamptz IS NULL OR (c.starts_at, c.id) < ($3, $4))
+		ORDER BY c.starts_at DESC, c.id DESC
+		LIMIT $5
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, status, now, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query challenges: %w", err)
+	}
+	defer rows.Close()
+
+	var challenges []model.Challenge
+	for rows.Next() {
+		challenge, err := scanChallenge(rows)
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, challenge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating challenges: %w", err)
+	}
+
+	return challenges, nil
+}
+
+// Returns a challenge with the completion stats of its participants
+func (repo *PostGreSQL) GetChallengeByID(ctx context.Context, challengeID int) (model.Challenge, error) {
+	query := `SELECT ` + challengeColumns + challengeFrom + ` WHERE c.id = $1`
+
+	challenge, err := scanChallenge(repo.Database.QueryRowContext(ctx, query, challengeID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.Challenge{}, fmt.Errorf("challenge not found")
+		}
+		return model.Challenge{}, err
+	}
+
+	statsQuery := `
+		SELECT COUNT(*) FILTER (WHERE progress >= goal_quantity)
+		FROM (
+			SELECT ` + challengeProgress + ` AS progress, c.goal_quantity
+			FROM challenge_participants cp
+			JOIN challenges c ON c.id = cp.challenge_id
+			JOIN users u ON u.id = cp.user_id
+			WHERE cp.challenge_id = $1 AND u.deleted_at IS NULL
+		) AS participants
+	`
+
+	var stats model.ChallengeStats
+	if err := repo.Database.QueryRowContext(ctx, statsQuery, challengeID).Scan(&stats.CompletedCount); err != nil {
+		return model.Challenge{}, fmt.Errorf("could not get challenge stats: %w", err)
+	}
+
+	if challenge.ParticipantCount > 0 {
+		stats.CompletionRate = float64(stats.CompletedCount) / float64(challenge.ParticipantCount)
+	}
+	challenge.Stats = &stats
+
+	return challenge, nil
+}
+
+/*
+Adds a user to the participants of a challenge
+
+Returns:
+  - An error if the challenge doesn't exist, has ended or the user already joined it
+*/
+func (repo *PostGreSQL) JoinChallenge(ctx context.Context, challengeID int, userID string) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	// Keep the challenge from being deleted while joining
+	var endsAt time.Time
+	err = tx.QueryRowContext(ctx, `SELECT ends_at FROM challenges WHERE id = $1 FOR SHARE`, challengeID).Scan(&endsAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("challenge not found")
+		}
+		return fmt.Errorf("could not get challenge: %w", err)
+	}
+
+	if !time.Now().Before(endsAt) {
+		return fmt.Errorf("challenge has ended")
+	}
+
+	query := `
+		INSERT INTO challenge_participants (challenge_id, user_id, joined_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (challenge_id, user_id) DO NOTHING
+	`
+
+	result, err := tx.ExecContext(ctx, query, challengeID, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not join challenge: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user already joined this challenge")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) LeaveChallenge(ctx context.Context, challengeID int, userID string) error {
+	query := `
+		DELETE FROM challenge_participants
+		WHERE challenge_id = $1 AND user_id = $2
+	`
+
+	result, err := repo.Database.ExecContext(ctx, query, challengeID, userID)
+	if err != nil {
+		return fmt.Errorf("could not leave challenge: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("participant not found")
+	}
+
+	return nil
+}
+
+/*
+Returns the participants of a challenge with their progress, latest to join first
+
+Params:
+  - ctx:         The request context
+  - challengeID: The challenge
+  - page:        The requested page, one extra participant is fetched to know whether there is a next one
+
+Returns:
+  - The progress of the participants ordered by (joined_at, user_id) descending
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetChallengeParticipants(ctx context.Context, challengeID int, page pagination.Page) ([]model.ChallengeProgress, error) {
+	query := `
+		SELECT cp.challenge_id, cp.user_id, u.username, cp.joined_at, ` + challengeProgress + `, c.goal_quantity
+		FROM challenge_participants cp
+		JOIN challenges c ON c.id = cp.challenge_id
+		JOIN users u ON u.id = cp.user_id
+		WHERE cp.challenge_id = $1 AND u.deleted_at IS NULL
+		AND ($2::timestamptz IS NULL OR (cp.joined_at, cp.user_id) < ($2, $3))
+		ORDER BY cp.joined_at DESC, cp.user_id DESC
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := uuidCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, challengeID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query challenge participants: %w", err)
+	}
+	defer rows.Close()
+
+	var participants []model.ChallengeProgress
+	for rows.Next() {
+		participant, err := scanChallengeProgress(rows)
+		if err != nil {
+			return nil, err
+		}
+		participants = append(participants, participant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating challenge participants: %w", err)
+	}
+
+	return participants, nil
+}
+
+// Returns the progress of a participant of a challenge
+func (repo *PostGreSQL) GetChallengeProgress(ctx context.Context, challengeID int, userID string) (model.ChallengeProgress, error) {
+	query := `
+		SELECT cp.challenge_id, cp.user_id, u.username, cp.joined_at, ` + challengeProgress + `, c.goal_quantity
+		FROM challenge_participants cp
+		JOIN challenges c ON c.id = cp.challenge_id
+		JOIN users u ON u.id = cp.user_id
+		WHERE cp.challenge_id = $1 AND cp.user_id = $2 AND u.deleted_at IS NULL
+	`
+
+	participant, err := scanChallengeProgress(repo.Database.QueryRowContext(ctx, query, challengeID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.ChallengeProgress{}, fmt.Errorf("participant not found")
+		}
+		return model.ChallengeProgress{}, err
+	}
+
+	return participant, nil
+}
+
+// A row of a query, rows are scanned one at a time
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scans the challengeColumns
+func scanChallenge(row rowScanner) (model.Challenge, error) {
+	var challenge model.Challenge
+	var actionType, tag, createdBy sql.NullString
+
+	err := row.Scan(
+		&challenge.ID,
+		&challenge.Title,
+		&challenge.Description,
+		&challenge.StartsAt,
+		&challenge.EndsAt,
+		&challenge.GoalType,
+		&actionType,
+		&tag,
+		&challenge.GoalQuantity,
+		&challenge.Unit,
+		&createdBy,
+		&challenge.CreatedAt,
+		&challenge.ParticipantCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.Challenge{}, err
+		}
+		return model.Challenge{}, fmt.Errorf("could not scan challenge: %w", err)
+	}
+
+	if actionType.Valid {
+		challenge.ActionType = &actionType.String
+	}
+	if tag.Valid {
+		challenge.Tag = &tag.String
+	}
+	if createdBy.Valid {
+		challenge.CreatedBy = &createdBy.String
+	}
+
+	return challenge, nil
+}
+
+// Scans challenge_id, user_id, username, joined_at, progress, goal_quantity
+func scanChallengeProgress(row rowScanner) (model.ChallengeProgress, error) {
+	var participant model.ChallengeProgress
+
+	err := row.Scan(
+		&participant.ChallengeID,
+		&participant.UserID,
+		&participant.Username,
+		&participant.JoinedAt,
+		&participant.Progress,
+		&participant.Goal,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.ChallengeProgress{}, err
+		}
+		return model.ChallengeProgress{}, fmt.Errorf("could not scan challenge participant: %w", err)
+	}
+
+	participant.Completed = participant.Progress >= participant.Goal
+
+	return participant, nil
+}
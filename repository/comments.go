@@ -7,11 +7,20 @@ import (
 	"log"
 	"time"
 
+	"github.com/ecofriends/authentication-backend/achievement"
 	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
 	_ "github.com/lib/pq"
 )
 
-func (repo *PostGreSQL) CreateComment(ctx context.Context, userID string, postID int, text string) (model.Comment, error) {
+/*
+Creates a comment on a post, or a reply when a parent comment is given
+
+Returns:
+  - The created comment
+  - An error if the parent doesn't exist, is on another post or is nested too deeply
+*/
+func (repo *PostGreSQL) CreateComment(ctx context.Context, userID string, postID int, parentID *int, text string) (model.Comment, error) {
 	tx, err := repo.Database.BeginTx(ctx, nil)
 	if err != nil {
 		return model.Comment{}, fmt.Errorf("could not begin transaction: %w", err)
@@ -23,9 +32,49 @@ func (repo *PostGreSQL) CreateComment(ctx context.Context, userID string, postID
 		}
 	}()
 
+	var postAuthorID string
+	err = tx.QueryRowContext(ctx, `SELECT user_id FROM posts WHERE id = $1`, postID).Scan(&postAuthorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.Comment{}, fmt.Errorf("post not found")
+		}
+		return model.Comment{}, fmt.Errorf("could not get post: %w", err)
+	}
+
+	depth := 0
+	var parentAuthorID string
+	if parentID != nil {
+		// Lock the parent so its reply count stays accurate
+		parentQuery := `
+			SELECT user_id, post_id, depth
+			FROM comments
+			WHERE id = $1
+			FOR UPDATE
+		`
+
+		var parentPostID, parentDepth int
+		err = tx.QueryRowContext(ctx, parentQuery, *parentID).Scan(&parentAuthorID, &parentPostID, &parentDepth)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return model.Comment{}, fmt.Errorf("parent comment not found")
+			}
+			return model.Comment{}, fmt.Errorf("could not get parent comment: %w", err)
+		}
+
+		if parentPostID != postID {
+			return model.Comment{}, fmt.Errorf("parent comment belongs to another post")
+		}
+
+		if parentDepth >= model.MaxCommentDepth {
+			return model.Comment{}, fmt.Errorf("maximum reply depth of %d reached", model.MaxCommentDepth)
+		}
+
+		depth = parentDepth + 1
+	}
+
 	query := `
-		INSERT INTO comments (user_id, post_id, text, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO comments (user_id, post_id, parent_id, depth, text, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at
 	`
 
@@ -33,6 +82,8 @@ func (repo *PostGreSQL) CreateComment(ctx context.Context, userID string, postID
 	err = tx.QueryRowContext(ctx, query,
 		userID,
 		postID,
+		parentID,
+		depth,
 		text,
 		time.Now(),
 	).Scan(
@@ -44,14 +95,49 @@ func (repo *PostGreSQL) CreateComment(ctx context.Context, userID string, postID
 		return model.Comment{}, fmt.Errorf("could not create comment: %w", err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return model.Comment{}, fmt.Errorf("could not commit transaction: %w", err)
+	if parentID != nil {
+		updateQuery := `
+			UPDATE comments
+			SET reply_count = reply_count + 1
+			WHERE id = $1
+		`
+		_, err = tx.ExecContext(ctx, updateQuery, *parentID)
+		if err != nil {
+			return model.Comment{}, fmt.Errorf("could not update reply count: %w", err)
+		}
+
+		replyEvent := notificationEvent{userID: parentAuthorID, actorID: userID, kind: model.NotificationReply, postID: &postID, commentID: parentID}
+		if err = notify(ctx, tx, replyEvent); err != nil {
+			return model.Comment{}, err
+		}
+	}
+
+	// An author replied to is already told about the reply
+	if parentID == nil || postAuthorID != parentAuthorID {
+		commentEvent := notificationEvent{userID: postAuthorID, actorID: userID, kind: model.NotificationComment, postID: &postID}
+		if err = notify(ctx, tx, commentEvent); err != nil {
+			return model.Comment{}, err
+		}
 	}
 
 	createdComment.UserID = userID
 	createdComment.PostID = postID
+	createdComment.ParentID = parentID
+	createdComment.Depth = depth
 	createdComment.Text = text
 
+	if err = awardBadges(ctx, tx, userID, achievement.EventCommentCreated); err != nil {
+		return model.Comment{}, err
+	}
+
+	if err = publishCommentCreated(ctx, tx, createdComment); err != nil {
+		return model.Comment{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.Comment{}, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
 	return createdComment, nil
 }
 
@@ -67,23 +153,32 @@ func (repo *PostGreSQL) DeleteComment(ctx context.Context, commentID int, userID
 		}
 	}()
 
+	// Replies to the comment are removed with it through their foreign key
 	query := `
 		DELETE FROM comments
 		WHERE id = $1 AND user_id = $2
+		RETURNING parent_id
 	`
 
-	result, err := tx.ExecContext(ctx, query, commentID, userID)
+	var parentID sql.NullInt64
+	err = tx.QueryRowContext(ctx, query, commentID, userID).Scan(&parentID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("comment not found or not owned by user")
+		}
 		return fmt.Errorf("could not delete comment: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("could not get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("comment not found or not owned by user")
+	if parentID.Valid {
+		updateQuery := `
+			UPDATE comments
+			SET reply_count = reply_count - 1
+			WHERE id = $1
+		`
+		_, err = tx.ExecContext(ctx, updateQuery, parentID.Int64)
+		if err != nil {
+			return fmt.Errorf("could not update reply count: %w", err)
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -93,69 +188,85 @@ func (repo *PostGreSQL) DeleteComment(ctx context.Context, commentID int, userID
 	return nil
 }
 
-func (repo *PostGreSQL) GetCommentsByPost(ctx context.Context, postID int, limit int, offset int) ([]model.CommentWithUser, error) {
+func (repo *PostGreSQL) GetCommentsByPost(ctx context.Context, postID int, page pagination.Page) ([]model.CommentWithUser, error) {
 	query := `
-		SELECT c.id, c.user_id, c.post_id, c.text, c.created_at, c.updated_at, u.username
+		SELECT c.id, c.user_id, c.post_id, c.parent_id, c.depth, c.reply_count, c.text, c.created_at, c.updated_at, u.username
 		FROM comments c
-		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = $1
-		ORDER BY c.created_at DESC
-		LIMIT $2 OFFSET $3
+		JOIN users u ON u.id = c.user_id AND u.deleted_at IS NULL
+		WHERE c.post_id = $1 AND c.parent_id IS NULL
+		AND ($2::timestamptz IS NULL OR (c.created_at, c.id) < ($2, $3))
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT $4
 	`
 
-	rows, err := repo.Database.QueryContext(ctx, query, postID, limit, offset)
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, postID, afterTime, afterID, page.FetchLimit())
 	if err != nil {
 		return nil, fmt.Errorf("could not query comments: %w", err)
 	}
-	defer rows.Close()
 
-	var comments []model.CommentWithUser
-	for rows.Next() {
-		var comment model.CommentWithUser
-		var updatedAt sql.NullTime
+	return scanCommentsWithUser(rows)
+}
 
-		err := rows.Scan(
-			&comment.ID,
-			&comment.UserID,
-			&comment.PostID,
-			&comment.Text,
-			&comment.CreatedAt,
-			&updatedAt,
-			&comment.Username,
-		)
-		if err != nil {
-			log.Printf("Error scanning comment row: %v", err)
-			continue
-		}
+/*
+Returns the direct replies to a comment, oldest first so a thread reads in
+order
 
-		if updatedAt.Valid {
-			comment.UpdatedAt = &updatedAt.Time
-		}
+Params:
+  - ctx:       The request context
+  - commentID: The comment the replies answer
+  - page:      The requested page, one extra reply is fetched to know whether there is a next one
 
-		comments = append(comments, comment)
+Returns:
+  - The replies ordered by (created_at, id) ascending
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetReplies(ctx context.Context, commentID int, page pagination.Page) ([]model.CommentWithUser, error) {
+	query := `
+		SELECT c.id, c.user_id, c.post_id, c.parent_id, c.depth, c.reply_count, c.text, c.created_at, c.updated_at, u.username
+		FROM comments c
+		JOIN users u ON u.id = c.user_id AND u.deleted_at IS NULL
+		WHERE c.parent_id = $1
+		AND ($2::timestamptz IS NULL OR (c.created_at, c.id) > ($2, $3))
+		ORDER BY c.created_at, c.id
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating comments: %w", err)
+	rows, err := repo.Database.QueryContext(ctx, query, commentID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query replies: %w", err)
 	}
 
-	return comments, nil
+	return scanCommentsWithUser(rows)
 }
 
 func (repo *PostGreSQL) GetCommentByID(ctx context.Context, commentID int) (model.Comment, error) {
 	query := `
-		SELECT id, user_id, post_id, text, created_at, updated_at
+		SELECT id, user_id, post_id, parent_id, depth, reply_count, text, created_at, updated_at
 		FROM comments
 		WHERE id = $1
 	`
 
 	var comment model.Comment
+	var parentID sql.NullInt64
 	var updatedAt sql.NullTime
 
 	err := repo.Database.QueryRowContext(ctx, query, commentID).Scan(
 		&comment.ID,
 		&comment.UserID,
 		&comment.PostID,
+		&parentID,
+		&comment.Depth,
+		&comment.ReplyCount,
 		&comment.Text,
 		&comment.CreatedAt,
 		&updatedAt,
@@ -168,6 +279,11 @@ func (repo *PostGreSQL) GetCommentByID(ctx context.Context, commentID int) (mode
 		return model.Comment{}, fmt.Errorf("could not get comment: %w", err)
 	}
 
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		comment.ParentID = &id
+	}
+
 	if updatedAt.Valid {
 		comment.UpdatedAt = &updatedAt.Time
 	}
@@ -187,13 +303,36 @@ func (repo *PostGreSQL) UpdateComment(ctx context.Context, commentID int, userID
 		}
 	}()
 
+	// Keep the previous version as a revision, the row lock orders concurrent edits
+	revisionQuery := `
+		INSERT INTO comment_revisions (comment_id, text, written_at)
+		SELECT id, text, COALESCE(updated_at, created_at)
+		FROM comments
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`
+
+	result, err := tx.ExecContext(ctx, revisionQuery, commentID, userID)
+	if err != nil {
+		return fmt.Errorf("could not save comment revision: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment not found or not owned by user")
+	}
+
 	query := `
 		UPDATE comments
 		SET text = $1, updated_at = $2
 		WHERE id = $3 AND user_id = $4
 	`
 
-	result, err := tx.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		text,
 		time.Now(),
 		commentID,
@@ -204,18 +343,117 @@ func (repo *PostGreSQL) UpdateComment(ctx context.Context, commentID int, userID
 		return fmt.Errorf("could not update comment: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) GetCommentsByUser(ctx context.Context, userID string, page pagination.Page) ([]model.Comment, error) {
+	query := `
+		SELECT id, user_id, post_id, parent_id, depth, reply_count, text, created_at, updated_at
+		FROM comments
+		WHERE user_id = $1
+		AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
 	if err != nil {
-		return fmt.Errorf("could not get rows affected: %w", err)
+		return nil, err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("comment not found or not owned by user")
+	rows, err := repo.Database.QueryContext(ctx, query, userID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query user comments: %w", err)
 	}
+	defer rows.Close()
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("could not commit transaction: %w", err)
+	var comments []model.Comment
+	for rows.Next() {
+		var comment model.Comment
+		var parentID sql.NullInt64
+		var updatedAt sql.NullTime
+
+		err := rows.Scan(
+			&comment.ID,
+			&comment.UserID,
+			&comment.PostID,
+			&parentID,
+			&comment.Depth,
+			&comment.ReplyCount,
+			&comment.Text,
+			&comment.CreatedAt,
+			&updatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning user comment row: %v", err)
+			continue
+		}
+
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			comment.ParentID = &id
+		}
+
+		if updatedAt.Valid {
+			comment.UpdatedAt = &updatedAt.Time
+		}
+
+		comments = append(comments, comment)
 	}
 
-	return nil
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// Scans and closes rows of id, user_id, post_id, parent_id, depth, reply_count, text, created_at, updated_at, username
+func scanCommentsWithUser(rows *sql.Rows) ([]model.CommentWithUser, error) {
+	defer rows.Close()
+
+	var comments []model.CommentWithUser
+	for rows.Next() {
+		var comment model.CommentWithUser
+		var parentID sql.NullInt64
+		var updatedAt sql.NullTime
+
+		err := rows.Scan(
+			&comment.ID,
+			&comment.UserID,
+			&comment.PostID,
+			&parentID,
+			&comment.Depth,
+			&comment.ReplyCount,
+			&comment.Text,
+			&comment.CreatedAt,
+			&updatedAt,
+			&comment.Username,
+		)
+		if err != nil {
+			log.Printf("Error scanning comment row: %v", err)
+			continue
+		}
+
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			comment.ParentID = &id
+		}
+
+		if updatedAt.Valid {
+			comment.UpdatedAt = &updatedAt.Time
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comments: %w", err)
+	}
+
+	return comments, nil
 }
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/achievement"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+)
+
+// Returns the catalog of eco-action types, by name
+func (repo *PostGreSQL) GetEcoActionTypes(ctx context.Context) ([]model.EcoActionType, error) {
+	query := `
+		SELECT id, name, unit, co2e_kg_per_unit, water_litres_per_unit, waste_kg_per_unit, max_quantity
+		FROM eco_action_types
+		ORDER BY name
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("could not query eco action types: %w", err)
+	}
+	defer rows.Close()
+
+	var actionTypes []model.EcoActionType
+	for rows.Next() {
+		var actionType model.EcoActionType
+		err := rows.Scan(
+			&actionType.ID,
+			&actionType.Name,
+			&actionType.Unit,
+			&actionType.CO2eKgPerUnit,
+			&actionType.WaterLitresPerUnit,
+			&actionType.WasteKgPerUnit,
+			&actionType.MaxQuantity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan eco action type: %w", err)
+		}
+		actionTypes = append(actionTypes, actionType)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating eco action types: %w", err)
+	}
+
+	return actionTypes, nil
+}
+
+/*
+Logs an eco-action, estimating its savings with the current factors of its type
+
+Params:
+  - ctx:         The request context
+  - userID:      The user who performed the action
+  - actionType:  The ID of the eco-action type
+  - quantity:    How much was done, in the unit of the type
+  - postID:      A post of the user to attach the action to (nullable)
+  - performedAt: When the action was performed
+
+Returns:
+  - The logged action
+  - An error if the type or post doesn't exist, or the quantity is too large
+*/
+func (repo *PostGreSQL) LogEcoAction(ctx context.Context, userID string, actionType string, quantity float64, postID *int, performedAt time.Time) (model.EcoAction, error) {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return model.EcoAction{}, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	var theType model.EcoActionType
+	typeQuery := `
+		SELECT unit, co2e_kg_per_unit, water_litres_per_unit, waste_kg_per_unit, max_quantity
+		FROM eco_action_types
+		WHERE id = $1
+	`
+	err = tx.QueryRowContext(ctx, typeQuery, actionType).Scan(
+		&theType.Unit,
+		&theType.CO2eKgPerUnit,
+		&theType.WaterLitresPerUnit,
+		&theType.WasteKgPerUnit,
+		&theType.MaxQuantity,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.EcoAction{}, fmt.Errorf("eco action type not found")
+		}
+		return model.EcoAction{}, fmt.Errorf("could not get eco action type: %w", err)
+	}
+
+	if quantity > theType.MaxQuantity {
+		return model.EcoAction{}, fmt.Errorf("quantity exceeds the maximum of %g %s", theType.MaxQuantity, theType.Unit)
+	}
+
+	if postID != nil {
+		var exists bool
+		postQuery := `SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1 AND user_id = $2)`
+		if err = tx.QueryRowContext(ctx, postQuery, *postID, userID).Scan(&exists); err != nil {
+			return model.EcoAction{}, fmt.Errorf("could not get post: %w", err)
+		}
+		if !exists {
+			return model.EcoAction{}, fmt.Errorf("post not found or not owned by user")
+		}
+	}
+
+	action := model.EcoAction{
+		UserID:     userID,
+		ActionType: actionType,
+		Quantity:   quantity,
+		Savings: model.EcoSavings{
+			CO2eKg:      quantity * theType.CO2eKgPerUnit,
+			WaterLitres: quantity * theType.WaterLitresPerUnit,
+			WasteKg:     quantity * theType.WasteKgPerUnit,
+		},
+		PostID:      postID,
+		PerformedAt: performedAt,
+	}
+
+	insertQuery := `
+		INSERT INTO eco_actions (user_id, action_type, quantity, co2e_kg, water_litres, waste_kg, post_id, performed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRowContext(ctx, insertQuery,
+		userID,
+		actionType,
+		quantity,
+		action.Savings.CO2eKg,
+		action.Savings.WaterLitres,
+		action.Savings.WasteKg,
+		postID,
+		performedAt,
+		time.Now(),
+	).Scan(&action.ID, &action.CreatedAt)
+	if err != nil {
+		return model.EcoAction{}, fmt.Errorf("could not log eco action: %w", err)
+	}
+
+	if err = awardBadges(ctx, tx, userID, achievement.EventEcoActionLogged); err != nil {
+		return model.EcoAction{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.EcoAction{}, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return action, nil
+}
+
+func (repo *PostGreSQL) DeleteEcoAction(ctx context.Context, actionID int, userID string) error {
+	query := `
+		DELETE FROM eco_actions
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := repo.Database.ExecContext(ctx, query, actionID, userID)
+	if err != nil {
+		return fmt.Errorf("could not delete eco action: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("eco action not found or not owned by user")
+	}
+
+	return nil
+}
+
+/*
+Returns the eco-actions of a user, most recently performed first
+
+Params:
+  - ctx:    The request context
+  - userID: The user
+  - page:   The requested page, one extra action is fetched to know whether there is a next one
+
+Returns:
+  - The actions ordered by (performed_at, id) descending
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetEcoActionsByUser(ctx context.Context, userID string, page pagination.Page) ([]model.EcoAction, error) {
+	query := `
+		SELECT id, user_id, action_type, quantity, co2e_kg, water_litres, waste_kg, post_id, performed_at, created_at
+		FROM eco_actions
+		WHERE user_id = $1
+		AND ($2::timestamptz IS NULL OR (performed_at, id) < ($2, $3))
+		ORDER BY performed_at DESC, id DESC
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query eco actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []model.EcoAction
+	for rows.Next() {
+		var action model.EcoAction
+		var postID sql.NullInt64
+
+		err := rows.Scan(
+			&action.ID,
+			&action.UserID,
+			&action.ActionType,
+			&action.Quantity,
+			&action.Savings.CO2eKg,
+			&action.Savings.WaterLitres,
+			&action.Savings.WasteKg,
+			&postID,
+			&action.PerformedAt,
+			&action.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan eco action: %w", err)
+		}
+
+		if postID.Valid {
+			id := int(postID.Int64)
+			action.PostID = &id
+		}
+
+		actions = append(actions, action)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating eco actions: %w", err)
+	}
+
+	return actions, nil
+}
+
+/*
+Totals the savings of a user's eco-actions over several periods at once
+
+Params:
+  - ctx:    The request context
+  - userID: The user
+  - now:    The current time, in the location the periods are measured in
+
+Returns:
+  - The totals of every model.EcoPeriods period, in that order
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetEcoTotals(ctx context.Context, userID string, now time.Time) ([]model.EcoTotals, error) {
+	totals := make([]model.EcoTotals, len(model.EcoPeriods))
+	args := []interface{}{userID}
+	columns := make([]string, 0, len(model.EcoPeriods))
+
+	for i, period := range model.EcoPeriods {
+		since, err := model.EcoPeriodStart(period, now)
+		if err != nil {
+			return nil, err
+		}
+		totals[i] = model.EcoTotals{Period: period, Since: since}
+
+		var sinceArg sql.NullTime
+		if since != nil {
+			sinceArg = sql.NullTime{Time: *since, Valid: true}
+		}
+		args = append(args, sinceArg)
+
+		filter := fmt.Sprintf("FILTER (WHERE $%d::timestamptz IS NULL OR performed_at >= $%d)", len(args), len(args))
+		columns = append(columns, fmt.Sprintf(
+			"COUNT(*) %[1]s, COALESCE(SUM(co2e_kg) %[1]s, 0), COALESCE(SUM(water_litres) %[1]s, 0), COALESCE(SUM(waste_kg) %[1]s, 0)",
+			filter,
+		))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM eco_actions
+		WHERE user_id = $1
+	`, strings.Join(columns, ",\n\t\t\t"))
+
+	destinations := make([]interface{}, 0, len(totals)*4)
+	for i := range totals {
+		destinations = append(destinations,
+			&totals[i].ActionCount,
+			&totals[i].Savings.CO2eKg,
+			&totals[i].Savings.WaterLitres,
+			&totals[i].Savings.WasteKg,
+		)
+	}
+
+	if err := repo.Database.QueryRowContext(ctx, query, args...).Scan(destinations...); err != nil {
+		return nil, fmt.Errorf("could not total eco actions: %w", err)
+	}
+
+	return totals, nil
+}
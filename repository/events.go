@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/realtime"
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+/*
+Publishes a real-time event to every server instance
+
+Runs in the transaction of the action that caused the event, Postgres only
+delivers the notification once the transaction commits.
+
+Params:
+  - ctx:       The request context
+  - tx:        The transaction of the action
+  - topic:     The topic of the event, see realtime.UserTopic and realtime.PostTopic
+  - eventType: One of the realtime.Event* types
+  - data:      The event, encoded as JSON
+
+Returns:
+  - An error if the event could not be published
+*/
+func publishEvent(ctx context.Context, tx *sql.Tx, topic string, eventType string, data interface{}) error {
+	payload, err := realtime.Payload(topic, eventType, data)
+	if err != nil {
+		return fmt.Errorf("could not encode event: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, realtime.Channel, payload); err != nil {
+		return fmt.Errorf("could not publish event: %w", err)
+	}
+
+	return nil
+}
+
+// Publishes a new comment to the clients watching its post
+func publishCommentCreated(ctx context.Context, tx *sql.Tx, comment model.Comment) error {
+	commentWithUser := model.CommentWithUser{Comment: comment}
+
+	err := tx.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1`, comment.UserID).Scan(&commentWithUser.Username)
+	if err != nil {
+		return fmt.Errorf("could not get comment author: %w", err)
+	}
+
+	return publishEvent(ctx, tx, realtime.PostTopic(comment.PostID), realtime.EventCommentCreated, util.NewCommentWithUserPayload(commentWithUser))
+}
+
+/*
+reactionsChangedEvent struct, the new reaction counts of a post or comment
+
+Fields:
+  - TargetType: string         - model.ReactionTargetPost or model.ReactionTargetComment
+  - TargetID:   int            - The post or comment
+  - PostID:     int            - The post, or the post of the comment
+  - Counts:     map[string]int - Number of reactions of every type
+  - LikeCount:  *int           - The like count of the post, only set for posts
+*/
+type reactionsChangedEvent struct {
+	TargetType string         `json:"target_type"`
+	TargetID   int            `json:"target_id"`
+	PostID     int            `json:"post_id"`
+	Counts     map[string]int `json:"counts"`
+	LikeCount  *int           `json:"like_count,omitempty"`
+}
+
+/*
+Publishes the reaction counts of a post or comment to the clients watching the post
+
+Params:
+  - ctx:        The request context
+  - tx:         The transaction that changed the reactions
+  - targetType: model.ReactionTargetPost or model.ReactionTargetComment
+  - targetID:   The post or comment
+
+Returns:
+  - An error if the counts could not be read or published
+*/
+func publishReactionsChanged(ctx context.Context, tx *sql.Tx, targetType string, targetID int) error {
+	table, err := reactionTableOf(targetType)
+	if err != nil {
+		return err
+	}
+
+	event := reactionsChangedEvent{
+		TargetType: targetType,
+		TargetID:   targetID,
+		PostID:     targetID,
+		Counts:     model.NewReactionSummary().Counts,
+	}
+
+	if targetType == model.ReactionTargetPost {
+		var likeCount int
+		if err := tx.QueryRowContext(ctx, `SELECT like_count FROM posts WHERE id = $1`, targetID).Scan(&likeCount); err != nil {
+			return fmt.Errorf("could not get post like count: %w", err)
+		}
+		event.LikeCount = &likeCount
+	} else {
+		if err := tx.QueryRowContext(ctx, `SELECT post_id FROM comments WHERE id = $1`, targetID).Scan(&event.PostID); err != nil {
+			return fmt.Errorf("could not get comment post: %w", err)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT type, COUNT(*)
+		FROM %s
+		WHERE %s = $1
+		GROUP BY type
+	`, table.name, table.column)
+
+	rows, err := tx.QueryContext(ctx, query, targetID)
+	if err != nil {
+		return fmt.Errorf("could not count reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reactionType string
+		var count int
+		if err := rows.Scan(&reactionType, &count); err != nil {
+			return fmt.Errorf("could not scan reaction count: %w", err)
+		}
+		event.Counts[reactionType] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating reaction counts: %w", err)
+	}
+
+	return publishEvent(ctx, tx, realtime.PostTopic(event.PostID), realtime.EventReactionsChanged, event)
+}
+
+/*
+notificationPublished struct, tells a user their notifications changed
+
+Fields:
+  - NotificationID: int    - The new or updated notification
+  - Type:           string - One of the model.Notification* types
+  - UnreadCount:    int    - Number of unread notifications of the user
+*/
+type notificationPublished struct {
+	NotificationID int    `json:"notification_id"`
+	Type           string `json:"type"`
+	UnreadCount    int    `json:"unread_count"`
+}
+
+// Publishes a new or updated notification to its user
+func publishNotification(ctx context.Context, tx *sql.Tx, userID string, notificationID int, kind string) error {
+	event := notificationPublished{NotificationID: notificationID, Type: kind}
+
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+	if err := tx.QueryRowContext(ctx, query, userID).Scan(&event.UnreadCount); err != nil {
+		return fmt.Errorf("could not count unread notifications: %w", err)
+	}
+
+	return publishEvent(ctx, tx, realtime.UserTopic(userID), realtime.EventNotification, event)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+)
+
+// How far back comments count towards the popularity of a post
+const popularCommentWindow = 7 * 24 * time.Hour
+
+/*
+Returns the posts of a user and of the users they follow, newest first
+
+Params:
+  - ctx:    The request context
+  - userID: The user the feed is built for
+  - page:   The requested page, one extra post is fetched to know whether there is a next one
+
+Returns:
+  - The posts ordered by (created_at, id) descending
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetTimelinePosts(ctx context.Context, userID string, page pagination.Page) ([]model.Post, error) {
+	query := `
+		SELECT p.id, p.user_id, p.text, p.like_count, p.created_at, p.updated_at
+		FROM posts p
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE (p.user_id = $1 OR p.user_id IN (
+			SELECT followee_id FROM follows WHERE follower_id = $1
+		))
+		AND ($2::timestamptz IS NULL OR (p.created_at, p.id) < ($2, $3))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query timeline posts: %w", err)
+	}
+
+	return scanPosts(rows)
+}
+
+/*
+Returns the most popular posts within a window of the feed
+
+Popularity is the like count plus the number of comments written in the last
+week. Posts the timeline already contains, those of the user and of the users
+they follow, are left out. Because every feed page asks for the window between
+its own bounds, a popular post is shown on exactly one page.
+
+Params:
+  - ctx:    The request context
+  - userID: The user the feed is built for
+  - before: Upper bound of the window (exclusive), nil for no bound
+  - after:  Lower bound of the window (exclusive), nil for no bound
+  - limit:  The maximum number of posts
+
+Returns:
+  - The posts ordered by popularity
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetPopularPosts(ctx context.Context, userID string, before *pagination.Cursor, after *pagination.Cursor, limit int) ([]model.Post, error) {
+	query := `
+		SELECT p.id, p.user_id, p.text, p.like_count, p.created_at, p.updated_at
+		FROM posts p
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS recent FROM comments c
+			WHERE c.post_id = p.id AND c.created_at > $6
+		) c ON TRUE
+		WHERE p.user_id <> $1
+		AND p.user_id NOT IN (SELECT followee_id FROM follows WHERE follower_id = $1)
+		AND ($2::timestamptz IS NULL OR (p.created_at, p.id) < ($2, $3))
+		AND ($4::timestamptz IS NULL OR (p.created_at, p.id) > ($4, $5))
+		AND p.like_count + c.recent > 0
+		ORDER BY p.like_count + c.recent DESC, p.created_at DESC, p.id DESC
+		LIMIT $7
+	`
+
+	beforeTime, beforeID, err := intCursorArgs(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterTime, afterID, err := intCursorArgs(after)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-popularCommentWindow)
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID, beforeTime, beforeID, afterTime, afterID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query popular posts: %w", err)
+	}
+
+	return scanPosts(rows)
+}
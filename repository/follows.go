@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+)
+
+func (repo *PostGreSQL) FollowUser(ctx context.Context, followerID string, followeeID string) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	// First check if the user already follows the other user
+	isFollowing, err := repo.IsFollowing(ctx, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("could not check follow status: %w", err)
+	}
+	if isFollowing {
+		return fmt.Errorf("user already follows this user")
+	}
+
+	query := `
+        INSERT INTO follows (follower_id, followee_id, created_at)
+        VALUES ($1, $2, $3)
+    `
+
+	_, err = tx.ExecContext(ctx, query, followerID, followeeID, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not follow user: %w", err)
+	}
+
+	// Update the follow counts of both users
+	updateQuery := `
+        UPDATE users
+        SET follower_count = follower_count + CASE WHEN id = $2 THEN 1 ELSE 0 END,
+            following_count = following_count + CASE WHEN id = $1 THEN 1 ELSE 0 END
+        WHERE id IN ($1, $2)
+    `
+	_, err = tx.ExecContext(ctx, updateQuery, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("could not update follow counts: %w", err)
+	}
+
+	err = notify(ctx, tx, notificationEvent{userID: followeeID, actorID: followerID, kind: model.NotificationFollow})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) UnfollowUser(ctx context.Context, followerID string, followeeID string) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	query := `
+        DELETE FROM follows
+        WHERE follower_id = $1 AND followee_id = $2
+    `
+
+	result, err := tx.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("could not unfollow user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("follow not found")
+	}
+
+	// Update the follow counts of both users
+	updateQuery := `
+        UPDATE users
+        SET follower_count = follower_count - CASE WHEN id = $2 THEN 1 ELSE 0 END,
+            following_count = following_count - CASE WHEN id = $1 THEN 1 ELSE 0 END
+        WHERE id IN ($1, $2)
+    `
+	_, err = tx.ExecContext(ctx, updateQuery, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("could not update follow counts: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) IsFollowing(ctx context.Context, followerID string, followeeID string) (bool, error) {
+	query := `
+        SELECT EXISTS (
+            SELECT 1 FROM follows
+            WHERE follower_id = $1 AND followee_id = $2
+        )
+    `
+
+	var exists bool
+	err := repo.Database.QueryRowContext(ctx, query, followerID, followeeID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("could not check follow status: %w", err)
+	}
+
+	return exists, nil
+}
+
+// Returns the users following the given user, most recent first
+func (repo *PostGreSQL) GetFollowers(ctx context.Context, userID string, page pagination.Page) ([]model.Follow, error) {
+	query := `
+        SELECT u.id, u.username, f.created_at
+        FROM follows f
+        JOIN users u ON u.id = f.follower_id
+        WHERE f.followee_id = $1 AND u.deleted_at IS NULL
+        AND ($2::timestamptz IS NULL OR (f.created_at, f.follower_id) < ($2, $3::uuid))
+        ORDER BY f.created_at DESC, f.follower_id DESC
+        LIMIT $4
+    `
+
+	return repo.queryFollows(ctx, query, userID, page)
+}
+
+// Returns the users the given user follows, most recent first
+func (repo *PostGreSQL) GetFollowing(ctx context.Context, userID string, page pagination.Page) ([]model.Follow, error) {
+	query := `
+        SELECT u.id, u.username, f.created_at
+        FROM follows f
+        JOIN users u ON u.id = f.followee_id
+        WHERE f.follower_id = $1 AND u.deleted_at IS NULL
+        AND ($2::timestamptz IS NULL OR (f.created_at, f.followee_id) < ($2, $3::uuid))
+        ORDER BY f.created_at DESC, f.followee_id DESC
+        LIMIT $4
+    `
+
+	return repo.queryFollows(ctx, query, userID, page)
+}
+
+// Runs a followers or following query with the user, cursor and limit as arguments
+func (repo *PostGreSQL) queryFollows(ctx context.Context, query string, userID string, page pagination.Page) ([]model.Follow, error) {
+	afterTime, afterID, err := uuidCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query follows: %w", err)
+	}
+	defer rows.Close()
+
+	var follows []model.Follow
+	for rows.Next() {
+		var follow model.Follow
+		err := rows.Scan(
+			&follow.UserID,
+			&follow.Username,
+			&follow.FollowedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning follow row: %v", err)
+			continue
+		}
+		follows = append(follows, follow)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating follows: %w", err)
+	}
+
+	return follows, nil
+}
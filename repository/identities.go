@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/lib/pq"
+)
+
+func (repo *PostGreSQL) GetUserByIdentity(ctx context.Context, provider string, subject string) (model.User, error) {
+	query := `
+		SELECT u.id, u.username, u.email, u.password, u.email_verified, u.deleted_at
+		FROM user_identities i
+		JOIN users u ON i.user_id = u.id
+		WHERE i.provider = $1 AND i.subject = $2
+	`
+
+	var user model.User
+	var password sql.NullString
+	var deletedAt sql.NullTime
+
+	err := repo.Database.QueryRowContext(ctx, query, provider, subject).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&password,
+		&user.EmailVerified,
+		&deletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.User{}, fmt.Errorf("identity not found")
+		}
+		return model.User{}, fmt.Errorf("could not get user by identity: %w", err)
+	}
+
+	user.Password = password.String
+
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+
+	return user, nil
+}
+
+/*
+Inserts a user that signed up through an OAuth provider together with its identity
+
+The user is inserted without a password, so it can only sign in through a
+linked provider until a password is set.
+*/
+func (repo *PostGreSQL) InsertOAuthUser(ctx context.Context, user model.User, identity model.UserIdentity) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	user.Password = ""
+	if err = insertUser(ctx, tx, user); err != nil {
+		return err
+	}
+
+	identity.UserID = user.ID
+	if err = insertIdentity(ctx, tx, identity); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) CreateIdentity(ctx context.Context, identity model.UserIdentity) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	if err = insertIdentity(ctx, tx, identity); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Links the identity of an account created by the old Google sign-in
+
+That sign-in stored a hash of the Google ID, which is not a secret, as the
+password, so the password is removed in the same transaction.
+*/
+func (repo *PostGreSQL) ConvertLegacyGoogleUser(ctx context.Context, identity model.UserIdentity) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	if err = insertIdentity(ctx, tx, identity); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET password = NULL
+		WHERE id = $1
+	`
+
+	if _, err = tx.ExecContext(ctx, query, identity.UserID); err != nil {
+		return fmt.Errorf("could not remove password: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertIdentity(ctx context.Context, tx *sql.Tx, identity model.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (provider, subject, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		identity.Provider,
+		identity.Subject,
+		identity.UserID,
+		identity.Email,
+		time.Now(),
+	)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("identity already linked")
+		}
+		return fmt.Errorf("could not create identity: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) GetIdentitiesByUser(ctx context.Context, userID string) ([]model.UserIdentity, error) {
+	query := `
+		SELECT provider, subject, user_id, email, created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query identities: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []model.UserIdentity
+	for rows.Next() {
+		var identity model.UserIdentity
+		var email sql.NullString
+
+		err := rows.Scan(
+			&identity.Provider,
+			&identity.Subject,
+			&identity.UserID,
+			&email,
+			&identity.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning identity row: %v", err)
+			continue
+		}
+
+		identity.Email = email.String
+		identities = append(identities, identity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating identities: %w", err)
+	}
+
+	return identities, nil
+}
+
+/*
+Unlinks a provider from a user
+
+The last identity of a user without a password cannot be removed, since the
+user would have no way left to sign in.
+*/
+func (repo *PostGreSQL) DeleteIdentity(ctx context.Context, userID string, provider string) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	// Lock the user row so concurrent unlinks cannot both pass the check
+	checkQuery := `
+		SELECT u.password IS NOT NULL,
+			(SELECT COUNT(*) FROM user_identities WHERE user_id = u.id)
+		FROM users u
+		WHERE u.id = $1
+		FOR UPDATE
+	`
+
+	var hasPassword bool
+	var identityCount int
+
+	err = tx.QueryRowContext(ctx, checkQuery, userID).Scan(&hasPassword, &identityCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user not found")
+		}
+		return fmt.Errorf("could not check user sign-in methods: %w", err)
+	}
+
+	if !hasPassword && identityCount <= 1 {
+		return fmt.Errorf("cannot unlink the only sign-in method")
+	}
+
+	query := `
+		DELETE FROM user_identities
+		WHERE user_id = $1 AND provider = $2
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID, provider)
+	if err != nil {
+		return fmt.Errorf("could not delete identity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("identity not found")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ecofriends/authentication-backend/model"
+)
+
+// Columns of leaderboard_scores each metric is ranked by
+var leaderboardColumns = map[string]string{
+	model.LeaderboardLikesReceived: "likes_received",
+	model.LeaderboardPosts:         "post_count",
+	model.LeaderboardEcoImpact:     "co2e_kg",
+}
+
+// Key of the advisory lock held while refreshing, so server instances take turns
+const leaderboardRefreshLock = 24_000_001
+
+/*
+Recomputes the leaderboard scores
+
+Readers keep seeing the previous scores while the refresh runs. Nothing is
+done when another server instance is already refreshing.
+
+Params:
+  - ctx: The application context
+
+Returns:
+  - Whether the scores were refreshed
+  - An error if the refresh failed
+*/
+func (repo *PostGreSQL) RefreshLeaderboards(ctx context.Context) (bool, error) {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	var locked bool
+	if err = tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, leaderboardRefreshLock).Scan(&locked); err != nil {
+		return false, fmt.Errorf("could not lock leaderboards: %w", err)
+	}
+
+	if !locked {
+		return false, nil
+	}
+
+	if _, err = tx.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_scores`); err != nil {
+		return false, fmt.Errorf("could not refresh leaderboards: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+/*
+Ranks the users with a score in a period, as of the last refresh
+
+Params:
+  - ctx:    The request context
+  - metric: One of model.LeaderboardMetrics
+  - period: One of model.LeaderboardPeriods
+  - scope:  model.LeaderboardScopeGlobal, or model.LeaderboardScopeFollowing to rank the user against the users they follow
+  - userID: The requesting user, empty when signed out
+  - limit:  Number of entries to return
+  - offset: Number of entries to skip
+
+Returns:
+  - The leaderboard, with the place of the requesting user
+  - An error if the metric doesn't exist or the query failed
+*/
+func (repo *PostGreSQL) GetLeaderboard(ctx context.Context, metric string, period string, scope string, userID string, limit int, offset int) (model.Leaderboard, error) {
+	column, ok := leaderboardColumns[metric]
+	if !ok {
+		return model.Leaderboard{}, fmt.Errorf("unknown leaderboard metric %q", metric)
+	}
+
+	leaderboard := model.Leaderboard{
+		Metric:  metric,
+		Period:  period,
+		Scope:   scope,
+		Entries: []model.LeaderboardEntry{},
+	}
+
+	var since, refreshedAt sql.NullTime
+	err := repo.Database.QueryRowContext(ctx, `SELECT since, refreshed_at FROM leaderboard_scores WHERE period = $1 LIMIT 1`, period).Scan(&since, &refreshedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return model.Leaderboard{}, fmt.Errorf("could not get leaderboard period: %w", err)
+	}
+	if since.Valid {
+		leaderboard.Since = &since.Time
+	}
+	if refreshedAt.Valid {
+		leaderboard.RefreshedAt = &refreshedAt.Time
+	}
+
+	var caller sql.NullString
+	if userID != "" {
+		caller = sql.NullString{String: userID, Valid: true}
+	}
+
+	// Users the scope is limited to are ranked among themselves
+	ranked := fmt.Sprintf(`
+		WITH ranked AS (
+			SELECT
+				RANK() OVER (ORDER BY s.%[1]s DESC) AS rank,
+				s.user_id,
+				u.username,
+				s.%[1]s::float8 AS score
+			FROM leaderboard_scores s
+			JOIN users u ON u.id = s.user_id
+			WHERE s.period = $1 AND s.%[1]s > 0 AND u.deleted_at IS NULL
+			AND (
+				$2::text = 'global'
+				OR s.user_id = $3::uuid
+				OR s.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $3::uuid)
+			)
+		)
+	`, column)
+
+	query := ranked + `
+		SELECT rank, user_id, username, score
+		FROM ranked
+		ORDER BY rank, user_id
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, period, scope, caller, limit, offset)
+	if err != nil {
+		return model.Leaderboard{}, fmt.Errorf("could not query leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.LeaderboardEntry
+		if err := rows.Scan(&entry.Rank, &entry.UserID, &entry.Username, &entry.Score); err != nil {
+			return model.Leaderboard{}, fmt.Errorf("could not scan leaderboard entry: %w", err)
+		}
+		leaderboard.Entries = append(leaderboard.Entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return model.Leaderboard{}, fmt.Errorf("error iterating leaderboard: %w", err)
+	}
+
+	if userID == "" {
+		return leaderboard, nil
+	}
+
+	meQuery := ranked + `
+		SELECT rank, user_id, username, score
+		FROM ranked
+		WHERE user_id = $3
+	`
+
+	var me model.LeaderboardEntry
+	err = repo.Database.QueryRowContext(ctx, meQuery, period, scope, caller).Scan(&me.Rank, &me.UserID, &me.Username, &me.Score)
+	if err != nil && err != sql.ErrNoRows {
+		return model.Leaderboard{}, fmt.Errorf("could not get leaderboard entry of user: %w", err)
+	}
+	if err == nil {
+		leaderboard.Me = &me
+	}
+
+	return leaderboard, nil
+}
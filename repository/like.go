@@ -4,56 +4,22 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
 	_ "github.com/lib/pq"
 )
 
+// Likes are like reactions, kept for clients of the original likes endpoints.
+// Those clients only know about likes, so another reaction is never replaced.
 func (repo *PostGreSQL) LikePost(ctx context.Context, userID string, postID int) error {
-	tx, err := repo.Database.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("could not begin transaction: %w", err)
-	}
-
-	defer func() {
-		if rErr := tx.Rollback(); rErr != nil && err == nil {
-			err = fmt.Errorf("rollback failed: %w", rErr)
-		}
-	}()
-
-	// First check if the user already liked the post
-	hasLiked, err := repo.HasLiked(ctx, userID, postID)
-	if err != nil {
-		return fmt.Errorf("could not check like status: %w", err)
-	}
-	if hasLiked {
-		return fmt.Errorf("user already liked this post")
-	}
-
-	query := `
-        INSERT INTO post_likes (user_id, post_id, created_at)
-        VALUES ($1, $2, $3)
-    `
-
-	_, err = tx.ExecContext(ctx, query, userID, postID, time.Now())
+	previous, err := repo.setReaction(ctx, userID, model.ReactionTargetPost, postID, model.ReactionLike, false)
 	if err != nil {
 		return fmt.Errorf("could not like post: %w", err)
 	}
 
-	// Update the like_count in posts table
-	updateQuery := `
-        UPDATE posts
-        SET like_count = like_count + 1
-        WHERE id = $1
-    `
-	_, err = tx.ExecContext(ctx, updateQuery, postID)
-	if err != nil {
-		return fmt.Errorf("could not update post like count: %w", err)
-	}
-
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("could not commit transaction: %w", err)
+	if previous == model.ReactionLike {
+		return fmt.Errorf("user already liked this post")
 	}
 
 	return nil
@@ -71,21 +37,13 @@ func (repo *PostGreSQL) UnlikePost(ctx context.Context, userID string, postID in
 		}
 	}()
 
-	// First check if the user has liked the post
-	hasLiked, err := repo.HasLiked(ctx, userID, postID)
-	if err != nil {
-		return fmt.Errorf("could not check like status: %w", err)
-	}
-	if !hasLiked {
-		return fmt.Errorf("user hasn't liked this post")
-	}
-
+	// Other reactions are left alone
 	query := `
-        DELETE FROM post_likes
-        WHERE user_id = $1 AND post_id = $2
+        DELETE FROM post_reactions
+        WHERE user_id = $1 AND post_id = $2 AND type = $3
     `
 
-	result, err := tx.ExecContext(ctx, query, userID, postID)
+	result, err := tx.ExecContext(ctx, query, userID, postID, model.ReactionLike)
 	if err != nil {
 		return fmt.Errorf("could not unlike post: %w", err)
 	}
@@ -96,18 +54,15 @@ func (repo *PostGreSQL) UnlikePost(ctx context.Context, userID string, postID in
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("like not found")
+		return fmt.Errorf("user hasn't liked this post")
 	}
 
-	// Update the like_count in posts table
-	updateQuery := `
-        UPDATE posts
-        SET like_count = like_count - 1
-        WHERE id = $1
-    `
-	_, err = tx.ExecContext(ctx, updateQuery, postID)
-	if err != nil {
-		return fmt.Errorf("could not update post like count: %w", err)
+	if err = updateLikeCount(ctx, tx, postID, model.ReactionLike, ""); err != nil {
+		return err
+	}
+
+	if err = publishReactionsChanged(ctx, tx, model.ReactionTargetPost, postID); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -120,12 +75,12 @@ func (repo *PostGreSQL) UnlikePost(ctx context.Context, userID string, postID in
 func (repo *PostGreSQL) GetLikeCount(ctx context.Context, postID int) (int, error) {
 	query := `
         SELECT COUNT(*)
-        FROM post_likes
-        WHERE post_id = $1
+        FROM post_reactions
+        WHERE post_id = $1 AND type = $2
     `
 
 	var count int
-	err := repo.Database.QueryRowContext(ctx, query, postID).Scan(&count)
+	err := repo.Database.QueryRowContext(ctx, query, postID, model.ReactionLike).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("could not get like count: %w", err)
 	}
@@ -136,13 +91,13 @@ func (repo *PostGreSQL) GetLikeCount(ctx context.Context, postID int) (int, erro
 func (repo *PostGreSQL) HasLiked(ctx context.Context, userID string, postID int) (bool, error) {
 	query := `
         SELECT EXISTS (
-            SELECT 1 FROM post_likes
-            WHERE user_id = $1 AND post_id = $2
+            SELECT 1 FROM post_reactions
+            WHERE user_id = $1 AND post_id = $2 AND type = $3
         )
     `
 
 	var exists bool
-	err := repo.Database.QueryRowContext(ctx, query, userID, postID).Scan(&exists)
+	err := repo.Database.QueryRowContext(ctx, query, userID, postID, model.ReactionLike).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("could not check like status: %w", err)
 	}
@@ -150,16 +105,22 @@ func (repo *PostGreSQL) HasLiked(ctx context.Context, userID string, postID int)
 	return exists, nil
 }
 
-func (repo *PostGreSQL) GetLikesByUser(ctx context.Context, userID string, limit int, offset int) ([]model.PostLike, error) {
+func (repo *PostGreSQL) GetLikesByUser(ctx context.Context, userID string, page pagination.Page) ([]model.PostLike, error) {
 	query := `
         SELECT user_id, post_id, created_at
-        FROM post_likes
-        WHERE user_id = $1
-        ORDER BY created_at DESC
-        LIMIT $2 OFFSET $3
+        FROM post_reactions
+        WHERE user_id = $1 AND type = $5
+        AND ($2::timestamptz IS NULL OR (created_at, post_id) < ($2, $3))
+        ORDER BY created_at DESC, post_id DESC
+        LIMIT $4
     `
 
-	rows, err := repo.Database.QueryContext(ctx, query, userID, limit, offset)
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID, afterTime, afterID, page.FetchLimit(), model.ReactionLike)
 	if err != nil {
 		return nil, fmt.Errorf("could not query likes: %w", err)
 	}
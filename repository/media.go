@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/lib/pq"
+)
+
+/*
+Attaches an uploaded image to a post
+
+Objectives:
+  - Lock the post and check that the user owns it
+  - Refuse more than model.MaxMediaPerPost images per post
+  - Insert the media after the existing ones
+
+Returns:
+  - The stored media
+  - An error if the post isn't owned by the user or is full
+*/
+func (repo *PostGreSQL) CreateMedia(ctx context.Context, userID string, media model.Media) (model.Media, error) {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Media{}, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	// Lock the post so concurrent uploads can't exceed the limit
+	lockQuery := `
+		SELECT id FROM posts
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`
+
+	var postID int
+	err = tx.QueryRowContext(ctx, lockQuery, media.PostID, userID).Scan(&postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.Media{}, fmt.Errorf("post not found or not owned by user")
+		}
+		return model.Media{}, fmt.Errorf("could not get post: %w", err)
+	}
+
+	countQuery := `
+		SELECT COUNT(*), COALESCE(MAX(position) + 1, 0)
+		FROM post_media
+		WHERE post_id = $1
+	`
+
+	var count int
+	err = tx.QueryRowContext(ctx, countQuery, postID).Scan(&count, &media.Position)
+	if err != nil {
+		return model.Media{}, fmt.Errorf("could not count post media: %w", err)
+	}
+
+	if count >= model.MaxMediaPerPost {
+		return model.Media{}, fmt.Errorf("post already has the maximum number of media")
+	}
+
+	query := `
+		INSERT INTO post_media (post_id, storage_key, thumbnail_key, mime_type, width, height, size_bytes, position, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		postID,
+		media.StorageKey,
+		media.ThumbnailKey,
+		media.MimeType,
+		media.Width,
+		media.Height,
+		media.SizeBytes,
+		media.Position,
+		time.Now(),
+	).Scan(&media.ID, &media.CreatedAt)
+	if err != nil {
+		return model.Media{}, fmt.Errorf("could not create media: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.Media{}, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return media, nil
+}
+
+// Returns the media of the given posts, keyed by post ID and in position order
+func (repo *PostGreSQL) GetMediaByPosts(ctx context.Context, postIDs []int) (map[int][]model.Media, error) {
+	media := make(map[int][]model.Media)
+	if len(postIDs) == 0 {
+		return media, nil
+	}
+
+	query := `
+		SELECT id, post_id, storage_key, thumbnail_key, mime_type, width, height, size_bytes, position, created_at
+		FROM post_media
+		WHERE post_id = ANY($1)
+		ORDER BY post_id, position
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, pq.Array(postIDs))
+	if err != nil {
+		return nil, fmt.Errorf("could not query post media: %w", err)
+	}
+
+	list, err := scanMedia(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range list {
+		media[item.PostID] = append(media[item.PostID], item)
+	}
+
+	return media, nil
+}
+
+/*
+Detaches an image from a post owned by the user
+
+Returns:
+  - The deleted media, so its files can be removed from storage
+  - An error if the media doesn't exist or the post isn't owned by the user
+*/
+func (repo *PostGreSQL) DeleteMedia(ctx context.Context, mediaID int, postID int, userID string) (model.Media, error) {
+	query := `
+		DELETE FROM post_media m
+		USING posts p
+		WHERE m.id = $1 AND m.post_id = $2 AND p.id = m.post_id AND p.user_id = $3
+		RETURNING m.id, m.post_id, m.storage_key, m.thumbnail_key, m.mime_type, m.width, m.height, m.size_bytes, m.position, m.created_at
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, mediaID, postID, userID)
+	if err != nil {
+		return model.Media{}, fmt.Errorf("could not delete media: %w", err)
+	}
+
+	deleted, err := scanMedia(rows)
+	if err != nil {
+		return model.Media{}, err
+	}
+
+	if len(deleted) == 0 {
+		return model.Media{}, fmt.Errorf("media not found or not owned by user")
+	}
+
+	return deleted[0], nil
+}
+
+// Returns the storage keys of every file attached to a post
+func (repo *PostGreSQL) GetMediaKeysByPost(ctx context.Context, postID int) ([]string, error) {
+	query := `
+		SELECT storage_key, thumbnail_key
+		FROM post_media
+		WHERE post_id = $1
+	`
+
+	return repo.queryMediaKeys(ctx, query, postID)
+}
+
+// Returns the storage keys of every file attached to the posts of users soft deleted before the given time
+func (repo *PostGreSQL) GetMediaKeysOfDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	query := `
+		SELECT m.storage_key, m.thumbnail_key
+		FROM post_media m
+		JOIN posts p ON p.id = m.post_id
+		JOIN users u ON u.id = p.user_id
+		WHERE u.deleted_at IS NOT NULL AND u.deleted_at < $1
+	`
+
+	return repo.queryMediaKeys(ctx, query, deletedBefore)
+}
+
+func (repo *PostGreSQL) queryMediaKeys(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := repo.Database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query media keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var storageKey, thumbnailKey string
+		if err := rows.Scan(&storageKey, &thumbnailKey); err != nil {
+			log.Printf("Error scanning media key row: %v", err)
+			continue
+		}
+		keys = append(keys, storageKey, thumbnailKey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating media keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Scans and closes rows of every post_media column
+func scanMedia(rows *sql.Rows) ([]model.Media, error) {
+	defer rows.Close()
+
+	var media []model.Media
+	for rows.Next() {
+		var item model.Media
+		err := rows.Scan(
+			&item.ID,
+			&item.PostID,
+			&item.StorageKey,
+			&item.ThumbnailKey,
+			&item.MimeType,
+			&item.Width,
+			&item.Height,
+			&item.SizeBytes,
+			&item.Position,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning media row: %v", err)
+			continue
+		}
+		media = append(media, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating media: %w", err)
+	}
+
+	return media, nil
+}
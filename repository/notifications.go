@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	"github.com/lib/pq"
+)
+
+/*
+notificationEvent struct, something a user should be told about
+
+Fields:
+  - userID:    string - The notified user
+  - actorID:   string - The user who caused the event
+  - kind:      string - One of the model.Notification* types
+  - postID:    *int   - The post the event happened on (nullable)
+  - commentID: *int   - The comment the event happened on (nullable)
+*/
+type notificationEvent struct {
+	userID    string
+	actorID   string
+	kind      string
+	postID    *int
+	commentID *int
+}
+
+/*
+Records an event in the notifications of a user
+
+Runs in the transaction of the action that caused the event, so a
+notification exists exactly when the action succeeded. The event joins the
+unread notification of its group when there is one. Users are never notified
+about their own actions. Connected clients of the user are told right away.
+
+Params:
+  - ctx:   The request context
+  - tx:    The transaction of the action
+  - event: The event
+
+Returns:
+  - An error if the notification could not be written
+*/
+func notify(ctx context.Context, tx *sql.Tx, event notificationEvent) error {
+	if event.userID == event.actorID {
+		return nil
+	}
+
+	now := time.Now()
+
+	query := `
+		INSERT INTO notifications (user_id, type, post_id, comment_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (user_id, type, COALESCE(post_id, 0), COALESCE(comment_id, 0)) WHERE read_at IS NULL
+		DO UPDATE SET updated_at = EXCLUDED.updated_at
+		RETURNING id
+	`
+
+	var notificationID int
+	err := tx.QueryRowContext(ctx, query, event.userID, event.kind, event.postID, event.commentID, now).Scan(&notificationID)
+	if err != nil {
+		return fmt.Errorf("could not save notification: %w", err)
+	}
+
+	actorQuery := `
+		INSERT INTO notification_actors (notification_id, actor_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (notification_id, actor_id) DO UPDATE
+		SET created_at = EXCLUDED.created_at
+	`
+
+	if _, err := tx.ExecContext(ctx, actorQuery, notificationID, event.actorID, now); err != nil {
+		return fmt.Errorf("could not save notification actor: %w", err)
+	}
+
+	countQuery := `
+		UPDATE notifications
+		SET actor_count = (SELECT COUNT(*) FROM notification_actors WHERE notification_id = $1)
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, countQuery, notificationID); err != nil {
+		return fmt.Errorf("could not update notification actor count: %w", err)
+	}
+
+	return publishNotification(ctx, tx, event.userID, notificationID, event.kind)
+}
+
+/*
+Returns the notifications of a user, newest first
+
+New events bump updated_at, so pages are keyed on the immutable created_at to
+keep a notification from moving between pages while the user scrolls.
+
+Params:
+  - ctx:        The request context
+  - userID:     The notified user
+  - unreadOnly: Whether read notifications are left out
+  - page:       The requested page, one extra notification is fetched to know whether there is a next one
+
+Returns:
+  - The notifications ordered by (created_at, id) descending, with their most recent actors
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetNotifications(ctx context.Context, userID string, unreadOnly bool, page pagination.Page) ([]model.Notification, error) {
+	query := `
+		SELECT id, user_id, type, post_id, comment_id, actor_count, created_at, updated_at, read_at
+		FROM notifications
+		WHERE user_id = $1 AND actor_count > 0
+		AND (NOT $2 OR read_at IS NULL)
+		AND ($3::timestamptz IS NULL OR (created_at, id) < ($3, $4))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID, unreadOnly, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []model.Notification
+	for rows.Next() {
+		var notification model.Notification
+		var postID, commentID sql.NullInt64
+		var readAt sql.NullTime
+
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&postID,
+			&commentID,
+			&notification.ActorCount,
+			&notification.CreatedAt,
+			&notification.UpdatedAt,
+			&readAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning notification row: %v", err)
+			continue
+		}
+
+		if postID.Valid {
+			id := int(postID.Int64)
+			notification.PostID = &id
+		}
+
+		if commentID.Valid {
+			id := int(commentID.Int64)
+			notification.CommentID = &id
+		}
+
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	if err := repo.loadNotificationActors(ctx, notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// Loads the most recent actors of every notification
+func (repo *PostGreSQL) loadNotificationActors(ctx context.Context, notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(notifications))
+	for _, notification := range notifications {
+		ids = append(ids, notification.ID)
+	}
+
+	query := `
+		SELECT notification_id, actor_id, username
+		FROM (
+			SELECT na.notification_id, na.actor_id, u.username,
+				ROW_NUMBER() OVER (PARTITION BY na.notification_id ORDER BY na.created_at DESC) AS position
+			FROM notification_actors na
+			JOIN users u ON u.id = na.actor_id
+			WHERE na.notification_id = ANY($1)
+		) a
+		WHERE position <= $2
+		ORDER BY notification_id, position
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, pq.Array(ids), model.NotificationActorsShown)
+	if err != nil {
+		return fmt.Errorf("could not query notification actors: %w", err)
+	}
+	defer rows.Close()
+
+	actors := make(map[int][]model.NotificationActor, len(notifications))
+	for rows.Next() {
+		var notificationID int
+		var actor model.NotificationActor
+
+		if err := rows.Scan(&notificationID, &actor.UserID, &actor.Username); err != nil {
+			log.Printf("Error scanning notification actor row: %v", err)
+			continue
+		}
+
+		actors[notificationID] = append(actors[notificationID], actor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating notification actors: %w", err)
+	}
+
+	for i := range notifications {
+		notifications[i].Actors = actors[notifications[i].ID]
+		if notifications[i].Actors == nil {
+			notifications[i].Actors = []model.NotificationActor{}
+		}
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) GetUnreadNotificationCount(ctx context.Context, userID string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL AND actor_count > 0
+	`
+
+	var count int
+	if err := repo.Database.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("could not count unread notifications: %w", err)
+	}
+
+	return count, nil
+}
+
+func (repo *PostGreSQL) MarkNotificationRead(ctx context.Context, notificationID int, userID string) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $3)
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := repo.Database.ExecContext(ctx, query, notificationID, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not mark notification as read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("notification not found")
+	}
+
+	return nil
+}
+
+// Marks every unread notification of the user as read and returns how many there were
+func (repo *PostGreSQL) MarkAllNotificationsRead(ctx context.Context, userID string) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = $2
+		WHERE user_id = $1 AND read_at IS NULL
+	`
+
+	result, err := repo.Database.ExecContext(ctx, query, userID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("could not mark notifications as read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
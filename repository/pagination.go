@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/pagination"
+)
+
+/*
+Converts a cursor over integer keys into query arguments
+
+Listings compare (created_at, id) against the arguments only when the time is
+not NULL, which is the case on the first page.
+*/
+func intCursorArgs(cursor *pagination.Cursor) (sql.NullTime, int, error) {
+	if cursor == nil {
+		return sql.NullTime{}, 0, nil
+	}
+
+	id, err := cursor.IntID()
+	if err != nil {
+		return sql.NullTime{}, 0, err
+	}
+
+	return sql.NullTime{Time: cursor.CreatedAt, Valid: true}, id, nil
+}
+
+// Converts a cursor over UUID keys into query arguments, like intCursorArgs
+func uuidCursorArgs(cursor *pagination.Cursor) (sql.NullTime, sql.NullString, error) {
+	if cursor == nil {
+		return sql.NullTime{}, sql.NullString{}, nil
+	}
+
+	id, err := cursor.UUID()
+	if err != nil {
+		return sql.NullTime{}, sql.NullString{}, err
+	}
+
+	return sql.NullTime{Time: cursor.CreatedAt, Valid: true}, sql.NullString{String: id.String(), Valid: true}, nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/util"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+func (repo *PostGreSQL) UpdatePassword(ctx context.Context, userID string, password string) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	if err = updatePassword(ctx, tx, userID, password); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func updatePassword(ctx context.Context, tx *sql.Tx, userID string, password string) error {
+	hash, err := util.GenerateHash(password, util.DefaultHashCost)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET password = $1
+		WHERE id = $2
+	`
+
+	result, err := tx.ExecContext(ctx, query, hash, userID)
+	if err != nil {
+		return fmt.Errorf("could not update password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) CreatePasswordReset(ctx context.Context, userID string, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO password_resets (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := repo.Database.ExecContext(ctx, query, uuid.New(), userID, tokenHash, expiresAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not create password reset: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) GetLatestPasswordResetTime(ctx context.Context, userID string) (*time.Time, error) {
+	query := `
+		SELECT MAX(created_at)
+		FROM password_resets
+		WHERE user_id = $1
+	`
+
+	var latest sql.NullTime
+	err := repo.Database.QueryRowContext(ctx, query, userID).Scan(&latest)
+	if err != nil {
+		return nil, fmt.Errorf("could not get latest password reset: %w", err)
+	}
+
+	if !latest.Valid {
+		return nil, nil
+	}
+
+	return &latest.Time, nil
+}
+
+/*
+Consumes a password reset token and sets the new password
+
+The token must be unused and unexpired. Every other outstanding reset token
+of the user is invalidated along with it.
+
+Returns the ID of the user whose password was reset.
+*/
+func (repo *PostGreSQL) UsePasswordReset(ctx context.Context, tokenHash string, password string) (string, error) {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	now := time.Now()
+
+	selectQuery := `
+		SELECT user_id
+		FROM password_resets
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		FOR UPDATE
+	`
+
+	var userID string
+	err = tx.QueryRowContext(ctx, selectQuery, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("password reset not found or expired")
+		}
+		return "", fmt.Errorf("could not get password reset: %w", err)
+	}
+
+	if err = updatePassword(ctx, tx, userID, password); err != nil {
+		return "", err
+	}
+
+	useQuery := `
+		UPDATE password_resets
+		SET used_at = $1
+		WHERE user_id = $2 AND used_at IS NULL
+	`
+
+	_, err = tx.ExecContext(ctx, useQuery, now, userID)
+	if err != nil {
+		return "", fmt.Errorf("could not use password reset: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return userID, nil
+}
@@ -7,7 +7,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/ecofriends/authentication-backend/achievement"
 	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
 	_ "github.com/lib/pq"
 )
 
@@ -44,6 +46,14 @@ func (repo *PostGreSQL) CreatePost(ctx context.Context, userID string, text stri
 		return model.Post{}, fmt.Errorf("could not create post: %w", err)
 	}
 
+	if err = setPostTags(ctx, tx, createdPost.ID, text); err != nil {
+		return model.Post{}, err
+	}
+
+	if err = awardBadges(ctx, tx, userID, achievement.EventPostCreated); err != nil {
+		return model.Post{}, err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return model.Post{}, fmt.Errorf("could not commit transaction: %w", err)
 	}
@@ -92,104 +102,142 @@ func (repo *PostGreSQL) DeletePost(ctx context.Context, postID int, userID strin
 	return nil
 }
 
-func (repo *PostGreSQL) GetAllPosts(ctx context.Context, limit int, offset int) ([]model.Post, error) {
-	query := `
-		SELECT id, user_id, text, like_count, created_at, updated_at
+/*
+Replaces the text of a post, keeping the previous version as a revision
+
+Returns:
+  - The updated post
+  - An error if the post doesn't exist or isn't owned by the user
+*/
+func (repo *PostGreSQL) UpdatePost(ctx context.Context, postID int, userID string, text string) (model.Post, error) {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Post{}, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	// Lock the post so concurrent edits keep the revisions in order
+	selectQuery := `
+		SELECT text, COALESCE(updated_at, created_at)
 		FROM posts
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
 	`
 
-	rows, err := repo.Database.QueryContext(ctx, query, limit, offset)
+	var previousText string
+	var writtenAt time.Time
+	err = tx.QueryRowContext(ctx, selectQuery, postID, userID).Scan(&previousText, &writtenAt)
 	if err != nil {
-		return nil, fmt.Errorf("could not query posts: %w", err)
+		if err == sql.ErrNoRows {
+			return model.Post{}, fmt.Errorf("post not found or not owned by user")
+		}
+		return model.Post{}, fmt.Errorf("could not get post: %w", err)
 	}
-	defer rows.Close()
 
-	var posts []model.Post
-	for rows.Next() {
-		var post model.Post
-		var updatedAt sql.NullTime
+	revisionQuery := `
+		INSERT INTO post_revisions (post_id, text, written_at)
+		VALUES ($1, $2, $3)
+	`
 
-		err := rows.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Text,
-			&post.LikeCount,
-			&post.CreatedAt,
-			&updatedAt,
-		)
-		if err != nil {
-			log.Printf("Error scanning post row: %v", err)
-			continue
-		}
+	_, err = tx.ExecContext(ctx, revisionQuery, postID, previousText, writtenAt)
+	if err != nil {
+		return model.Post{}, fmt.Errorf("could not save post revision: %w", err)
+	}
 
-		if updatedAt.Valid {
-			post.UpdatedAt = &updatedAt.Time
-		}
+	updateQuery := `
+		UPDATE posts
+		SET text = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING id, user_id, text, like_count, created_at, updated_at
+	`
 
-		posts = append(posts, post)
+	var updatedPost model.Post
+	var updatedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, updateQuery, text, time.Now(), postID).Scan(
+		&updatedPost.ID,
+		&updatedPost.UserID,
+		&updatedPost.Text,
+		&updatedPost.LikeCount,
+		&updatedPost.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return model.Post{}, fmt.Errorf("could not update post: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating posts: %w", err)
+	if updatedAt.Valid {
+		updatedPost.UpdatedAt = &updatedAt.Time
 	}
 
-	return posts, nil
+	if err = setPostTags(ctx, tx, postID, text); err != nil {
+		return model.Post{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.Post{}, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return updatedPost, nil
 }
 
-func (repo *PostGreSQL) GetPostsByUser(ctx context.Context, userID string, limit int, offset int) ([]model.Post, error) {
+func (repo *PostGreSQL) GetAllPosts(ctx context.Context, page pagination.Page) ([]model.Post, error) {
 	query := `
-		SELECT id, user_id, text, like_count, created_at, updated_at
-		FROM posts
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		SELECT p.id, p.user_id, p.text, p.like_count, p.created_at, p.updated_at
+		FROM posts p
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE ($1::timestamptz IS NULL OR (p.created_at, p.id) < ($1, $2))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $3
 	`
 
-	rows, err := repo.Database.QueryContext(ctx, query, userID, limit, offset)
+	afterTime, afterID, err := intCursorArgs(page.After)
 	if err != nil {
-		return nil, fmt.Errorf("could not query user posts: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	var posts []model.Post
-	for rows.Next() {
-		var post model.Post
-		var updatedAt sql.NullTime
+	rows, err := repo.Database.QueryContext(ctx, query, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query posts: %w", err)
+	}
 
-		err := rows.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Text,
-			&post.LikeCount,
-			&post.CreatedAt,
-			&updatedAt,
-		)
-		if err != nil {
-			log.Printf("Error scanning user post row: %v", err)
-			continue
-		}
+	return scanPosts(rows)
+}
 
-		if updatedAt.Valid {
-			post.UpdatedAt = &updatedAt.Time
-		}
+func (repo *PostGreSQL) GetPostsByUser(ctx context.Context, userID string, page pagination.Page) ([]model.Post, error) {
+	query := `
+		SELECT p.id, p.user_id, p.text, p.like_count, p.created_at, p.updated_at
+		FROM posts p
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE p.user_id = $1
+		AND ($2::timestamptz IS NULL OR (p.created_at, p.id) < ($2, $3))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $4
+	`
 
-		posts = append(posts, post)
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating user posts: %w", err)
+	rows, err := repo.Database.QueryContext(ctx, query, userID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query user posts: %w", err)
 	}
 
-	return posts, nil
+	return scanPosts(rows)
 }
 
 func (repo *PostGreSQL) GetPostByID(ctx context.Context, postID int) (model.Post, error) {
 	query := `
-		SELECT id, user_id, text, like_count, created_at, updated_at
-		FROM posts
-		WHERE id = $1
+		SELECT p.id, p.user_id, p.text, p.like_count, p.created_at, p.updated_at
+		FROM posts p
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE p.id = $1
 	`
 
 	var post model.Post
@@ -217,3 +265,39 @@ func (repo *PostGreSQL) GetPostByID(ctx context.Context, postID int) (model.Post
 
 	return post, nil
 }
+
+// Scans and closes rows of id, user_id, text, like_count, created_at, updated_at
+func scanPosts(rows *sql.Rows) ([]model.Post, error) {
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		var updatedAt sql.NullTime
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Text,
+			&post.LikeCount,
+			&post.CreatedAt,
+			&updatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning post row: %v", err)
+			continue
+		}
+
+		if updatedAt.Valid {
+			post.UpdatedAt = &updatedAt.Time
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating posts: %w", err)
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ecofriends/authentication-backend/model"
+)
+
+/*
+Returns the profile of a user
+
+Users that never edited their profile have no user_profiles row, their
+profile fields are returned empty.
+*/
+func (repo *PostGreSQL) GetProfile(ctx context.Context, userID string) (model.Profile, error) {
+	query := `
+		SELECT u.id, u.username,
+			COALESCE(p.display_name, ''), COALESCE(p.bio, ''),
+			COALESCE(p.location, ''), COALESCE(p.avatar_url, ''),
+			u.created_at, p.updated_at
+		FROM users u
+		LEFT JOIN user_profiles p ON p.user_id = u.id
+		WHERE u.id = $1 AND u.deleted_at IS NULL
+	`
+
+	var profile model.Profile
+	var updatedAt sql.NullTime
+	err := repo.Database.QueryRowContext(ctx, query, userID).Scan(
+		&profile.UserID,
+		&profile.Username,
+		&profile.DisplayName,
+		&profile.Bio,
+		&profile.Location,
+		&profile.AvatarURL,
+		&profile.JoinedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.Profile{}, fmt.Errorf("profile not found")
+		}
+		return model.Profile{}, fmt.Errorf("could not get profile: %w", err)
+	}
+
+	if updatedAt.Valid {
+		profile.UpdatedAt = &updatedAt.Time
+	}
+
+	return profile, nil
+}
+
+func (repo *PostGreSQL) UpsertProfile(ctx context.Context, profile model.Profile) error {
+	query := `
+		INSERT INTO user_profiles (user_id, display_name, bio, location, avatar_url, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE
+		SET display_name = EXCLUDED.display_name,
+			bio = EXCLUDED.bio,
+			location = EXCLUDED.location,
+			avatar_url = EXCLUDED.avatar_url,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := repo.Database.ExecContext(ctx, query,
+		profile.UserID,
+		profile.DisplayName,
+		profile.Bio,
+		profile.Location,
+		profile.AvatarURL,
+	)
+	if err != nil {
+		return fmt.Errorf("could not update profile: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/achievement"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	"github.com/lib/pq"
+)
+
+// The reaction table, the column of the reacted item and the table of the item for a target type
+type reactionTable struct {
+	name       string
+	column     string
+	targetName string
+}
+
+var reactionTables = map[string]reactionTable{
+	model.ReactionTargetPost:    {name: "post_reactions", column: "post_id", targetName: "posts"},
+	model.ReactionTargetComment: {name: "comment_reactions", column: "comment_id", targetName: "comments"},
+}
+
+// Looks up the tables of a target type
+func reactionTableOf(targetType string) (reactionTable, error) {
+	table, ok := reactionTables[targetType]
+	if !ok {
+		return reactionTable{}, fmt.Errorf("unknown reaction target %q", targetType)
+	}
+	return table, nil
+}
+
+/*
+Leaves a reaction on a post or comment, replacing the user's previous one
+
+Params:
+  - ctx:          The request context
+  - userID:       The reacting user
+  - targetType:   model.ReactionTargetPost or model.ReactionTargetComment
+  - targetID:     The post or comment
+  - reactionType: One of model.ReactionTypes
+
+Returns:
+  - The type of the replaced reaction, empty if there was none
+  - An error if the post or comment doesn't exist
+*/
+func (repo *PostGreSQL) SetReaction(ctx context.Context, userID string, targetType string, targetID int, reactionType string) (string, error) {
+	return repo.setReaction(ctx, userID, targetType, targetID, reactionType, true)
+}
+
+// Leaves a reaction, replacing a different previous one only when replace is set
+func (repo *PostGreSQL) setReaction(ctx context.Context, userID string, targetType string, targetID int, reactionType string, replace bool) (string, error) {
+	table, err := reactionTableOf(targetType)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	// Lock the item so its like count and the reaction change together
+	var ownerID string
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT user_id FROM %s WHERE id = $1 FOR UPDATE`, table.targetName), targetID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("%s not found", targetType)
+		}
+		return "", fmt.Errorf("could not get %s: %w", targetType, err)
+	}
+
+	var previous string
+	selectQuery := fmt.Sprintf(`SELECT type FROM %s WHERE user_id = $1 AND %s = $2`, table.name, table.column)
+	err = tx.QueryRowContext(ctx, selectQuery, userID, targetID).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("could not get reaction: %w", err)
+	}
+
+	if previous == reactionType {
+		return previous, nil
+	}
+
+	if previous != "" && !replace {
+		return previous, fmt.Errorf("user already reacted to this %s", targetType)
+	}
+
+	upsertQuery := fmt.Sprintf(`
+		INSERT INTO %s (user_id, %s, type, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, %s) DO UPDATE
+		SET type = EXCLUDED.type, created_at = EXCLUDED.created_at
+	`, table.name, table.column, table.column)
+
+	_, err = tx.ExecContext(ctx, upsertQuery, userID, targetID, reactionType, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("could not save reaction: %w", err)
+	}
+
+	if targetType == model.ReactionTargetPost {
+		if err = updateLikeCount(ctx, tx, targetID, previous, reactionType); err != nil {
+			return "", err
+		}
+	}
+
+	// Changing the type of a reaction isn't news to the author
+	if previous == "" {
+		event := notificationEvent{userID: ownerID, actorID: userID, kind: model.NotificationPostReaction, postID: &targetID}
+		if targetType == model.ReactionTargetComment {
+			event = notificationEvent{userID: ownerID, actorID: userID, kind: model.NotificationCommentReaction, commentID: &targetID}
+		}
+
+		if err = notify(ctx, tx, event); err != nil {
+			return "", err
+		}
+	}
+
+	if targetType == model.ReactionTargetPost && reactionType == model.ReactionLike {
+		if err = awardBadges(ctx, tx, ownerID, achievement.EventLikeReceived); err != nil {
+			return "", err
+		}
+	}
+
+	if err = publishReactionsChanged(ctx, tx, targetType, targetID); err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return previous, nil
+}
+
+/*
+Removes the user's reaction from a post or comment
+
+Params:
+  - ctx:        The request context
+  - userID:     The reacting user
+  - targetType: model.ReactionTargetPost or model.ReactionTargetComment
+  - targetID:   The post or comment
+
+Returns:
+  - The type of the removed reaction
+  - An error if the user has no reaction on the item
+*/
+func (repo *PostGreSQL) RemoveReaction(ctx context.Context, userID string, targetType string, targetID int) (string, error) {
+	table, err := reactionTableOf(targetType)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE user_id = $1 AND %s = $2
+		RETURNING type
+	`, table.name, table.column)
+
+	var removed string
+	err = tx.QueryRowContext(ctx, query, userID, targetID).Scan(&removed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("reaction not found")
+		}
+		return "", fmt.Errorf("could not remove reaction: %w", err)
+	}
+
+	if targetType == model.ReactionTargetPost {
+		if err = updateLikeCount(ctx, tx, targetID, removed, ""); err != nil {
+			return "", err
+		}
+	}
+
+	if err = publishReactionsChanged(ctx, tx, targetType, targetID); err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return removed, nil
+}
+
+// Keeps posts.like_count equal to the number of like reactions when a reaction changes from one type to another
+func updateLikeCount(ctx context.Context, tx *sql.Tx, postID int, from string, to string) error {
+	delta := 0
+	if from == model.ReactionLike {
+		delta--
+	}
+	if to == model.ReactionLike {
+		delta++
+	}
+	if delta == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE posts
+		SET like_count = like_count + $1
+		WHERE id = $2
+	`
+	if _, err := tx.ExecContext(ctx, query, delta, postID); err != nil {
+		return fmt.Errorf("could not update post like count: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Returns the reactions on several posts or comments at once
+
+Params:
+  - ctx:        The request context
+  - targetType: model.ReactionTargetPost or model.ReactionTargetComment
+  - targetIDs:  The posts or comments
+  - userID:     The requesting user, whose own reaction is reported, empty when signed out
+
+Returns:
+  - A summary for every requested item, keyed by its ID
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetReactionSummaries(ctx context.Context, targetType string, targetIDs []int, userID string) (map[int]model.ReactionSummary, error) {
+	table, err := reactionTableOf(targetType)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[int]model.ReactionSummary, len(targetIDs))
+	for _, id := range targetIDs {
+		summaries[id] = model.NewReactionSummary()
+	}
+	if len(targetIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, type, COUNT(*), BOOL_OR(user_id::text = $2)
+		FROM %s
+		WHERE %s = ANY($1)
+		GROUP BY %s, type
+	`, table.column, table.name, table.column, table.column)
+
+	rows, err := repo.Database.QueryContext(ctx, query, pq.Array(targetIDs), userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, count int
+		var reactionType string
+		var mine bool
+
+		if err := rows.Scan(&id, &reactionType, &count, &mine); err != nil {
+			log.Printf("Error scanning reaction row: %v", err)
+			continue
+		}
+
+		summary := summaries[id]
+		summary.Counts[reactionType] = count
+		if mine {
+			summary.MyReaction = reactionType
+		}
+		summaries[id] = summary
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reactions: %w", err)
+	}
+
+	return summaries, nil
+}
+
+/*
+Returns the reactions a user left on posts or comments, newest first
+
+Params:
+  - ctx:        The request context
+  - userID:     The user
+  - targetType: model.ReactionTargetPost or model.ReactionTargetComment
+  - page:       The requested page, one extra reaction is fetched to know whether there is a next one
+
+Returns:
+  - The reactions ordered by (created_at, target ID) descending
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetReactionsByUser(ctx context.Context, userID string, targetType string, page pagination.Page) ([]model.Reaction, error) {
+	table, err := reactionTableOf(targetType)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT user_id, %s, type, created_at
+		FROM %s
+		WHERE user_id = $1
+		AND ($2::timestamptz IS NULL OR (created_at, %s) < ($2, $3))
+		ORDER BY created_at DESC, %s DESC
+		LIMIT $4
+	`, table.column, table.name, table.column, table.column)
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, userID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query user reactions: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []model.Reaction
+	for rows.Next() {
+		reaction := model.Reaction{TargetType: targetType}
+		err := rows.Scan(
+			&reaction.UserID,
+			&reaction.TargetID,
+			&reaction.Type,
+			&reaction.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning user reaction row: %v", err)
+			continue
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user reactions: %w", err)
+	}
+
+	return reactions, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+)
+
+// Returns the previous versions of a post, most recently replaced first
+func (repo *PostGreSQL) GetPostRevisions(ctx context.Context, postID int, page pagination.Page) ([]model.Revision, error) {
+	query := `
+		SELECT id, text, written_at, revised_at
+		FROM post_revisions
+		WHERE post_id = $1
+		AND ($2::timestamptz IS NULL OR (revised_at, id) < ($2, $3))
+		ORDER BY revised_at DESC, id DESC
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, postID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query post revisions: %w", err)
+	}
+
+	return scanRevisions(rows)
+}
+
+// Returns the previous versions of a comment, most recently replaced first
+func (repo *PostGreSQL) GetCommentRevisions(ctx context.Context, commentID int, page pagination.Page) ([]model.Revision, error) {
+	query := `
+		SELECT id, text, written_at, revised_at
+		FROM comment_revisions
+		WHERE comment_id = $1
+		AND ($2::timestamptz IS NULL OR (revised_at, id) < ($2, $3))
+		ORDER BY revised_at DESC, id DESC
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, commentID, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query comment revisions: %w", err)
+	}
+
+	return scanRevisions(rows)
+}
+
+// Scans and closes rows of id, text, written_at, revised_at
+func scanRevisions(rows *sql.Rows) ([]model.Revision, error) {
+	defer rows.Close()
+
+	var revisions []model.Revision
+	for rows.Next() {
+		var revision model.Revision
+		err := rows.Scan(
+			&revision.ID,
+			&revision.Text,
+			&revision.WrittenAt,
+			&revision.RevisedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning revision row: %v", err)
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revisions: %w", err)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func (repo *PostGreSQL) RevokeToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	_, err = tx.ExecContext(ctx, query, jti, userID, expiresAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not revoke token: %w", err)
+	}
+
+	// Expired tokens are rejected anyway, so there is no need to keep them
+	cleanupQuery := `
+		DELETE FROM revoked_tokens
+		WHERE expires_at < $1
+	`
+
+	_, err = tx.ExecContext(ctx, cleanupQuery, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not clean up revoked tokens: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM revoked_tokens
+			WHERE jti = $1
+		)
+	`
+
+	var exists bool
+	err := repo.Database.QueryRowContext(ctx, query, jti).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("could not check token revocation: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (repo *PostGreSQL) RevokeAllUserTokens(ctx context.Context, userID string) (time.Time, error) {
+	query := `
+		UPDATE users
+		SET tokens_revoked_at = $1
+		WHERE id = $2
+	`
+
+	// PostgreSQL keeps microseconds, so the returned cutoff matches the stored one
+	now := time.Now().Truncate(time.Microsecond)
+
+	result, err := repo.Database.ExecContext(ctx, query, now, userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not revoke user tokens: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return time.Time{}, fmt.Errorf("user not found")
+	}
+
+	return now, nil
+}
+
+func (repo *PostGreSQL) GetTokensRevokedAt(ctx context.Context, userID string) (*time.Time, error) {
+	query := `
+		SELECT tokens_revoked_at
+		FROM users
+		WHERE id = $1
+	`
+
+	var revokedAt sql.NullTime
+	err := repo.Database.QueryRowContext(ctx, query, userID).Scan(&revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("could not get token revocation time: %w", err)
+	}
+
+	if !revokedAt.Valid {
+		return nil, nil
+	}
+
+	return &revokedAt.Time, nil
+}
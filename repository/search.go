@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ecofriends/authentication-backend/model"
+)
+
+// Options of ts_headline, matches are wrapped in <mark> tags
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MinWords=10, MaxWords=30, MaxFragments=2"
+
+/*
+Full-text searches posts, ranking them by relevance and recency
+
+The text is HTML escaped before the headline is built, so the only markup in
+it are the <mark> tags around the matches.
+
+Params:
+  - ctx:    The request context
+  - query:  The search query, in web search syntax ("quoted phrases", -excluded, or)
+  - limit:  The maximum number of posts
+  - offset: The number of posts to skip
+
+Returns:
+  - The matching posts, best first
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) SearchPosts(ctx context.Context, query string, limit int, offset int) ([]model.PostSearchResult, error) {
+	searchQuery := `
+		SELECT p.id, p.user_id, p.text, p.like_count, p.created_at, p.updated_at,
+			ts_headline('english', REPLACE(REPLACE(REPLACE(p.text, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), q, $2),
+			ts_rank(p.search_vector, q) / (1 + EXTRACT(EPOCH FROM NOW() - p.created_at) / 604800) AS rank
+		FROM posts p
+		CROSS JOIN websearch_to_tsquery('english', $1) q
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE p.search_vector @@ q
+		ORDER BY rank DESC, p.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, searchQuery, query, headlineOptions, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("could not search posts: %w", err)
+	}
+	defer rows.Close()
+
+	results := []model.PostSearchResult{}
+	for rows.Next() {
+		var result model.PostSearchResult
+		var updatedAt sql.NullTime
+
+		err := rows.Scan(
+			&result.ID,
+			&result.UserID,
+			&result.Text,
+			&result.LikeCount,
+			&result.CreatedAt,
+			&updatedAt,
+			&result.Headline,
+			&result.Rank,
+		)
+		if err != nil {
+			log.Printf("Error scanning post search row: %v", err)
+			continue
+		}
+
+		if updatedAt.Valid {
+			result.UpdatedAt = &updatedAt.Time
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// Full-text searches comments, like SearchPosts
+func (repo *PostGreSQL) SearchComments(ctx context.Context, query string, limit int, offset int) ([]model.CommentSearchResult, error) {
+	searchQuery := `
+		SELECT c.id, c.user_id, c.post_id, c.parent_id, c.depth, c.reply_count, c.text, c.created_at, c.updated_at, u.username,
+			ts_headline('english', REPLACE(REPLACE(REPLACE(c.text, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), q, $2),
+			ts_rank(c.search_vector, q) / (1 + EXTRACT(EPOCH FROM NOW() - c.created_at) / 604800) AS rank
+		FROM comments c
+		CROSS JOIN websearch_to_tsquery('english', $1) q
+		JOIN users u ON u.id = c.user_id AND u.deleted_at IS NULL
+		WHERE c.search_vector @@ q
+		ORDER BY rank DESC, c.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, searchQuery, query, headlineOptions, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("could not search comments: %w", err)
+	}
+	defer rows.Close()
+
+	results := []model.CommentSearchResult{}
+	for rows.Next() {
+		var result model.CommentSearchResult
+		var parentID sql.NullInt64
+		var updatedAt sql.NullTime
+
+		err := rows.Scan(
+			&result.ID,
+			&result.UserID,
+			&result.PostID,
+			&parentID,
+			&result.Depth,
+			&result.ReplyCount,
+			&result.Text,
+			&result.CreatedAt,
+			&updatedAt,
+			&result.Username,
+			&result.Headline,
+			&result.Rank,
+		)
+		if err != nil {
+			log.Printf("Error scanning comment search row: %v", err)
+			continue
+		}
+
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			result.ParentID = &id
+		}
+
+		if updatedAt.Valid {
+			result.UpdatedAt = &updatedAt.Time
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comment search results: %w", err)
+	}
+
+	return results, nil
+}
+
+/*
+Searches users by username
+
+Usernames starting with the query come first, the others are matched and
+ordered by trigram similarity so typos still find the user.
+
+Params:
+  - ctx:    The request context
+  - query:  The username or its beginning
+  - limit:  The maximum number of users
+  - offset: The number of users to skip
+
+Returns:
+  - The matching users, best first
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) SearchUsers(ctx context.Context, query string, limit int, offset int) ([]model.UserSearchResult, error) {
+	searchQuery := `
+		SELECT u.id, u.username, COALESCE(p.display_name, ''), COALESCE(p.avatar_url, ''),
+			similarity(u.username, $1) AS score
+		FROM users u
+		LEFT JOIN user_profiles p ON p.user_id = u.id
+		WHERE u.deleted_at IS NULL
+		AND (u.username ILIKE $2 OR u.username % $1)
+		ORDER BY u.username ILIKE $2 DESC, score DESC, u.username
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, searchQuery, query, likePrefix(query), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("could not search users: %w", err)
+	}
+	defer rows.Close()
+
+	results := []model.UserSearchResult{}
+	for rows.Next() {
+		var result model.UserSearchResult
+
+		err := rows.Scan(
+			&result.UserID,
+			&result.Username,
+			&result.DisplayName,
+			&result.AvatarURL,
+			&result.Similarity,
+		)
+		if err != nil {
+			log.Printf("Error scanning user search row: %v", err)
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// Builds a LIKE pattern matching values that start with the prefix
+func likePrefix(prefix string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return escaper.Replace(prefix) + "%"
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+func (repo *PostGreSQL) CreateSession(ctx context.Context, session model.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := repo.Database.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.FamilyID,
+		session.TokenHash,
+		session.ExpiresAt,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("could not create session: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) GetSessionByTokenHash(ctx context.Context, tokenHash string) (model.Session, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, created_at, revoked_at, replaced_by
+		FROM sessions
+		WHERE token_hash = $1
+	`
+
+	var session model.Session
+	var revokedAt sql.NullTime
+	var replacedBy uuid.NullUUID
+
+	err := repo.Database.QueryRowContext(ctx, query, tokenHash).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.FamilyID,
+		&session.TokenHash,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&revokedAt,
+		&replacedBy,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.Session{}, fmt.Errorf("session not found")
+		}
+		return model.Session{}, fmt.Errorf("could not get session: %w", err)
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	if replacedBy.Valid {
+		session.ReplacedBy = &replacedBy.UUID
+	}
+
+	return session, nil
+}
+
+/*
+Rotates a session by revoking the current one and inserting its replacement
+
+The old session is only revoked if it is still active, so two concurrent
+refreshes with the same token cannot both succeed; the loser receives a
+"session already rotated" error and is treated as a replay.
+*/
+func (repo *PostGreSQL) RotateSession(ctx context.Context, oldSessionID uuid.UUID, next model.Session) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	now := time.Now()
+
+	insertQuery := `
+		INSERT INTO sessions (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err = tx.ExecContext(ctx, insertQuery,
+		next.ID,
+		next.UserID,
+		next.FamilyID,
+		next.TokenHash,
+		next.ExpiresAt,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("could not create session: %w", err)
+	}
+
+	revokeQuery := `
+		UPDATE sessions
+		SET revoked_at = $1, replaced_by = $2
+		WHERE id = $3 AND revoked_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, revokeQuery, now, next.ID, oldSessionID)
+	if err != nil {
+		return fmt.Errorf("could not revoke session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("session already rotated")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) RevokeSessionFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE family_id = $2 AND revoked_at IS NULL
+	`
+
+	_, err := repo.Database.ExecContext(ctx, query, time.Now(), familyID)
+	if err != nil {
+		return fmt.Errorf("could not revoke session family: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) RevokeUserSessions(ctx context.Context, userID string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL
+	`
+
+	_, err := repo.Database.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("could not revoke user sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ecofriends/authentication-backend/hashtag"
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/ecofriends/authentication-backend/pagination"
+	"github.com/lib/pq"
+)
+
+/*
+Replaces the tags of a post with the hashtags of its text
+
+Params:
+  - ctx:    The request context
+  - tx:     The transaction the post is written in
+  - postID: The post
+  - text:   The text of the post
+
+Returns:
+  - An error if the tags could not be saved
+*/
+func setPostTags(ctx context.Context, tx *sql.Tx, postID int, text string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM post_tags WHERE post_id = $1`, postID)
+	if err != nil {
+		return fmt.Errorf("could not clear post tags: %w", err)
+	}
+
+	tags := hashtag.Parse(text)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tagQuery := `
+		INSERT INTO tags (name)
+		SELECT UNNEST($1::text[])
+		ON CONFLICT (name) DO NOTHING
+	`
+
+	_, err = tx.ExecContext(ctx, tagQuery, pq.Array(tags))
+	if err != nil {
+		return fmt.Errorf("could not save tags: %w", err)
+	}
+
+	postTagQuery := `
+		INSERT INTO post_tags (post_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2)
+	`
+
+	_, err = tx.ExecContext(ctx, postTagQuery, postID, pq.Array(tags))
+	if err != nil {
+		return fmt.Errorf("could not save post tags: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) GetPostsByTag(ctx context.Context, tag string, page pagination.Page) ([]model.Post, error) {
+	query := `
+		SELECT p.id, p.user_id, p.text, p.like_count, p.created_at, p.updated_at
+		FROM posts p
+		JOIN post_tags pt ON pt.post_id = p.id
+		JOIN tags t ON t.id = pt.tag_id
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE t.name = $1
+		AND ($2::timestamptz IS NULL OR (p.created_at, p.id) < ($2, $3))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $4
+	`
+
+	afterTime, afterID, err := intCursorArgs(page.After)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Database.QueryContext(ctx, query, tag, afterTime, afterID, page.FetchLimit())
+	if err != nil {
+		return nil, fmt.Errorf("could not query tagged posts: %w", err)
+	}
+
+	return scanPosts(rows)
+}
+
+/*
+Returns the tags used by the most posts written since the given time
+
+Params:
+  - ctx:   The request context
+  - since: Only posts written after this time are counted
+  - limit: The maximum number of tags
+
+Returns:
+  - The tags ordered by the number of posts, most used first
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) GetTrendingTags(ctx context.Context, since time.Time, limit int) ([]model.Tag, error) {
+	query := `
+		SELECT t.name, COUNT(*) AS post_count
+		FROM post_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		JOIN posts p ON p.id = pt.post_id
+		JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE p.created_at > $1
+		GROUP BY t.name
+		ORDER BY post_count DESC, t.name
+		LIMIT $2
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query trending tags: %w", err)
+	}
+
+	return scanTags(rows)
+}
+
+/*
+Returns the tags starting with a prefix, for autocompletion
+
+Params:
+  - ctx:    The request context
+  - prefix: The normalized beginning of the tag
+  - limit:  The maximum number of tags
+
+Returns:
+  - The tags ordered by the number of posts using them, most used first
+  - An error if the query failed
+*/
+func (repo *PostGreSQL) SearchTags(ctx context.Context, prefix string, limit int) ([]model.Tag, error) {
+	query := `
+		SELECT t.name, COUNT(pt.post_id) AS post_count
+		FROM tags t
+		LEFT JOIN (
+			post_tags pt
+			JOIN posts p ON p.id = pt.post_id
+			JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL
+		) ON pt.tag_id = t.id
+		WHERE t.name LIKE $1
+		GROUP BY t.name
+		ORDER BY post_count DESC, t.name
+		LIMIT $2
+	`
+
+	rows, err := repo.Database.QueryContext(ctx, query, likePrefix(prefix), limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not search tags: %w", err)
+	}
+
+	return scanTags(rows)
+}
+
+// Scans and closes rows of name, post_count
+func scanTags(rows *sql.Rows) ([]model.Tag, error) {
+	defer rows.Close()
+
+	tags := []model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		if err := rows.Scan(&tag.Name, &tag.PostCount); err != nil {
+			log.Printf("Error scanning tag row: %v", err)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	return tags, nil
+}
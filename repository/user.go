@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/ecofriends/authentication-backend/model"
 	"github.com/ecofriends/authentication-backend/util"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func (repo *PostGreSQL) InsertUser(ctx context.Context, user model.User) error {
@@ -27,33 +28,61 @@ func (repo *PostGreSQL) InsertUser(ctx context.Context, user model.User) error {
 		}
 	}()
 
-	// Hash the user password
-	user.Password, err = util.GenerateHash(user.Password, util.DefaultHashCost)
+	// Insert the user
+	err = insertUser(ctx, tx, user)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Hashed user password:", user.Password)
+	// Commit the transaction
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("[FAIL]: could not commit transaction")
+	}
+
+	return nil
+}
+
+/*
+Inserts a user within a transaction
+
+Objectives:
+  - Hash the user password, users without a password get a NULL password
+  - Insert the user from the model data
+
+Params:
+  - ctx:  The request context
+  - tx:   The transaction to insert the user in
+  - user: The user to insert, with a plain text password
+
+Returns:
+  - An error if the insertion failed
+*/
+func insertUser(ctx context.Context, tx *sql.Tx, user model.User) error {
+	var password sql.NullString
+
+	// Hash the user password
+	if user.Password != "" {
+		hash, err := util.GenerateHash(user.Password, util.DefaultHashCost)
+		if err != nil {
+			return err
+		}
+		password = sql.NullString{String: hash, Valid: true}
+	}
 
 	// Construct a query to insert the user from the model data
 	var insertQuery = `
-		INSERT INTO users (id, username, email, password)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (id, username, email, password, email_verified)
+		VALUES ($1, $2, $3, $4, $5)
 	`
 
 	// Execute the insertion query
-	_, err = tx.ExecContext(ctx, insertQuery, user.ID, user.Username, user.Email, user.Password)
+	_, err := tx.ExecContext(ctx, insertQuery, user.ID, user.Username, user.Email, password, user.EmailVerified)
 	if err != nil {
 		log.Println(err)
 		return fmt.Errorf("[FAIL]: could not execute insert query")
 	}
 
-	// Commit the transaction
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("[FAIL]: could not commit transaction")
-	}
-
 	return nil
 }
 
@@ -92,14 +121,16 @@ func (repo *PostGreSQL) GetUserByID(ctx context.Context, id string) (model.User,
 
 	// Construct a query to return the user details from the provided id
 	var getUserByIDQuery = `
-		SELECT id, username, email, password FROM users WHERE id = $1
+		SELECT id, username, email, password, email_verified, deleted_at FROM users WHERE id = $1
 	`
 
 	// Allocate memory for the user model data
 	var user model.User
+	var password sql.NullString
+	var deletedAt sql.NullTime
 
 	// Execute the query, returns the row with the details
-	err = tx.QueryRowContext(ctx, getUserByIDQuery, id).Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	err = tx.QueryRowContext(ctx, getUserByIDQuery, id).Scan(&user.ID, &user.Username, &user.Email, &password, &user.EmailVerified, &deletedAt)
 	if err != nil {
 		log.Println(err)
 		if err == sql.ErrNoRows {
@@ -108,6 +139,13 @@ func (repo *PostGreSQL) GetUserByID(ctx context.Context, id string) (model.User,
 		return model.User{}, fmt.Errorf("[FAIL]: could not execute query: %w", err)
 	}
 
+	// Users that only sign in through a provider have no password
+	user.Password = password.String
+
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+
 	return user, nil
 }
 
@@ -121,14 +159,16 @@ func (repo *PostGreSQL) GetUserByEmail(ctx context.Context, email string) (model
 
 	// construct a query to return the data model using the email provided
 	var getUserByIDQuery = `
-		SELECT id, username, email, password FROM users WHERE email = $1
+		SELECT id, username, email, password, email_verified, deleted_at FROM users WHERE email = $1
 	`
 
 	// Allocate memory for the user data
 	var user model.User
+	var password sql.NullString
+	var deletedAt sql.NullTime
 
 	// Execute the query
-	err = tx.QueryRowContext(ctx, getUserByIDQuery, email).Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	err = tx.QueryRowContext(ctx, getUserByIDQuery, email).Scan(&user.ID, &user.Username, &user.Email, &password, &user.EmailVerified, &deletedAt)
 	if err != nil {
 		log.Println(err)
 		if err == sql.ErrNoRows {
@@ -137,5 +177,260 @@ func (repo *PostGreSQL) GetUserByEmail(ctx context.Context, email string) (model
 		return model.User{}, fmt.Errorf("[FAIL]: could not execute query: %w", err)
 	}
 
+	// Users that only sign in through a provider have no password
+	user.Password = password.String
+
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+
 	return user, nil
 }
+
+/*
+Updates a user's username and email
+
+Changing the email resets the verification status, since the new address has
+not been confirmed yet.
+*/
+func (repo *PostGreSQL) UpdateUser(ctx context.Context, userID string, username string, email string) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	query := `
+		UPDATE users
+		SET username = $1,
+			email = $2,
+			email_verified = CASE WHEN email = $2 THEN email_verified ELSE FALSE END
+		WHERE id = $3
+	`
+
+	result, err := tx.ExecContext(ctx, query, username, email, userID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("username or email already taken")
+		}
+		return fmt.Errorf("could not update user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) SoftDeleteUser(ctx context.Context, userID string) (time.Time, error) {
+	query := `
+		UPDATE users
+		SET deleted_at = $1
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	now := time.Now()
+
+	result, err := repo.Database.ExecContext(ctx, query, now, userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return time.Time{}, fmt.Errorf("user not found or already deleted")
+	}
+
+	return now, nil
+}
+
+func (repo *PostGreSQL) RestoreUser(ctx context.Context, userID string) error {
+	query := `
+		UPDATE users
+		SET deleted_at = NULL
+		WHERE id = $1
+	`
+
+	_, err := repo.Database.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("could not restore user: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Hard deletes every user soft deleted before the given time
+
+Posts, comments, reactions and every other row owned by the users are
+removed through their ON DELETE CASCADE foreign keys. The like, follow, reply
+and notification actor counts the users contributed to are decremented first,
+in the same transaction.
+*/
+func (repo *PostGreSQL) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	countQueries := []string{`
+		UPDATE posts p
+		SET like_count = p.like_count - d.n
+		FROM (
+			SELECT l.post_id, COUNT(*) AS n
+			FROM post_reactions l
+			JOIN users u ON u.id = l.user_id
+			WHERE u.deleted_at IS NOT NULL AND u.deleted_at < $1
+			AND l.type = 'like'
+			GROUP BY l.post_id
+		) d
+		WHERE p.id = d.post_id
+	`, `
+		UPDATE users t
+		SET follower_count = t.follower_count - d.n
+		FROM (
+			SELECT f.followee_id AS id, COUNT(*) AS n
+			FROM follows f
+			JOIN users u ON u.id = f.follower_id
+			WHERE u.deleted_at IS NOT NULL AND u.deleted_at < $1
+			GROUP BY f.followee_id
+		) d
+		WHERE t.id = d.id
+	`, `
+		UPDATE users t
+		SET following_count = t.following_count - d.n
+		FROM (
+			SELECT f.follower_id AS id, COUNT(*) AS n
+			FROM follows f
+			JOIN users u ON u.id = f.followee_id
+			WHERE u.deleted_at IS NOT NULL AND u.deleted_at < $1
+			GROUP BY f.follower_id
+		) d
+		WHERE t.id = d.id
+	`, `
+		UPDATE comments p
+		SET reply_count = p.reply_count - d.n
+		FROM (
+			SELECT c.parent_id, COUNT(*) AS n
+			FROM comments c
+			JOIN users u ON u.id = c.user_id
+			WHERE u.deleted_at IS NOT NULL AND u.deleted_at < $1
+			AND c.parent_id IS NOT NULL
+			GROUP BY c.parent_id
+		) d
+		WHERE p.id = d.parent_id
+	`, `
+		UPDATE notifications n
+		SET actor_count = n.actor_count - d.n
+		FROM (
+			SELECT a.notification_id, COUNT(*) AS n
+			FROM notification_actors a
+			JOIN users u ON u.id = a.actor_id
+			WHERE u.deleted_at IS NOT NULL AND u.deleted_at < $1
+			GROUP BY a.notification_id
+		) d
+		WHERE n.id = d.notification_id
+	`}
+
+	for _, query := range countQueries {
+		if _, err = tx.ExecContext(ctx, query, deletedBefore); err != nil {
+			return 0, fmt.Errorf("could not update counts of purged users: %w", err)
+		}
+	}
+
+	query := `
+		DELETE FROM users
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	`
+
+	result, err := tx.ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("could not purge deleted users: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+func (repo *PostGreSQL) GetUserStats(ctx context.Context, userID string) (model.UserStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM posts WHERE user_id = $1),
+			(SELECT COUNT(*) FROM comments WHERE user_id = $1),
+			(SELECT COUNT(*) FROM post_reactions l JOIN posts p ON p.id = l.post_id WHERE p.user_id = $1 AND l.type = 'like'),
+			follower_count,
+			following_count
+		FROM users
+		WHERE id = $1
+	`
+
+	var stats model.UserStats
+	err := repo.Database.QueryRowContext(ctx, query, userID).Scan(
+		&stats.PostCount,
+		&stats.CommentCount,
+		&stats.LikesReceived,
+		&stats.FollowerCount,
+		&stats.FollowingCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.UserStats{}, fmt.Errorf("user not found")
+		}
+		return model.UserStats{}, fmt.Errorf("could not get user stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Reports whether the user may manage community content such as challenges
+func (repo *PostGreSQL) IsAdmin(ctx context.Context, userID string) (bool, error) {
+	query := `
+		SELECT is_admin
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	var isAdmin bool
+	err := repo.Database.QueryRowContext(ctx, query, userID).Scan(&isAdmin)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("user not found")
+		}
+		return false, fmt.Errorf("could not check admin status: %w", err)
+	}
+
+	return isAdmin, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func (repo *PostGreSQL) CreateEmailVerification(ctx context.Context, jti string, userID string, email string) error {
+	query := `
+		INSERT INTO email_verifications (jti, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := repo.Database.ExecContext(ctx, query, jti, userID, email, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not create email verification: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Returns how many verification emails a user was sent since a given time,
+and when the latest one was sent
+*/
+func (repo *PostGreSQL) GetEmailVerificationStats(ctx context.Context, userID string, since time.Time) (int, *time.Time, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE created_at >= $2), MAX(created_at)
+		FROM email_verifications
+		WHERE user_id = $1
+	`
+
+	var count int
+	var latest sql.NullTime
+
+	err := repo.Database.QueryRowContext(ctx, query, userID, since).Scan(&count, &latest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("could not get email verification stats: %w", err)
+	}
+
+	if !latest.Valid {
+		return count, nil, nil
+	}
+
+	return count, &latest.Time, nil
+}
+
+/*
+Consumes a verification token and marks the address as verified
+
+The token is only accepted once, and only while the user's address is still
+the one the token was issued for.
+*/
+func (repo *PostGreSQL) UseEmailVerification(ctx context.Context, jti string, userID string, email string) error {
+	tx, err := repo.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && err == nil {
+			err = fmt.Errorf("rollback failed: %w", rErr)
+		}
+	}()
+
+	useQuery := `
+		UPDATE email_verifications
+		SET used_at = $1
+		WHERE jti = $2 AND user_id = $3 AND email = $4 AND used_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, useQuery, time.Now(), jti, userID, email)
+	if err != nil {
+		return fmt.Errorf("could not use email verification: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("verification not found or already used")
+	}
+
+	verifyQuery := `
+		UPDATE users
+		SET email_verified = TRUE
+		WHERE id = $1 AND email = $2
+	`
+
+	result, err = tx.ExecContext(ctx, verifyQuery, userID, email)
+	if err != nil {
+		return fmt.Errorf("could not verify email: %w", err)
+	}
+
+	rowsAffected, err = result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("email address has changed since the verification was sent")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *PostGreSQL) IsEmailVerified(ctx context.Context, userID string) (bool, error) {
+	query := `
+		SELECT email_verified
+		FROM users
+		WHERE id = $1
+	`
+
+	var verified bool
+	err := repo.Database.QueryRowContext(ctx, query, userID).Scan(&verified)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("user not found")
+		}
+		return false, fmt.Errorf("could not check email verification: %w", err)
+	}
+
+	return verified, nil
+}
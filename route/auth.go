@@ -2,8 +2,11 @@ package route
 
 import (
 	"database/sql"
+	"log"
 
 	handler "github.com/ecofriends/authentication-backend/handler/auth"
+	"github.com/ecofriends/authentication-backend/mailer"
+	"github.com/ecofriends/authentication-backend/middleware"
 	repository "github.com/ecofriends/authentication-backend/repository"
 	"github.com/ecofriends/authentication-backend/service"
 	"github.com/go-chi/chi/v5"
@@ -15,12 +18,24 @@ func LoadAuthRoutes(router chi.Router, db *sql.DB) {
 
 	authHandler := &handler.AuthHandler{}
 	authHandler.WithService(authDBService)
+	mail, err := mailer.FromEnvironment()
+	if err != nil {
+		log.Fatal("[FATAL]: unable to setup mailer: ", err)
+	}
+	authHandler.WithMailer(mail)
 
 	router.Get("/", authHandler.Home)
 	router.Post("/sign-up", authHandler.SignUp)
 	router.Post("/sign-in", authHandler.SignIn)
 	router.Post("/sign-out", authHandler.SignOut)
-	router.Get("/oauth/google", authHandler.GoogleSignIn)
-	router.Get("/oauth/google/callback", authHandler.GoogleSignInCallback)
-	router.Get("/oauth/{x}/failure", authHandler.OAuthFailure)
+	router.Post("/refresh", authHandler.Refresh)
+	router.Post("/forgot-password", authHandler.ForgotPassword)
+	router.Post("/reset-password", authHandler.ResetPassword)
+	router.Get("/verify-email", authHandler.VerifyEmail)
+	router.With(middleware.AuthenticateMiddleware).Post("/verify-email/resend", authHandler.ResendVerification)
+	router.With(middleware.AuthenticateMiddleware).Post("/sign-out-all", authHandler.SignOutAll)
+	router.Get("/oauth/{provider}", authHandler.OAuthSignIn)
+	router.Get("/oauth/{provider}/callback", authHandler.OAuthSignInCallback)
+	router.With(middleware.AuthenticateMiddleware).Get("/oauth/{provider}/link", authHandler.OAuthLink)
+	router.Get("/oauth/{provider}/failure", authHandler.OAuthFailure)
 }
@@ -0,0 +1,16 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadBadgeRoutes(router chi.Router, db *sql.DB) {
+	badge := &handler.Badge{}
+	badge.New(&repository.PostGreSQL{Database: db})
+
+	router.Get("/", badge.GetBadges)
+}
@@ -0,0 +1,27 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadChallengeRoutes(router chi.Router, db *sql.DB) {
+	repo := &repository.PostGreSQL{Database: db}
+
+	challenge := &handler.Challenge{}
+	challenge.New(repo)
+
+	router.Get("/", challenge.GetChallenges)
+	router.Get("/{id}", challenge.GetChallenge)
+	router.Get("/{id}/participants", challenge.GetParticipants)
+	router.Get("/{id}/participants/{userID}", challenge.GetProgress)
+
+	router.With(middleware.AuthenticateMiddleware, middleware.RequireAdmin(repo)).Post("/", challenge.CreateChallenge)
+	router.With(middleware.AuthenticateMiddleware, middleware.RequireAdmin(repo)).Delete("/{id}", challenge.DeleteChallenge)
+	router.With(middleware.AuthenticateMiddleware).Post("/{id}/join", challenge.JoinChallenge)
+	router.With(middleware.AuthenticateMiddleware).Delete("/{id}/join", challenge.LeaveChallenge)
+}
@@ -10,13 +10,22 @@ import (
 )
 
 func LoadCommentRoutes(router chi.Router, db *sql.DB) {
+	repo := &repository.PostGreSQL{Database: db}
+
 	comment := &handler.Comment{}
-	comment.New(&repository.PostGreSQL{Database: db})
+	comment.New(repo)
+
+	reaction := &handler.Reaction{}
+	reaction.New(repo)
 
-	router.Get("/{id}", comment.GetCommentByID)
-	router.Get("/post", comment.GetCommentsByPost)
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/{id}", comment.GetCommentByID)
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/post", comment.GetCommentsByPost)
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/{id}/replies", comment.GetReplies)
 
-	router.With(middleware.AuthenticateMiddleware).Post("/create", comment.CreateComment)
+	router.With(middleware.AuthenticateMiddleware, middleware.RequireVerifiedEmail(repo)).Post("/create", comment.CreateComment)
 	router.With(middleware.AuthenticateMiddleware).Put("/update", comment.UpdateComment)
 	router.With(middleware.AuthenticateMiddleware).Delete("/delete", comment.DeleteComment)
+	router.With(middleware.AuthenticateMiddleware).Get("/{id}/revisions", comment.GetCommentRevisions)
+	router.With(middleware.AuthenticateMiddleware).Put("/{id}/reactions", reaction.ReactToComment)
+	router.With(middleware.AuthenticateMiddleware).Delete("/{id}/reactions", reaction.RemoveCommentReaction)
 }
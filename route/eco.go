@@ -0,0 +1,22 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadEcoRoutes(router chi.Router, db *sql.DB) {
+	eco := &handler.Eco{}
+	eco.New(&repository.PostGreSQL{Database: db})
+
+	router.Get("/action_types", eco.GetActionTypes)
+	router.Get("/actions/user", eco.GetActionsByUser)
+	router.Get("/totals", eco.GetTotals)
+
+	router.With(middleware.AuthenticateMiddleware).Post("/actions", eco.LogAction)
+	router.With(middleware.AuthenticateMiddleware).Delete("/actions/{id}", eco.DeleteAction)
+}
@@ -0,0 +1,16 @@
+package route
+
+import (
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	"github.com/ecofriends/authentication-backend/realtime"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadEventRoutes(router chi.Router, hub *realtime.Hub) {
+	events := &handler.Events{}
+	events.New(hub)
+
+	router.With(middleware.AuthenticateMiddleware).Get("/", events.Stream)
+	router.With(middleware.AuthenticateMiddleware).Get("/ws", events.WebSocket)
+}
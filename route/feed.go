@@ -0,0 +1,19 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadFeedRoutes(router chi.Router, db *sql.DB) {
+	feed := &handler.Feed{}
+	feed.New(&repository.PostGreSQL{Database: db})
+	feed.WithStorage(storage.FromEnvironment())
+
+	router.With(middleware.AuthenticateMiddleware).Get("/", feed.GetFeed)
+}
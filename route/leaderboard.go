@@ -0,0 +1,17 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadLeaderboardRoutes(router chi.Router, db *sql.DB) {
+	leaderboard := &handler.Leaderboard{}
+	leaderboard.New(&repository.PostGreSQL{Database: db})
+
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/", leaderboard.GetLeaderboard)
+}
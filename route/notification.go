@@ -0,0 +1,20 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadNotificationRoutes(router chi.Router, db *sql.DB) {
+	notification := &handler.Notification{}
+	notification.New(&repository.PostGreSQL{Database: db})
+
+	router.With(middleware.AuthenticateMiddleware).Get("/", notification.GetNotifications)
+	router.With(middleware.AuthenticateMiddleware).Get("/unread_count", notification.GetUnreadCount)
+	router.With(middleware.AuthenticateMiddleware).Post("/read_all", notification.MarkAllRead)
+	router.With(middleware.AuthenticateMiddleware).Post("/{id}/read", notification.MarkRead)
+}
@@ -6,17 +6,30 @@ import (
 	"github.com/ecofriends/authentication-backend/handler"
 	"github.com/ecofriends/authentication-backend/middleware"
 	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
 	"github.com/go-chi/chi/v5"
 )
 
 func LoadPostRoutes(router chi.Router, db *sql.DB) {
+	repo := &repository.PostGreSQL{Database: db}
+
 	post := &handler.Post{}
-	post.New(&repository.PostGreSQL{Database: db})
+	post.New(repo)
+	post.WithStorage(storage.FromEnvironment())
+
+	reaction := &handler.Reaction{}
+	reaction.New(repo)
 
-	router.Get("/{id}", post.GetPostByID)
-	router.Get("/all", post.GetAllPosts)
-	router.Get("/user", post.GetPostsByUser)
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/{id}", post.GetPostByID)
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/all", post.GetAllPosts)
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/user", post.GetPostsByUser)
 
-	router.With(middleware.AuthenticateMiddleware).Post("/create", post.CreatePost)
+	router.With(middleware.AuthenticateMiddleware, middleware.RequireVerifiedEmail(repo)).Post("/create", post.CreatePost)
 	router.With(middleware.AuthenticateMiddleware).Delete("/delete", post.DeletePost)
+	router.With(middleware.AuthenticateMiddleware).Put("/{id}", post.UpdatePost)
+	router.With(middleware.AuthenticateMiddleware).Get("/{id}/revisions", post.GetPostRevisions)
+	router.With(middleware.AuthenticateMiddleware, middleware.RequireVerifiedEmail(repo)).Post("/{id}/media", post.UploadMedia)
+	router.With(middleware.AuthenticateMiddleware).Delete("/{id}/media/{mediaID}", post.DeleteMedia)
+	router.With(middleware.AuthenticateMiddleware).Put("/{id}/reactions", reaction.ReactToPost)
+	router.With(middleware.AuthenticateMiddleware).Delete("/{id}/reactions", reaction.RemovePostReaction)
 }
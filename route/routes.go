@@ -4,7 +4,11 @@ import (
 	"database/sql"
 	"net/http"
 
+	"github.com/ecofriends/authentication-backend/authentication"
 	_ "github.com/ecofriends/authentication-backend/docs"
+	"github.com/ecofriends/authentication-backend/realtime"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
 	"github.com/ecofriends/authentication-backend/util"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,29 +22,35 @@ Loads the application routes
 Objectives:
   - Create the application base router
   - Setup CORS
+  - Setup the revoked token denylist
   - Setup a request handler to the base route
   - Setup other routes and sub-routers
+  - Serve locally stored media
   - Handle requests to undefined endpoints
 
 Params:
-  - db: A pointer to the application database
+  - db:  A pointer to the application database
+  - hub: The hub real-time events are delivered through
 
 Returns:
   - A chi multiplexer
 */
-func LoadRoutes(db *sql.DB) *chi.Mux {
+func LoadRoutes(db *sql.DB, hub *realtime.Hub) *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 
 	// Setup CORS
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 	}))
 
+	// Setup the denylist checked by the authentication middleware
+	authentication.UseDenylist(authentication.NewDenylist(&repository.PostGreSQL{Database: db}))
+
 	// Handle requests made to the base route
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		msg := "Welcome to the API"
@@ -72,6 +82,56 @@ func LoadRoutes(db *sql.DB) *chi.Mux {
 		LoadLikeRoutes(router, db)
 	})
 
+	// Setup feed route handlers
+	router.Route("/feed", func(router chi.Router) {
+		LoadFeedRoutes(router, db)
+	})
+
+	// Setup notification route handlers
+	router.Route("/notifications", func(router chi.Router) {
+		LoadNotificationRoutes(router, db)
+	})
+
+	// Setup tag route handlers
+	router.Route("/tags", func(router chi.Router) {
+		LoadTagRoutes(router, db)
+	})
+
+	// Setup search route handlers
+	router.Route("/search", func(router chi.Router) {
+		LoadSearchRoutes(router, db)
+	})
+
+	// Setup eco-action route handlers
+	router.Route("/eco", func(router chi.Router) {
+		LoadEcoRoutes(router, db)
+	})
+
+	// Setup challenge route handlers
+	router.Route("/challenges", func(router chi.Router) {
+		LoadChallengeRoutes(router, db)
+	})
+
+	// Setup leaderboard route handlers
+	router.Route("/leaderboards", func(router chi.Router) {
+		LoadLeaderboardRoutes(router, db)
+	})
+
+	// Setup badge route handlers
+	router.Route("/badges", func(router chi.Router) {
+		LoadBadgeRoutes(router, db)
+	})
+
+	// Setup real-time event route handlers
+	router.Route("/events", func(router chi.Router) {
+		LoadEventRoutes(router, hub)
+	})
+
+	// Serve uploaded media when it is kept on the local disk
+	if local, ok := storage.FromEnvironment().(*storage.LocalStorage); ok {
+		router.Handle("/media/*", http.StripPrefix("/media", local.Handler()))
+	}
+
 	// Setup swagger route handlers
 	router.Get("/swagger/*", httpSwagger.Handler())
 
@@ -0,0 +1,19 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadSearchRoutes(router chi.Router, db *sql.DB) {
+	search := &handler.Search{}
+	search.New(&repository.PostGreSQL{Database: db})
+	search.WithStorage(storage.FromEnvironment())
+
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/", search.Search)
+}
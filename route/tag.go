@@ -0,0 +1,21 @@
+package route
+
+import (
+	"database/sql"
+
+	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/middleware"
+	repository "github.com/ecofriends/authentication-backend/repository"
+	"github.com/ecofriends/authentication-backend/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+func LoadTagRoutes(router chi.Router, db *sql.DB) {
+	tag := &handler.Tag{}
+	tag.New(&repository.PostGreSQL{Database: db})
+	tag.WithStorage(storage.FromEnvironment())
+
+	router.Get("/trending", tag.GetTrendingTags)
+	router.Get("/search", tag.SearchTags)
+	router.With(middleware.OptionalAuthenticateMiddleware).Get("/{name}/posts", tag.GetPostsByTag)
+}
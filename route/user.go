@@ -2,8 +2,10 @@ package route
 
 import (
 	"database/sql"
+	"log"
 
 	"github.com/ecofriends/authentication-backend/handler"
+	"github.com/ecofriends/authentication-backend/mailer"
 	"github.com/ecofriends/authentication-backend/middleware"
 	repository "github.com/ecofriends/authentication-backend/repository"
 	"github.com/go-chi/chi/v5"
@@ -12,7 +14,24 @@ import (
 func LoadUserRoutes(router chi.Router, db *sql.DB) {
 	user := &handler.User{}
 	user.New(&repository.PostGreSQL{Database: db})
+	mail, err := mailer.FromEnvironment()
+	if err != nil {
+		log.Fatal("[FATAL]: unable to setup mailer: ", err)
+	}
+	user.WithMailer(mail)
 
 	router.Get("/", user.Home)
-	router.With(middleware.AuthenticateMiddleware).Get("/{id}", user.GetUserByID)
+	router.With(middleware.AuthenticateMiddleware).Get("/me", user.GetMe)
+	router.With(middleware.AuthenticateMiddleware).Patch("/me", user.UpdateMe)
+	router.With(middleware.AuthenticateMiddleware).Delete("/me", user.DeleteMe)
+	router.With(middleware.AuthenticateMiddleware).Patch("/me/profile", user.UpdateProfile)
+	router.With(middleware.AuthenticateMiddleware).Get("/me/export", user.ExportMe)
+	router.With(middleware.AuthenticateMiddleware).Post("/me/password", user.ChangePassword)
+	router.With(middleware.AuthenticateMiddleware).Get("/me/identities", user.GetIdentities)
+	router.With(middleware.AuthenticateMiddleware).Delete("/me/identities/{provider}", user.UnlinkIdentity)
+	router.Get("/{id}", user.GetUserByID)
+	router.Get("/{id}/followers", user.GetFollowers)
+	router.Get("/{id}/following", user.GetFollowing)
+	router.With(middleware.AuthenticateMiddleware).Post("/{id}/follow", user.FollowUser)
+	router.With(middleware.AuthenticateMiddleware).Delete("/{id}/follow", user.UnfollowUser)
 }
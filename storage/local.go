@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Storage that keeps files on the local filesystem, for development and
+single-instance deployments
+
+Fields:
+  - Directory: string - Directory the files are written to
+  - BaseURL:   string - URL the directory is served from
+*/
+type LocalStorage struct {
+	Directory string
+	BaseURL   string
+}
+
+func (storage *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := storage.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create media directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial file
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, data, 0o644); err != nil {
+		return fmt.Errorf("could not write media file: %w", err)
+	}
+
+	if err := os.Rename(temporary, path); err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("could not write media file: %w", err)
+	}
+
+	return nil
+}
+
+func (storage *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := storage.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not delete media file: %w", err)
+	}
+
+	return nil
+}
+
+func (storage *LocalStorage) URL(key string) string {
+	return storage.BaseURL + "/" + key
+}
+
+// Serves the stored files, to be mounted at the path of BaseURL
+func (storage *LocalStorage) Handler() http.Handler {
+	files := http.FileServer(http.Dir(storage.Directory))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Never list the contents of a directory
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// Resolves a key to a path inside the directory, rejecting keys that escape it
+func (storage *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid media key: %s", key)
+	}
+
+	return filepath.Join(storage.Directory, filepath.FromSlash(cleaned)), nil
+}
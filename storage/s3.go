@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+Storage that keeps files in an S3-compatible bucket, such as AWS S3, MinIO or
+Cloudflare R2. Requests are signed with AWS Signature Version 4.
+
+Fields:
+  - Endpoint:  string       - Base URL of the service, e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+  - Region:    string       - Region used for signing, "us-east-1" when empty
+  - Bucket:    string       - Name of the bucket
+  - AccessKey: string       - Access key ID
+  - SecretKey: string       - Secret access key
+  - PublicURL: string       - URL the bucket is publicly served from, defaults to the object URL
+  - PathStyle: bool         - Address the bucket as Endpoint/Bucket instead of Bucket.Endpoint, needed by most local stand-ins
+  - Client:    *http.Client - Client used for requests, http.DefaultClient when nil
+*/
+type S3Storage struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string
+	PathStyle bool
+	Client    *http.Client
+}
+
+func (storage *S3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	request, err := storage.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	return storage.do(request, data)
+}
+
+func (storage *S3Storage) Delete(ctx context.Context, key string) error {
+	request, err := storage.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	return storage.do(request, nil)
+}
+
+func (storage *S3Storage) URL(key string) string {
+	if storage.PublicURL != "" {
+		return strings.TrimSuffix(storage.PublicURL, "/") + "/" + key
+	}
+
+	objectURL, err := storage.objectURL(key)
+	if err != nil {
+		return ""
+	}
+	return objectURL.String()
+}
+
+// Builds the URL of an object, in path or virtual-hosted style
+func (storage *S3Storage) objectURL(key string) (*url.URL, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(storage.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %q", storage.Endpoint)
+	}
+
+	if storage.PathStyle {
+		endpoint.Path += "/" + storage.Bucket + "/" + key
+	} else {
+		endpoint.Host = storage.Bucket + "." + endpoint.Host
+		endpoint.Path += "/" + key
+	}
+
+	return endpoint, nil
+}
+
+func (storage *S3Storage) newRequest(ctx context.Context, method string, key string, data []byte) (*http.Request, error) {
+	objectURL, err := storage.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, objectURL.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create S3 request: %w", err)
+	}
+
+	return request, nil
+}
+
+// Signs and sends a request, treating any non-2xx status as an error
+func (storage *S3Storage) do(request *http.Request, data []byte) error {
+	storage.sign(request, data, time.Now().UTC())
+
+	client := storage.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("could not reach S3: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("S3 %s %s failed with status %d: %s", request.Method, request.URL.Path, response.StatusCode, message)
+	}
+
+	return nil
+}
+
+/*
+Signs a request with AWS Signature Version 4
+
+Objectives:
+  - Hash the payload and set the x-amz-date and x-amz-content-sha256 headers
+  - Build the canonical request from the method, path, query and signed headers
+  - Derive the signing key from the secret key, date, region and service
+  - Set the Authorization header
+
+Params:
+  - request: The request to sign
+  - payload: The request body, nil when there is none
+  - now:     The signing time in UTC
+
+Returns:
+  - No return value
+*/
+func (storage *S3Storage) sign(request *http.Request, payload []byte, now time.Time) {
+	region := storage.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", request.URL.Host, payloadHash, amzDate)
+	if contentType := request.Header.Get("Content-Type"); contentType != "" {
+		signedHeaders = "content-type;" + signedHeaders
+		canonicalHeaders = fmt.Sprintf("content-type:%s\n", contentType) + canonicalHeaders
+	}
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+storage.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		storage.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
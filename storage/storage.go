@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+/*
+Storage Interface
+
+Defines anything that can store uploaded files under a key and serve them
+from a public URL
+*/
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+/*
+Creates the storage configured in the environment
+
+Objectives:
+  - Use an S3-compatible bucket when STORAGE_DRIVER is "s3"
+  - Otherwise store files in MEDIA_DIR, served by the API under /media
+
+Params:
+  - No parameters
+
+Returns:
+  - The configured storage
+*/
+func FromEnvironment() Storage {
+	// Load environment variables from .env file in development
+	if env := os.Getenv("ENVIRONMENT"); env != "production" {
+		err := godotenv.Load()
+		if err != nil {
+			log.Println("[FAIL]: could not load environment variables:", err)
+		}
+	}
+
+	if os.Getenv("STORAGE_DRIVER") == "s3" {
+		return &S3Storage{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		}
+	}
+
+	directory := os.Getenv("MEDIA_DIR")
+	if directory == "" {
+		directory = "uploads"
+	}
+
+	return &LocalStorage{
+		Directory: directory,
+		BaseURL:   strings.TrimSuffix(os.Getenv("APP_URL"), "/") + "/media",
+	}
+}
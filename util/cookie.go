@@ -2,6 +2,7 @@ package util
 
 import (
 	"net/http"
+	"time"
 )
 
 /*
@@ -29,6 +30,33 @@ func CreateTokenCookie(token string) http.Cookie {
 	return cookie
 }
 
+/*
+Creates a cookie with the refresh token that lives until the token expires
+
+Objectives:
+  - Create a cookie with the value set to the refresh token
+  - Expire the cookie at the same time as the refresh token
+
+Params:
+  - token:     An opaque refresh token to create the cookie with
+  - expiresAt: The time at which the refresh token expires
+
+Returns:
+  - A http cookie with the refresh token and configurations
+*/
+func CreateRefreshTokenCookie(token string, expiresAt time.Time) http.Cookie {
+	cookie := http.Cookie{
+		Name:     "refresh_token",
+		Value:    token,
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		HttpOnly: true,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+	}
+	return cookie
+}
+
 /*
 Expires any cookies saved in the client
 
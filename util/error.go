@@ -12,6 +12,8 @@ var (
 	ErrEmptyFields    ValidationError = errors.New("one or more fields are empty")
 	ErrEmailInvalid   ValidationError = errors.New("invalid email provided")
 	ErrPasswordLength ValidationError = errors.New("password length must be at least 8 characters long")
+	ErrFieldTooLong   ValidationError = errors.New("one or more fields are too long")
+	ErrURLInvalid     ValidationError = errors.New("invalid url provided")
 )
 
 /*
@@ -22,8 +22,34 @@ func GenerateStateOauthCookie(w http.ResponseWriter) string {
 	b := make([]byte, 16)
 	rand.Read(b)
 	state := base64.URLEncoding.EncodeToString(b)
-	cookie := http.Cookie{Name: "oauthstate", Value: state, Expires: expiration}
+	cookie := http.Cookie{Name: "oauthstate", Value: state, Path: "/auth/oauth", Expires: expiration, HttpOnly: true}
 	http.SetCookie(w, &cookie)
 
 	return state
 }
+
+/*
+Marks an oauth flow as linking a provider to the signed-in user
+
+The cookie holds the oauth state of the flow, so it only applies to the
+callback of the flow it was created for.
+
+Params:
+  - w:     A http response writer
+  - state: The oauth state of the flow
+
+Returns:
+  - No return value
+*/
+func SetOauthLinkCookie(w http.ResponseWriter, state string) {
+	cookie := http.Cookie{
+		Name:     "oauthlink",
+		Value:    state,
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		HttpOnly: true,
+		MaxAge:   600, // Lives for 10 minutes
+	}
+	http.SetCookie(w, &cookie)
+}
@@ -0,0 +1,368 @@
+package util
+
+import (
+	"time"
+
+	"github.com/ecofriends/authentication-backend/model"
+	"github.com/google/uuid"
+)
+
+/*
+Public user payload struct, safe to show to anyone
+
+Fields:
+  - ID:             uuid
+  - Username:       string
+  - DisplayName:    string
+  - Bio:            string
+  - Location:       string
+  - AvatarURL:      string
+  - JoinedAt:       time.Time
+  - PostCount:      int
+  - CommentCount:   int
+  - LikesReceived:  int
+  - FollowerCount:  int
+  - FollowingCount: int
+  - Badges:         []model.Badge (earliest first)
+*/
+type PublicUserPayload struct {
+	ID             uuid.UUID     `json:"id"`
+	Username       string        `json:"username"`
+	DisplayName    string        `json:"display_name"`
+	Bio            string        `json:"bio"`
+	Location       string        `json:"location"`
+	AvatarURL      string        `json:"avatar_url"`
+	JoinedAt       time.Time     `json:"joined_at"`
+	PostCount      int           `json:"post_count"`
+	CommentCount   int           `json:"comment_count"`
+	LikesReceived  int           `json:"likes_received"`
+	FollowerCount  int           `json:"follower_count"`
+	FollowingCount int           `json:"following_count"`
+	Badges         []model.Badge `json:"badges"`
+}
+
+/*
+Post payload struct
+
+Fields:
+  - ID:        int
+  - UserID:    string
+  - Text:      string
+  - LikeCount: int
+  - CreatedAt: time.Time
+  - UpdatedAt: *time.Time (when the post was last edited)
+  - Edited:    bool
+  - Media:     []MediaPayload
+  - Reactions: model.ReactionSummary
+*/
+type PostPayload struct {
+	ID        int                   `json:"id"`
+	UserID    string                `json:"user_id"`
+	Text      string                `json:"text"`
+	LikeCount int                   `json:"like_count"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt *time.Time            `json:"updated_at,omitempty"`
+	Edited    bool                  `json:"edited"`
+	Media     []MediaPayload        `json:"media"`
+	Reactions model.ReactionSummary `json:"reactions"`
+}
+
+/*
+Media payload struct
+
+Fields:
+  - ID:           int
+  - URL:          string
+  - ThumbnailURL: string
+  - MimeType:     string
+  - Width:        int
+  - Height:       int
+*/
+type MediaPayload struct {
+	ID           int    `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	MimeType     string `json:"mime_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+/*
+Feed post payload struct
+
+Fields:
+  - PostPayload: the post
+  - Source:      string ("following" or "popular")
+*/
+type FeedPostPayload struct {
+	PostPayload
+	Source string `json:"source"`
+}
+
+/*
+Search payload struct, the results of each searched type
+
+Fields:
+  - Posts:    []PostSearchPayload
+  - Comments: []CommentSearchPayload
+  - Users:    []model.UserSearchResult
+*/
+type SearchPayload struct {
+	Posts    []PostSearchPayload      `json:"posts"`
+	Comments []CommentSearchPayload   `json:"comments"`
+	Users    []model.UserSearchResult `json:"users"`
+}
+
+/*
+Post search payload struct
+
+Fields:
+  - PostPayload: the post
+  - Headline:    string (HTML escaped excerpt with the matches wrapped in <mark> tags)
+  - Rank:        float64
+*/
+type PostSearchPayload struct {
+	PostPayload
+	Headline string  `json:"headline"`
+	Rank     float64 `json:"rank"`
+}
+
+/*
+Comment search payload struct
+
+Fields:
+  - CommentPayload: the comment
+  - Headline:       string (HTML escaped excerpt with the matches wrapped in <mark> tags)
+  - Rank:           float64
+*/
+type CommentSearchPayload struct {
+	CommentPayload
+	Headline string  `json:"headline"`
+	Rank     float64 `json:"rank"`
+}
+
+/*
+Comment payload struct
+
+Fields:
+  - ID:        int
+  - UserID:    string
+  - Username:  string (only set when listing comments)
+  - PostID:     int
+  - ParentID:   *int (the comment this one replies to)
+  - Depth:      int
+  - ReplyCount: int
+  - Text:       string
+  - CreatedAt:  time.Time
+  - UpdatedAt:  *time.Time (when the comment was last edited)
+  - Edited:     bool
+  - Reactions:  model.ReactionSummary
+*/
+type CommentPayload struct {
+	ID         int                   `json:"id"`
+	UserID     string                `json:"user_id"`
+	Username   string                `json:"username,omitempty"`
+	PostID     int                   `json:"post_id"`
+	ParentID   *int                  `json:"parent_id"`
+	Depth      int                   `json:"depth"`
+	ReplyCount int                   `json:"reply_count"`
+	Text       string                `json:"text"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  *time.Time            `json:"updated_at,omitempty"`
+	Edited     bool                  `json:"edited"`
+	Reactions  model.ReactionSummary `json:"reactions"`
+}
+
+/*
+Notification payload struct
+
+Fields:
+  - ID:         int
+  - Type:       string
+  - Message:    string (e.g. "alex and 4 others reacted to your post")
+  - PostID:     *int
+  - CommentID:  *int
+  - Actors:     []model.NotificationActor (the most recent ones)
+  - ActorCount: int
+  - Read:       bool
+  - CreatedAt:  time.Time
+  - UpdatedAt:  time.Time (when the latest event happened)
+*/
+type NotificationPayload struct {
+	ID         int                       `json:"id"`
+	Type       string                    `json:"type"`
+	Message    string                    `json:"message"`
+	PostID     *int                      `json:"post_id,omitempty"`
+	CommentID  *int                      `json:"comment_id,omitempty"`
+	Actors     []model.NotificationActor `json:"actors"`
+	ActorCount int                       `json:"actor_count"`
+	Read       bool                      `json:"read"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	UpdatedAt  time.Time                 `json:"updated_at"`
+}
+
+/*
+Like payload struct
+
+Fields:
+  - UserID:    string
+  - PostID:    int
+  - CreatedAt: time.Time
+*/
+type LikePayload struct {
+	UserID    string    `json:"user_id"`
+	PostID    int       `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Builds the private payload of the signed-in user
+func NewUserPayload(user model.User) UserPayload {
+	return UserPayload{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+// Builds the public payload of a user from their profile, activity and badges
+func NewPublicUserPayload(profile model.Profile, stats model.UserStats, badges []model.Badge) PublicUserPayload {
+	return PublicUserPayload{
+		ID:             profile.UserID,
+		Username:       profile.Username,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		Location:       profile.Location,
+		AvatarURL:      profile.AvatarURL,
+		JoinedAt:       profile.JoinedAt,
+		PostCount:      stats.PostCount,
+		CommentCount:   stats.CommentCount,
+		LikesReceived:  stats.LikesReceived,
+		FollowerCount:  stats.FollowerCount,
+		FollowingCount: stats.FollowingCount,
+		Badges:         badges,
+	}
+}
+
+// Builds the payload of a post
+func NewPostPayload(post model.Post) PostPayload {
+	return PostPayload{
+		ID:        post.ID,
+		UserID:    post.UserID,
+		Text:      post.Text,
+		LikeCount: post.LikeCount,
+		CreatedAt: post.CreatedAt,
+		UpdatedAt: post.UpdatedAt,
+		Edited:    post.UpdatedAt != nil,
+		Media:     NewMediaPayloads(post.Media),
+		Reactions: newReactionSummary(post.Reactions),
+	}
+}
+
+// Builds the payload of an image attached to a post
+func NewMediaPayload(media model.Media) MediaPayload {
+	return MediaPayload{
+		ID:           media.ID,
+		URL:          media.URL,
+		ThumbnailURL: media.ThumbnailURL,
+		MimeType:     media.MimeType,
+		Width:        media.Width,
+		Height:       media.Height,
+	}
+}
+
+// Builds the payloads of the images of a post, never nil so it encodes as []
+func NewMediaPayloads(media []model.Media) []MediaPayload {
+	payloads := make([]MediaPayload, 0, len(media))
+	for _, item := range media {
+		payloads = append(payloads, NewMediaPayload(item))
+	}
+	return payloads
+}
+
+// Builds the payloads of the posts of a feed
+func NewFeedPostPayloads(posts []model.FeedPost) []FeedPostPayload {
+	payloads := make([]FeedPostPayload, 0, len(posts))
+	for _, post := range posts {
+		payloads = append(payloads, FeedPostPayload{
+			PostPayload: NewPostPayload(post.Post),
+			Source:      post.Source,
+		})
+	}
+	return payloads
+}
+
+// Builds the payload of a post found by a search
+func NewPostSearchPayload(result model.PostSearchResult) PostSearchPayload {
+	return PostSearchPayload{
+		PostPayload: NewPostPayload(result.Post),
+		Headline:    result.Headline,
+		Rank:        result.Rank,
+	}
+}
+
+// Builds the payload of a comment found by a search
+func NewCommentSearchPayload(result model.CommentSearchResult) CommentSearchPayload {
+	return CommentSearchPayload{
+		CommentPayload: NewCommentWithUserPayload(result.CommentWithUser),
+		Headline:       result.Headline,
+		Rank:           result.Rank,
+	}
+}
+
+// Builds the payload of a comment
+func NewCommentPayload(comment model.Comment) CommentPayload {
+	return CommentPayload{
+		ID:         comment.ID,
+		UserID:     comment.UserID,
+		PostID:     comment.PostID,
+		ParentID:   comment.ParentID,
+		Depth:      comment.Depth,
+		ReplyCount: comment.ReplyCount,
+		Text:       comment.Text,
+		CreatedAt:  comment.CreatedAt,
+		UpdatedAt:  comment.UpdatedAt,
+		Edited:     comment.UpdatedAt != nil,
+		Reactions:  newReactionSummary(comment.Reactions),
+	}
+}
+
+// Builds the payload of a comment with its author
+func NewCommentWithUserPayload(comment model.CommentWithUser) CommentPayload {
+	payload := NewCommentPayload(comment.Comment)
+	payload.Username = comment.Username
+	return payload
+}
+
+// Returns the loaded reactions, or an empty summary when they weren't loaded
+func newReactionSummary(summary *model.ReactionSummary) model.ReactionSummary {
+	if summary == nil {
+		return model.NewReactionSummary()
+	}
+	return *summary
+}
+
+// Builds the payload of a notification
+func NewNotificationPayload(notification model.Notification) NotificationPayload {
+	return NotificationPayload{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		Message:    notification.Message(),
+		PostID:     notification.PostID,
+		CommentID:  notification.CommentID,
+		Actors:     notification.Actors,
+		ActorCount: notification.ActorCount,
+		Read:       notification.ReadAt != nil,
+		CreatedAt:  notification.CreatedAt,
+		UpdatedAt:  notification.UpdatedAt,
+	}
+}
+
+// Builds the payload of a like
+func NewLikePayload(like model.PostLike) LikePayload {
+	return LikePayload{
+		UserID:    like.UserID,
+		PostID:    like.PostID,
+		CreatedAt: like.CreatedAt,
+	}
+}
@@ -1,6 +1,9 @@
 package util
 
 import (
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/mrz1836/go-sanitize"
 )
@@ -53,20 +56,151 @@ func (sanitizable *SignInRequestBody) Sanitize() {
 	sanitizable.Password = sanitize.AlphaNumeric(sanitizable.Password, false)
 }
 
+/*
+Forgot password request body
+
+Fields:
+  - Email: string
+*/
+type ForgotPasswordRequestBody struct {
+	Email string `json:"email"`
+}
+
+// Implement the sanitize function for the forgot password request body
+func (sanitizable *ForgotPasswordRequestBody) Sanitize() {
+	sanitizable.Email = sanitize.Email(sanitizable.Email, false)
+}
+
+/*
+Reset password request body
+
+Fields:
+  - Token:    string
+  - Password: string
+*/
+type ResetPasswordRequestBody struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Implement the sanitize function for the reset password request body
+func (sanitizable *ResetPasswordRequestBody) Sanitize() {
+	sanitizable.Password = sanitize.AlphaNumeric(sanitizable.Password, false)
+}
+
+/*
+Update user request body, omitted fields are left unchanged
+
+Fields:
+  - Username: *string
+  - Email:    *string
+*/
+type UpdateUserRequestBody struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+}
+
+// Implement the sanitize function for the update user request body
+func (sanitizable *UpdateUserRequestBody) Sanitize() {
+	if sanitizable.Email != nil {
+		email := sanitize.Email(*sanitizable.Email, false)
+		sanitizable.Email = &email
+	}
+	if sanitizable.Username != nil {
+		username := sanitize.Alpha(*sanitizable.Username, false)
+		sanitizable.Username = &username
+	}
+}
+
+/*
+Update profile request body, omitted fields are left unchanged and empty
+strings clear a field
+
+Fields:
+  - DisplayName: *string
+  - Bio:         *string
+  - Location:    *string
+  - AvatarURL:   *string
+*/
+type UpdateProfileRequestBody struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	Location    *string `json:"location"`
+	AvatarURL   *string `json:"avatar_url"`
+}
+
+// Implement the sanitize function for the update profile request body
+func (sanitizable *UpdateProfileRequestBody) Sanitize() {
+	if sanitizable.DisplayName != nil {
+		displayName := strings.TrimSpace(sanitize.SingleLine(sanitize.XSS(*sanitizable.DisplayName)))
+		sanitizable.DisplayName = &displayName
+	}
+	if sanitizable.Bio != nil {
+		bio := strings.TrimSpace(sanitize.XSS(*sanitizable.Bio))
+		sanitizable.Bio = &bio
+	}
+	if sanitizable.Location != nil {
+		location := strings.TrimSpace(sanitize.SingleLine(sanitize.XSS(*sanitizable.Location)))
+		sanitizable.Location = &location
+	}
+	if sanitizable.AvatarURL != nil {
+		avatarURL := sanitize.URL(*sanitizable.AvatarURL)
+		sanitizable.AvatarURL = &avatarURL
+	}
+}
+
+/*
+Change password request body
+
+Fields:
+  - CurrentPassword: string
+  - NewPassword:     string
+*/
+type ChangePasswordRequestBody struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Implement the sanitize function for the change password request body
+func (sanitizable *ChangePasswordRequestBody) Sanitize() {
+	sanitizable.CurrentPassword = sanitize.AlphaNumeric(sanitizable.CurrentPassword, false)
+	sanitizable.NewPassword = sanitize.AlphaNumeric(sanitizable.NewPassword, false)
+}
+
+/*
+Delete account request body
+
+Fields:
+  - Password: string (required when the account has a password)
+*/
+type DeleteAccountRequestBody struct {
+	Password string `json:"password"`
+}
+
+// Implement the sanitize function for the delete account request body
+func (sanitizable *DeleteAccountRequestBody) Sanitize() {
+	sanitizable.Password = sanitize.AlphaNumeric(sanitizable.Password, false)
+}
+
 type CreatePostRequestBody struct {
 	UserID uuid.UUID `json:"user_id"`
 	Text   string    `json:"text"`
 }
 
+type UpdatePostRequestBody struct {
+	Text string `json:"text"`
+}
+
 type DeletePostRequestBody struct {
 	PostID int       `json:"post_id"`
 	UserID uuid.UUID `json:"user_id"`
 }
 
 type CreateCommentRequestBody struct {
-	UserID uuid.UUID `json:"user_id"`
-	PostID int       `json:"post_id"`
-	Text   string    `json:"text"`
+	UserID   uuid.UUID `json:"user_id"`
+	PostID   int       `json:"post_id"`
+	ParentID *int      `json:"parent_id,omitempty"`
+	Text     string    `json:"text"`
 }
 
 type DeleteCommentRequestBody struct {
@@ -80,6 +214,28 @@ type UpdateCommentRequestBody struct {
 	Text      string    `json:"text"`
 }
 
+type ReactionRequestBody struct {
+	Type string `json:"type"`
+}
+
+type LogEcoActionRequestBody struct {
+	ActionType  string     `json:"action_type"`
+	Quantity    float64    `json:"quantity"`
+	PostID      *int       `json:"post_id"`
+	PerformedAt *time.Time `json:"performed_at"`
+}
+
+type CreateChallengeRequestBody struct {
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	GoalType     string    `json:"goal_type"`
+	ActionType   *string   `json:"action_type"`
+	Tag          *string   `json:"tag"`
+	GoalQuantity float64   `json:"goal_quantity"`
+}
+
 type LikePostRequestBody struct {
 	UserID uuid.UUID `json:"user_id"`
 	PostID int       `json:"post_id"`
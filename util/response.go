@@ -26,14 +26,16 @@ type Response struct {
 User payload struct
 
 Fields:
-  - ID:       uuid
-  - Username: string
-  - Email:    string
+  - ID:            uuid
+  - Username:      string
+  - Email:         string
+  - EmailVerified: bool
 */
 type UserPayload struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
 }
 
 /*
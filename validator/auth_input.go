@@ -41,3 +41,57 @@ func ValidateUserInput(body *util.SignUpRequestBody) error {
 
 	return nil
 }
+
+/*
+Validates a new password
+
+Objectives:
+  - The password must not be empty
+  - Password length must be at least 8 characters
+
+Params:
+  - password: The new password
+
+Returns:
+  - An error if the password doesn't pass all checks
+*/
+func ValidatePassword(password string) error {
+	if password == "" {
+		log.Println("[FAIL]: password is empty")
+		return util.ErrEmptyFields
+	}
+
+	if len(password) < 8 {
+		log.Println("[FAIL]: password field must contain at least 8 characters")
+		return util.ErrPasswordLength
+	}
+
+	return nil
+}
+
+/*
+Validates user input from the update user request body
+
+Objectives:
+  - Provided fields must not be empty
+  - A provided email must be valid
+
+Params:
+  - body: Update user request body, omitted fields are not checked
+
+Returns:
+  - An error if the request body doesn't pass all checks
+*/
+func ValidateUserUpdate(body *util.UpdateUserRequestBody) error {
+	if (body.Username != nil && *body.Username == "") || (body.Email != nil && *body.Email == "") {
+		log.Println("[FAIL]: username or email is empty")
+		return util.ErrEmptyFields
+	}
+
+	if body.Email != nil && !util.IsValidEmail(*body.Email) {
+		log.Println("[FAIL]: invalid email provided")
+		return util.ErrEmailInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package validators
+
+import (
+	"log"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/ecofriends/authentication-backend/util"
+)
+
+// Maximum lengths of the profile fields, in characters
+const (
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 280
+	MaxLocationLength    = 100
+	MaxAvatarURLLength   = 2048
+)
+
+/*
+Validates user input from the update profile request body
+
+Objectives:
+  - Provided fields must not exceed their maximum length
+  - A provided avatar URL must be an absolute http(s) URL, or empty to clear it
+
+Params:
+  - body: Update profile request body, omitted fields are not checked
+
+Returns:
+  - An error if the request body doesn't pass all checks
+*/
+func ValidateProfileUpdate(body *util.UpdateProfileRequestBody) error {
+	if tooLong(body.DisplayName, MaxDisplayNameLength) ||
+		tooLong(body.Bio, MaxBioLength) ||
+		tooLong(body.Location, MaxLocationLength) ||
+		tooLong(body.AvatarURL, MaxAvatarURLLength) {
+		log.Println("[FAIL]: profile field exceeds its maximum length")
+		return util.ErrFieldTooLong
+	}
+
+	if body.AvatarURL != nil && *body.AvatarURL != "" {
+		avatarURL, err := url.Parse(*body.AvatarURL)
+		if err != nil || (avatarURL.Scheme != "http" && avatarURL.Scheme != "https") || avatarURL.Host == "" {
+			log.Println("[FAIL]: invalid avatar url provided")
+			return util.ErrURLInvalid
+		}
+	}
+
+	return nil
+}
+
+// Reports whether a provided field is longer than the limit
+func tooLong(field *string, limit int) bool {
+	return field != nil && utf8.RuneCountInString(*field) > limit
+}